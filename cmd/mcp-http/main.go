@@ -21,10 +21,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 
@@ -33,21 +37,47 @@ import (
 )
 
 func main() {
+	// Select the tools to register
+	var tools mcp.ToolFlags
+	tools.Register(flag.CommandLine)
+	flag.Parse()
+	toolFilter, err := tools.Option()
+	if err != nil {
+		log.Fatalf("Invalid tool configuration: %v", err)
+	}
+
 	// Create marketplace client
 	client := marketplace.NewClient()
 
-	// Create MCP server
-	mcpServer := mcp.NewServer(client)
+	// Create MCP server, with package subscriptions and the background sync
+	// worker if configured
+	opts := append(mcp.OptionsFromEnv(), toolFilter)
+	opts = append(opts, mcp.SyncOptionsFromEnv()...)
+	mcpServer := mcp.NewServer(client, append(opts, mcp.WatchOptionsFromEnv()...)...)
+
+	// Serve the MCP endpoint alongside a health endpoint
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", health(mcpServer))
 
-	// Create HTTP server using mcp-go framework with stateless mode
+	// Create HTTP server using mcp-go framework. Requests without a session
+	// are accepted as in stateless mode, but session IDs are issued so that
+	// clients can subscribe to packages.
 	httpServer := server.NewStreamableHTTPServer(
 		mcpServer.GetMCPServer(),
-		server.WithStateLess(true), // Enable stateless mode for simple HTTP API usage
+		server.WithSessionIdManager(mcpServer.SessionIDManager()),
+		server.WithStreamableHTTPServer(&http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}),
 	)
+	mux.Handle("/mcp", mcpServer.SubscriptionHandler(httpServer))
 
 	// Setup signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// Keep the indexes warm in the background
+	go mcpServer.RunSync(ctx)
+
+	// Notify subscribers of new package versions
+	go mcpServer.RunWatch(ctx)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -70,6 +100,7 @@ func main() {
 
 	log.Printf("Starting Upbound Marketplace MCP HTTP Server on %s...", addr)
 	log.Printf("MCP endpoint will be available at http://localhost%s/mcp", addr)
+	log.Printf("Health endpoint will be available at http://localhost%s/healthz", addr)
 
 	if err := httpServer.Start(addr); err != nil {
 		cancel()
@@ -79,3 +110,18 @@ func main() {
 	cancel()
 	log.Println("Server stopped")
 }
+
+// health reports that the server is up, along with the status of the
+// background sync worker if one is configured.
+func health(s *mcp.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		body := map[string]any{"status": "ok"}
+		if status, ok := s.SyncStatus(); ok {
+			body["sync"] = status
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			log.Printf("Error writing health response: %v", err)
+		}
+	}
+}
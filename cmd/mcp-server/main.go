@@ -21,6 +21,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -31,11 +32,20 @@ import (
 )
 
 func main() {
+	// Select the tools to register
+	var tools mcp.ToolFlags
+	tools.Register(flag.CommandLine)
+	flag.Parse()
+	toolFilter, err := tools.Option()
+	if err != nil {
+		log.Fatalf("Invalid tool configuration: %v", err)
+	}
+
 	// Create marketplace client
 	client := marketplace.NewClient()
 
 	// Create MCP server
-	server := mcp.NewServer(client)
+	server := mcp.NewServer(client, append(mcp.OptionsFromEnv(), toolFilter)...)
 
 	// Setup signal handling
 	ctx, cancel := context.WithCancel(context.Background())
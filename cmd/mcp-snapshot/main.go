@@ -0,0 +1,220 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package main is the entrypoint to the snapshot command, which exports
+packages to snapshots that servers can load without access to the
+marketplace, and compares snapshots.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/mcp"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+)
+
+const usage = `Usage:
+  mcp-snapshot export -o FILE [-account ACCOUNT]... [-f FILE] [PACKAGE...]
+  mcp-snapshot diff [-format markdown|json] [-top N] OLD NEW
+
+export writes packages to a snapshot archive. Packages are references such as
+upbound/provider-aws-s3:v1.20.0; a reference without a version exports the
+latest version. -account exports the latest version of every package in an
+account, and -f reads references from a file, one per line.
+
+diff reports the packages added, removed and deprecated, the new versions,
+tier changes and download and star changes between two snapshots.
+`
+
+// stringsFlag is a flag that may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	case "diff":
+		err = runDiff(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", os.Args[1], err)
+	}
+}
+
+// runExport runs the export command.
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	out := fs.String("o", "", "File to write the snapshot to")
+	file := fs.String("f", "", "File of package references, one per line")
+	var accounts stringsFlag
+	fs.Var(&accounts, "account", "Account whose packages to export; may be repeated")
+	_ = fs.Parse(args)
+	if *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return export(ctx, *out, *file, accounts, fs.Args())
+}
+
+// runDiff runs the diff command.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	format := fs.String("format", "markdown", "Output format, markdown or json")
+	top := fs.Int("top", 20, "Number of download and star changes to list in markdown, or 0 for all")
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 || (*format != "markdown" && *format != "json") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	from, err := snapshot.OpenManifest(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := snapshot.OpenManifest(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	r := snapshot.Diff(from, to)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	_, err = fmt.Fprint(os.Stdout, r.Markdown(*top))
+	return err
+}
+
+// export writes a snapshot of the selected packages to out.
+func export(ctx context.Context, out, file string, accounts, refs []string) error {
+	if file != "" {
+		more, err := readRefs(file)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, more...)
+	}
+
+	// Export from the same catalogs the server is configured with.
+	server := mcp.NewServer(marketplace.NewClient(), mcp.OptionsFromEnv()...)
+	e := snapshot.NewExporter(server.Catalog())
+
+	var sel []snapshot.Selection
+	for _, a := range accounts {
+		s, err := e.List(ctx, a)
+		if err != nil {
+			return err
+		}
+		sel = append(sel, s...)
+	}
+	for _, r := range refs {
+		ref, err := marketplace.ParseReference(r)
+		if err != nil {
+			return fmt.Errorf("invalid package %q: %w", r, err)
+		}
+		if ref.Version == "" && ref.Digest != "" {
+			return fmt.Errorf("package %s pins a digest but no version: catalogs look packages up by version", r)
+		}
+		s := snapshot.Selection{Account: ref.Account, Repository: ref.Repository}
+		if ref.Version != "" {
+			s.Versions = []string{ref.Version}
+		}
+		sel = append(sel, s)
+	}
+	if len(sel) == 0 {
+		return fmt.Errorf("no packages selected")
+	}
+
+	f, err := os.Create(out) //nolint:gosec // The output path is supplied by the user.
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	m, err := e.Export(ctx, f, sel)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write snapshot: %w", cerr)
+	}
+	if err != nil {
+		_ = os.Remove(out)
+		return err
+	}
+
+	versions := 0
+	for _, p := range m.Packages {
+		versions += len(p.Versions)
+	}
+	for _, w := range m.Warnings {
+		log.Printf("Warning: Not exported: %s", w)
+	}
+	log.Printf("Exported %d versions of %d packages to %s", versions, len(m.Packages), out)
+	return nil
+}
+
+// readRefs reads package references from a file, one per line. Blank lines
+// and lines starting with # are ignored.
+func readRefs(path string) ([]string, error) {
+	f, err := os.Open(path) //nolint:gosec // The path is supplied by the user.
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package list: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+
+	var refs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read package list: %w", err)
+	}
+	return refs, nil
+}
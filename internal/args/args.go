@@ -0,0 +1,334 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package args
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// An Error reports an invalid argument.
+type Error struct {
+	// Arg is the path of the argument, such as size or resources[0].kind.
+	Arg string
+
+	// Reason is why the argument is invalid, such as "is required".
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid argument %s: %s", e.Arg, e.Reason)
+}
+
+// field is an argument bound to a struct field.
+type field struct {
+	index    []int
+	name     string
+	desc     string
+	required bool
+	enum     []string
+	def      string
+	min, max *float64
+}
+
+// fields caches the arguments of each struct type.
+var fields sync.Map
+
+// fieldsOf returns the arguments bound to the fields of a struct type,
+// including the fields of embedded structs, panicking if they are tagged
+// incorrectly.
+func fieldsOf(t reflect.Type) []field {
+	if fs, ok := fields.Load(t); ok {
+		return fs.([]field)
+	}
+	var out []field
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("arg")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		f := field{
+			index:    sf.Index,
+			name:     name,
+			desc:     sf.Tag.Get("desc"),
+			required: opts == "required",
+			def:      sf.Tag.Get("default"),
+		}
+		if e := sf.Tag.Get("enum"); e != "" {
+			f.enum = strings.Split(e, ",")
+		}
+		f.min = bound(t, sf, "min")
+		f.max = bound(t, sf, "max")
+		out = append(out, f)
+	}
+	fields.Store(t, out)
+	return out
+}
+
+// bound returns the numeric bound a field is tagged with, if any.
+func bound(t reflect.Type, sf reflect.StructField, tag string) *float64 {
+	v, ok := sf.Tag.Lookup(tag)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid %s tag on %s.%s: %v", tag, t, sf.Name, err))
+	}
+	return &n
+}
+
+// Schema returns the JSON Schema of the arguments bound to the struct T.
+func Schema[T any]() mcp.ToolInputSchema {
+	props, required := properties(reflect.TypeFor[T]())
+	return mcp.ToolInputSchema{Type: "object", Properties: props, Required: required}
+}
+
+// properties returns the property schemas and required properties of a
+// struct type.
+func properties(t reflect.Type) (map[string]any, []string) {
+	props := map[string]any{}
+	var required []string
+	for _, f := range fieldsOf(t) {
+		ft := t.FieldByIndex(f.index).Type
+		p := typeSchema(ft)
+		if f.desc != "" {
+			p["description"] = f.desc
+		}
+		if f.enum != nil {
+			p["enum"] = f.enum
+		}
+		if f.def != "" {
+			v, err := convert(f.def, ft)
+			if err != nil {
+				panic(fmt.Sprintf("invalid default of argument %s: %v", f.name, err))
+			}
+			p["default"] = v.Interface()
+		}
+		if f.min != nil {
+			p["minimum"] = *f.min
+		}
+		if f.max != nil {
+			p["maximum"] = *f.max
+		}
+		props[f.name] = p
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	return props, required
+}
+
+// typeSchema returns the schema of a Go type.
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props, required := properties(t)
+		s := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	default:
+		panic(fmt.Sprintf("arguments of type %s are not supported", t))
+	}
+}
+
+// Decode decodes the arguments of a call into v, which must be a pointer to
+// a struct. Absent arguments take their default value, and an argument that
+// is missing, of the wrong type, or outside its constraints is reported as an
+// *Error. Arguments that are not bound to a field are ignored.
+func Decode(arguments map[string]any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode arguments into %T", v)
+	}
+	return decodeStruct(arguments, rv.Elem(), "")
+}
+
+func decodeStruct(arguments map[string]any, rv reflect.Value, prefix string) error {
+	for _, f := range fieldsOf(rv.Type()) {
+		path := prefix + f.name
+		fv := rv.FieldByIndex(f.index)
+
+		raw, ok := arguments[f.name]
+		if !ok || raw == nil {
+			if f.required {
+				return &Error{Arg: path, Reason: "is required"}
+			}
+			if f.def == "" {
+				continue
+			}
+			raw = f.def
+		}
+		if err := decode(raw, fv, path); err != nil {
+			return err
+		}
+		if err := f.validate(fv, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode decodes a raw argument into a value.
+func decode(raw any, rv reflect.Value, path string) error {
+	t := rv.Type()
+	switch {
+	case t.Kind() == reflect.Pointer:
+		p := reflect.New(t.Elem())
+		if err := decode(raw, p.Elem(), path); err != nil {
+			return err
+		}
+		rv.Set(p)
+		return nil
+	case t.Kind() == reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			return &Error{Arg: path, Reason: "must be an array"}
+		}
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := decode(item, s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+		return nil
+	case t.Kind() == reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return &Error{Arg: path, Reason: "must be an object"}
+		}
+		return decodeStruct(obj, rv, path+".")
+	}
+	v, err := convert(raw, t)
+	if err != nil {
+		return &Error{Arg: path, Reason: err.Error()}
+	}
+	rv.Set(v)
+	return nil
+}
+
+// convert converts a raw scalar argument to a Go type. Strings are accepted
+// for numbers and booleans, since some clients send every argument as one.
+func convert(raw any, t reflect.Type) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.String:
+		if s, ok := raw.(string); ok {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("must be a string")
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			return reflect.ValueOf(b).Convert(t), nil
+		case string:
+			if v, err := strconv.ParseBool(b); err == nil {
+				return reflect.ValueOf(v).Convert(t), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("must be a boolean")
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, ok := number(raw)
+		if !ok || n != math.Trunc(n) {
+			return reflect.Value{}, fmt.Errorf("must be an integer")
+		}
+		return reflect.ValueOf(int64(n)).Convert(t), nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := number(raw)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a number")
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("arguments of type %s are not supported", t)
+	}
+}
+
+// number returns the value of a raw numeric argument.
+func number(raw any) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// validate checks a decoded value against the constraints of its argument.
+func (f field) validate(rv reflect.Value, path string) error {
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+		if f.required && s == "" {
+			return &Error{Arg: path, Reason: "is required"}
+		}
+		if f.enum != nil && s != "" && !slices.Contains(f.enum, s) {
+			return &Error{Arg: path, Reason: "must be one of " + strings.Join(f.enum, ", ")}
+		}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		n := 0.0
+		if rv.CanInt() {
+			n = float64(rv.Int())
+		} else {
+			n = rv.Float()
+		}
+		if f.min != nil && n < *f.min {
+			return &Error{Arg: path, Reason: "must be at least " + strconv.FormatFloat(*f.min, 'f', -1, 64)}
+		}
+		if f.max != nil && n > *f.max {
+			return &Error{Arg: path, Reason: "must be at most " + strconv.FormatFloat(*f.max, 'f', -1, 64)}
+		}
+	case reflect.Slice:
+		if f.required && rv.Len() == 0 {
+			return &Error{Arg: path, Reason: "is required"}
+		}
+	}
+	return nil
+}
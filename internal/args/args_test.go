@@ -0,0 +1,172 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package args
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type common struct {
+	Account string `arg:"account" desc:"Account name"`
+}
+
+type resource struct {
+	APIVersion string `arg:"apiVersion,required"`
+	Kind       string `arg:"kind,required"`
+}
+
+type testArgs struct {
+	common
+	Query     string     `arg:"query,required" desc:"Search query"`
+	Size      int        `arg:"size" desc:"Number of results" default:"20" min:"1" max:"500"`
+	Tier      string     `arg:"tier" enum:"official,community"`
+	Public    *bool      `arg:"public"`
+	Tags      []string   `arg:"tags"`
+	Resources []resource `arg:"resources"`
+	Ignored   string
+}
+
+func TestSchema(t *testing.T) {
+	b, err := json.Marshal(Schema[testArgs]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"account": map[string]any{"type": "string", "description": "Account name"},
+			"query":   map[string]any{"type": "string", "description": "Search query"},
+			"size":    map[string]any{"type": "integer", "description": "Number of results", "default": 20.0, "minimum": 1.0, "maximum": 500.0},
+			"tier":    map[string]any{"type": "string", "enum": []any{"official", "community"}},
+			"public":  map[string]any{"type": "boolean"},
+			"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"resources": map[string]any{"type": "array", "items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"apiVersion": map[string]any{"type": "string"},
+					"kind":       map[string]any{"type": "string"},
+				},
+				"required": []any{"apiVersion", "kind"},
+			}},
+		},
+		"required": []any{"query"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Schema() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	yes := true
+	cases := map[string]struct {
+		args    map[string]any
+		want    testArgs
+		wantErr *Error
+	}{
+		"Defaults": {
+			args: map[string]any{"query": "s3"},
+			want: testArgs{Query: "s3", Size: 20},
+		},
+		"AllArguments": {
+			args: map[string]any{
+				"account":   "upbound",
+				"query":     "s3",
+				"size":      50.0,
+				"tier":      "official",
+				"public":    true,
+				"tags":      []any{"aws"},
+				"resources": []any{map[string]any{"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": "Bucket"}},
+				"unknown":   "ignored",
+			},
+			want: testArgs{
+				common:    common{Account: "upbound"},
+				Query:     "s3",
+				Size:      50,
+				Tier:      "official",
+				Public:    &yes,
+				Tags:      []string{"aws"},
+				Resources: []resource{{APIVersion: "s3.aws.upbound.io/v1beta1", Kind: "Bucket"}},
+			},
+		},
+		"StringlyTyped": {
+			args: map[string]any{"query": "s3", "size": "50", "public": "true"},
+			want: testArgs{Query: "s3", Size: 50, Public: &yes},
+		},
+		"MissingRequired": {
+			args:    map[string]any{},
+			wantErr: &Error{Arg: "query", Reason: "is required"},
+		},
+		"EmptyRequired": {
+			args:    map[string]any{"query": ""},
+			wantErr: &Error{Arg: "query", Reason: "is required"},
+		},
+		"WrongType": {
+			args:    map[string]any{"query": 1.0},
+			wantErr: &Error{Arg: "query", Reason: "must be a string"},
+		},
+		"NotAnInteger": {
+			args:    map[string]any{"query": "s3", "size": 1.5},
+			wantErr: &Error{Arg: "size", Reason: "must be an integer"},
+		},
+		"BelowMinimum": {
+			args:    map[string]any{"query": "s3", "size": 0.0},
+			wantErr: &Error{Arg: "size", Reason: "must be at least 1"},
+		},
+		"AboveMaximum": {
+			args:    map[string]any{"query": "s3", "size": 501.0},
+			wantErr: &Error{Arg: "size", Reason: "must be at most 500"},
+		},
+		"NotInEnum": {
+			args:    map[string]any{"query": "s3", "tier": "gold"},
+			wantErr: &Error{Arg: "tier", Reason: "must be one of official, community"},
+		},
+		"NestedRequired": {
+			args:    map[string]any{"query": "s3", "resources": []any{map[string]any{"apiVersion": "v1"}}},
+			wantErr: &Error{Arg: "resources[0].kind", Reason: "is required"},
+		},
+		"NotAnArray": {
+			args:    map[string]any{"query": "s3", "tags": "aws"},
+			wantErr: &Error{Arg: "tags", Reason: "must be an array"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got testArgs
+			err := Decode(tc.args, &got)
+			if tc.wantErr != nil {
+				var e *Error
+				if !errors.As(err, &e) || *e != *tc.wantErr {
+					t.Fatalf("Decode() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
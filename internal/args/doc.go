@@ -0,0 +1,34 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package args binds the arguments of MCP tool calls to Go structs.
+
+The arguments of a tool are declared as the fields of a struct, tagged with
+the argument's name and constraints:
+
+	type searchArgs struct {
+		Query string `arg:"query,required" desc:"Search query"`
+		Size  int    `arg:"size" desc:"Number of results" default:"20" min:"1" max:"500"`
+		Tier  string `arg:"tier" desc:"Package tier" enum:"official,partner,community"`
+	}
+
+Schema generates the JSON Schema of a tool's arguments from the struct, and
+Decode decodes and validates the arguments of a call into it, reporting the
+first invalid argument as an *Error. Embedded structs contribute their fields,
+so that arguments shared by several tools are declared once.
+*/
+package args
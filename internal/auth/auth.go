@@ -21,6 +21,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -53,6 +54,26 @@ type Token struct {
 	TokenType   string `json:"token_type"`   //nolint:tagliatelle // External API.
 }
 
+// ErrNoProfile is returned, wrapped, when the UP CLI has no profile to read a
+// session from: there is no config, no default profile or no session in it.
+// Other errors, such as an unreadable config, leave it unknown whether a
+// session exists.
+var ErrNoProfile = errors.New("no UP CLI profile")
+
+// noProfileError is an error that the UP CLI has no profile.
+type noProfileError struct {
+	msg string
+}
+
+func (e *noProfileError) Error() string { return e.msg }
+
+func (e *noProfileError) Is(target error) bool { return target == ErrNoProfile }
+
+// noProfile returns an ErrNoProfile error with the supplied message.
+func noProfile(format string, a ...any) error {
+	return &noProfileError{msg: fmt.Sprintf(format, a...)}
+}
+
 // Manager handles UP CLI configuration reading.
 type Manager struct {
 	configPath string
@@ -96,18 +117,18 @@ func (m *Manager) GetCurrentToken() (*Token, error) {
 	// Get the default profile name
 	defaultProfile := config.Upbound.Default
 	if defaultProfile == "" {
-		return nil, fmt.Errorf("no default profile set in UP CLI config")
+		return nil, noProfile("no default profile set in UP CLI config")
 	}
 
 	// Get the profile
 	profile, exists := config.Upbound.Profiles[defaultProfile]
 	if !exists {
-		return nil, fmt.Errorf("default profile '%s' not found in UP CLI config", defaultProfile)
+		return nil, noProfile("default profile '%s' not found in UP CLI config", defaultProfile)
 	}
 
 	// Check if session token exists
 	if profile.Session == "" {
-		return nil, fmt.Errorf("no session token found in profile '%s'. Please run 'up login' to authenticate", defaultProfile)
+		return nil, noProfile("no session token found in profile '%s'. Please run 'up login' to authenticate", defaultProfile)
 	}
 
 	return &Token{
@@ -126,12 +147,12 @@ func (m *Manager) GetTokenForProfile(profileName string) (*Token, error) {
 	// Get the profile
 	profile, exists := config.Upbound.Profiles[profileName]
 	if !exists {
-		return nil, fmt.Errorf("profile '%s' not found in UP CLI config", profileName)
+		return nil, noProfile("profile '%s' not found in UP CLI config", profileName)
 	}
 
 	// Check if session token exists
 	if profile.Session == "" {
-		return nil, fmt.Errorf("no session token found in profile '%s'. Please run 'up login' to authenticate", profileName)
+		return nil, noProfile("no session token found in profile '%s'. Please run 'up login' to authenticate", profileName)
 	}
 
 	return &Token{
@@ -150,13 +171,13 @@ func (m *Manager) GetCurrentProfile() (*Profile, error) {
 	// Get the default profile name
 	defaultProfile := config.Upbound.Default
 	if defaultProfile == "" {
-		return nil, fmt.Errorf("no default profile set in UP CLI config")
+		return nil, noProfile("no default profile set in UP CLI config")
 	}
 
 	// Get the profile
 	profile, exists := config.Upbound.Profiles[defaultProfile]
 	if !exists {
-		return nil, fmt.Errorf("default profile '%s' not found in UP CLI config", defaultProfile)
+		return nil, noProfile("default profile '%s' not found in UP CLI config", defaultProfile)
 	}
 
 	return &profile, nil
@@ -180,7 +201,7 @@ func (m *Manager) GetDefaultProfileName() (string, error) {
 	}
 
 	if config.Upbound.Default == "" {
-		return "", fmt.Errorf("no default profile set in UP CLI config")
+		return "", noProfile("no default profile set in UP CLI config")
 	}
 
 	return config.Upbound.Default, nil
@@ -252,7 +273,7 @@ func (m *Manager) RefreshToken(_ any) (*Token, error) {
 func (m *Manager) loadConfig() (*UPConfig, error) {
 	// Check if config file exists
 	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("UP CLI config not found at %s. Please run 'up login' first", m.configPath)
+		return nil, noProfile("UP CLI config not found at %s. Please run 'up login' first", m.configPath)
 	}
 
 	// Read config file
@@ -0,0 +1,236 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package budget
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BytesPerToken is the number of bytes a token is estimated to take.
+const BytesPerToken = 4
+
+// EstimateTokens estimates the number of tokens text takes.
+func EstimateTokens(text string) int {
+	return tokens(len(text))
+}
+
+// tokens estimates the number of tokens n bytes take.
+func tokens(n int) int {
+	return (n + BytesPerToken - 1) / BytesPerToken
+}
+
+// Bytes returns the byte budget of the supplied token and byte budgets,
+// either of which may be zero for no budget. It returns zero if neither is
+// set.
+func Bytes(maxTokens, maxBytes int) int {
+	b := maxTokens * BytesPerToken
+	if maxBytes > 0 && (b == 0 || maxBytes < b) {
+		b = maxBytes
+	}
+	return b
+}
+
+// A Kind of output determines where it is split.
+type Kind int
+
+// Kinds of output.
+const (
+	// Document output is markdown or plain text, split at sections, then
+	// blocks, then lines.
+	Document Kind = iota
+
+	// Data output is indented JSON or YAML, split before the line with the
+	// least indentation.
+	Data
+)
+
+// A Page is part of some output.
+type Page struct {
+	// Text is the text of the page, followed by a truncation marker unless
+	// it is the last page.
+	Text string
+
+	// Start and End are the byte offsets of the page in the output, and
+	// Total is the size of the output.
+	Start, End, Total int
+
+	// Cursor fetches the next page, or is empty if this is the last page.
+	Cursor string
+}
+
+// Truncated returns true if the page is not all of the output.
+func (p Page) Truncated() bool {
+	return p.Start > 0 || p.End < p.Total
+}
+
+// cursor locates a page of some output.
+type cursor struct {
+	Tool   string `json:"t"`
+	Offset int    `json:"o"`
+	Bytes  int    `json:"b"`
+	Hash   string `json:"h"`
+}
+
+// ErrCursorChanged is returned when the output a cursor pages through has
+// changed since the cursor was issued.
+var ErrCursorChanged = errors.New("the result changed since the cursor was issued; call again without a cursor")
+
+// Paginate returns the page of a tool's output that starts at the supplied
+// cursor, or at the start of the output if the cursor is empty, and is at
+// most maxBytes long, excluding the truncation marker. A cursor remembers
+// the budget it was issued with, which is used when maxBytes is zero.
+func Paginate(tool, text string, kind Kind, maxBytes int, c string) (Page, error) {
+	hash := digest(text)
+	start := 0
+	if c != "" {
+		cur, err := decodeCursor(c)
+		if err != nil {
+			return Page{}, err
+		}
+		if cur.Tool != tool {
+			return Page{}, fmt.Errorf("the cursor was issued by the %s tool, not %s", cur.Tool, tool)
+		}
+		if cur.Hash != hash || cur.Offset > len(text) {
+			return Page{}, ErrCursorChanged
+		}
+		start = cur.Offset
+		if maxBytes == 0 {
+			maxBytes = cur.Bytes
+		}
+	}
+
+	if maxBytes <= 0 || len(text)-start <= maxBytes {
+		return Page{Text: text[start:], Start: start, End: len(text), Total: len(text)}, nil
+	}
+
+	end := cut(text, start, start+maxBytes, kind)
+	next := encodeCursor(cursor{Tool: tool, Offset: end, Bytes: maxBytes, Hash: hash})
+	p := Page{Start: start, End: end, Total: len(text), Cursor: next}
+	p.Text = strings.TrimRight(text[start:end], "\n") + "\n\n" + marker(p)
+	return p, nil
+}
+
+// marker returns the truncation marker of a page.
+func marker(p Page) string {
+	return fmt.Sprintf("[truncated: showing bytes %d-%d of %d (about %d of %d tokens). Call the tool again with the same arguments and cursor %q for the next part.]",
+		p.Start, p.End, p.Total, tokens(p.End-p.Start), tokens(p.Total), p.Cursor)
+}
+
+// cut returns where to end a page that starts at start and may end at limit
+// at the latest. It cuts before the most significant line that starts in the
+// second half of the page, or at limit if no line does.
+func cut(text string, start, limit int, kind Kind) int {
+	best, bestLevel := -1, 0
+	floor := start + (limit-start)/2
+	for i := start; i < limit; {
+		nl := strings.IndexByte(text[i:limit], '\n')
+		if nl < 0 {
+			break
+		}
+		next := i + nl + 1
+		if next > floor && next <= limit {
+			if l := level(text, next, kind); best < 0 || l <= bestLevel {
+				best, bestLevel = next, l
+			}
+		}
+		i = next
+	}
+	if best > 0 {
+		return best
+	}
+	// No line starts in the window, so cut within a line without splitting
+	// a character.
+	for limit > start+1 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return limit
+}
+
+// level returns how significant a boundary before the line starting at i is.
+// Lower levels are more significant.
+func level(text string, i int, kind Kind) int {
+	line := text[i:]
+	if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+	}
+	if kind == Data {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "- ") {
+			// A YAML list item is nested one level deeper than its dash.
+			indent++
+		}
+		return indent
+	}
+
+	prevBlank := i >= 2 && text[i-2] == '\n'
+	switch {
+	case strings.HasPrefix(line, "# "), strings.HasPrefix(line, "## "):
+		return 0
+	case prevBlank && underlined(text, i+len(line)+1):
+		return 0
+	case prevBlank:
+		return 1
+	case line == "":
+		return 3
+	default:
+		return 2
+	}
+}
+
+// underlined returns true if the line starting at i underlines the previous
+// line as a plain text heading.
+func underlined(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	line := text[i:]
+	if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+	}
+	return line != "" && (strings.Trim(line, "-") == "" || strings.Trim(line, "=") == "")
+}
+
+// digest returns a short digest of some output.
+func digest(text string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(text))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || c.Offset < 0 || c.Bytes < 0 {
+		return cursor{}, errors.New("invalid cursor; pass the cursor of a truncated result as is")
+	}
+	return c, nil
+}
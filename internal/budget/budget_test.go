@@ -0,0 +1,149 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package budget
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	cases := map[string]struct {
+		maxTokens, maxBytes int
+		want                int
+	}{
+		"None":        {want: 0},
+		"Tokens":      {maxTokens: 100, want: 400},
+		"Bytes":       {maxBytes: 300, want: 300},
+		"SmallerWins": {maxTokens: 100, maxBytes: 1000, want: 400},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := Bytes(tc.maxTokens, tc.maxBytes); got != tc.want {
+				t.Errorf("Bytes(%d, %d) = %d, want %d", tc.maxTokens, tc.maxBytes, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	doc := "# Title\n\nIntro.\n\n## First\n\n- a\n- b\n- c\n\n## Second\n\n- d\n- e\n"
+	data := "kind: CRD\nspec:\n  group: example.org\n  names:\n    kind: Bucket\nstatus:\n  ok: true\n"
+	cases := map[string]struct {
+		text     string
+		kind     Kind
+		maxBytes int
+		want     string
+	}{
+		"Unlimited": {
+			text: doc,
+			kind: Document,
+			want: doc,
+		},
+		"Fits": {
+			text:     doc,
+			kind:     Document,
+			maxBytes: len(doc),
+			want:     doc,
+		},
+		"Section": {
+			text:     doc,
+			kind:     Document,
+			maxBytes: 40,
+			want:     "# Title\n\nIntro.\n\n## First\n\n- a\n- b\n- c",
+		},
+		"Block": {
+			text:     doc,
+			kind:     Document,
+			maxBytes: 38,
+			want:     "# Title\n\nIntro.\n\n## First",
+		},
+		"Shallowest": {
+			text:     data,
+			kind:     Data,
+			maxBytes: 63,
+			want:     "kind: CRD\nspec:\n  group: example.org\n  names:\n    kind: Bucket",
+		},
+		"WithinLine": {
+			text:     strings.Repeat("x", 20),
+			kind:     Data,
+			maxBytes: 8,
+			want:     "xxxxxxxx",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := Paginate("tool", tc.text, tc.kind, tc.maxBytes, "")
+			if err != nil {
+				t.Fatalf("Paginate() error = %v", err)
+			}
+			got := p.Text
+			if p.Truncated() {
+				if !strings.Contains(p.Text, "[truncated:") || !strings.Contains(p.Text, p.Cursor) {
+					t.Errorf("Paginate() text %q has no truncation marker with the cursor", p.Text)
+				}
+				got = strings.TrimSpace(p.Text[:strings.LastIndex(p.Text, "[truncated:")])
+			}
+			if got != tc.want {
+				t.Errorf("Paginate() page = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	text := strings.Repeat("- item ✓\n", 50)
+
+	var pages []string
+	cursor := ""
+	for i := 0; ; i++ {
+		if i > 100 {
+			t.Fatal("Paginate() did not reach the last page")
+		}
+		// Only the first call sets a budget; the cursor remembers it.
+		maxBytes := 0
+		if cursor == "" {
+			maxBytes = 64
+		}
+		p, err := Paginate("tool", text, Document, maxBytes, cursor)
+		if err != nil {
+			t.Fatalf("Paginate() error = %v", err)
+		}
+		if p.End-p.Start > 64 {
+			t.Errorf("Paginate() page is %d bytes, want at most 64", p.End-p.Start)
+		}
+		pages = append(pages, text[p.Start:p.End])
+		if p.Cursor == "" {
+			break
+		}
+		cursor = p.Cursor
+	}
+	if got := strings.Join(pages, ""); got != text {
+		t.Errorf("pages joined = %q, want %q", got, text)
+	}
+
+	if _, err := Paginate("other", text, Document, 0, cursor); err == nil {
+		t.Error("Paginate() accepted a cursor issued by another tool")
+	}
+	if _, err := Paginate("tool", text+"- new\n", Document, 0, cursor); !errors.Is(err, ErrCursorChanged) {
+		t.Errorf("Paginate() of changed text error = %v, want %v", err, ErrCursorChanged)
+	}
+	if _, err := Paginate("tool", text, Document, 0, "not a cursor"); err == nil {
+		t.Error("Paginate() accepted an invalid cursor")
+	}
+}
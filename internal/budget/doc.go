@@ -0,0 +1,26 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package budget fits tool output into a size budget.
+
+Output over budget is split into pages that end at the most significant
+boundary that fits: a document section or block, a list item or table row,
+or, in JSON and YAML, the shallowest nesting level of a schema or list. Each
+page but the last ends with a marker saying it was truncated, and carries an
+opaque cursor that fetches the next page of the same output.
+*/
+package budget
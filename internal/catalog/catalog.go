@@ -0,0 +1,58 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package catalog
+
+import (
+	"context"
+	"errors"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// ErrFilterUnsupported is returned, wrapped, by catalogs that cannot apply an
+// AIP-160 repository filter, rather than ignoring it.
+var ErrFilterUnsupported = errors.New("repository filters are not supported")
+
+// Catalog is a source of Crossplane packages. Its methods mirror the
+// marketplace Client, which is the reference implementation.
+type Catalog interface {
+	Searcher
+	PackageReader
+	ResourceReader
+}
+
+// Searcher finds packages and repositories in a catalog.
+type Searcher interface {
+	SearchPackages(ctx context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error)
+	GetRepositories(ctx context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error)
+}
+
+// PackageReader reads package metadata and assets.
+type PackageReader interface {
+	GetPackageMetadata(ctx context.Context, account, repo, version string, useV1 bool) (*marketplace.PackageMetadata, error)
+	GetPackageAssets(ctx context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error)
+}
+
+// ResourceReader reads the resources defined by a package version.
+type ResourceReader interface {
+	GetV1PackagesAccountRepositoryVersionResources(ctx context.Context, account, repositoryName, version string) (*marketplace.PackageResources, error)
+	GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (string, error)
+	GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind, compositionName string) (string, error)
+	GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (*marketplace.Examples, error)
+}
+
+var _ Catalog = &marketplace.Client{}
@@ -0,0 +1,273 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// rankConstant dampens the weight reciprocal rank fusion gives to the top
+// results of each backend. 60 is the value from the original paper.
+const rankConstant = 60
+
+// defaultSearchSize is the page size of searches that span several backends
+// and do not set one.
+const defaultSearchSize = 20
+
+// Backend is a named catalog in a federation.
+type Backend struct {
+	// Name identifies the backend in search results.
+	Name string
+
+	// Catalog serves the backend's packages.
+	Catalog Catalog
+
+	// Accounts served by this backend. Package references in these accounts
+	// are routed here. A backend without accounts serves any account not
+	// claimed by another backend.
+	Accounts []string
+}
+
+// Federated combines several backends into one catalog. Searches fan out to
+// every backend, while reads are routed to the backend serving the account.
+type Federated struct {
+	backends []Backend
+}
+
+var _ Catalog = &Federated{}
+
+// NewFederated returns a catalog that federates the supplied backends. Earlier
+// backends take precedence when ranking ties and when several backends could
+// serve an account.
+func NewFederated(backends ...Backend) *Federated {
+	return &Federated{backends: backends}
+}
+
+// Backends returns the federated backends.
+func (f *Federated) Backends() []Backend {
+	return f.backends
+}
+
+// Route returns the backend serving the supplied account.
+func (f *Federated) Route(account string) (*Backend, error) {
+	for i := range f.backends {
+		if slices.Contains(f.backends[i].Accounts, account) {
+			return &f.backends[i], nil
+		}
+	}
+	for i := range f.backends {
+		if len(f.backends[i].Accounts) == 0 {
+			return &f.backends[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no catalog serves account %q", account)
+}
+
+// SearchPackages searches every backend concurrently and merges the results
+// using reciprocal rank fusion. Each result is annotated with the name of the
+// backend it came from. Backends that fail are skipped unless all of them do.
+//
+// Pages are cut from the merged results, so every backend is read from its
+// first page up to the requested one; a package is then on exactly one page.
+// The total is the sum of the backends' totals, so it counts a package served
+// by several backends more than once.
+func (f *Federated) SearchPackages(ctx context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	backends := f.backends
+	if params.AccountName != "" {
+		// An account filter can only ever match the backend serving it.
+		b, err := f.Route(params.AccountName)
+		if err != nil {
+			return nil, err
+		}
+		backends = []Backend{*b}
+	}
+	if len(backends) == 1 {
+		// A single backend's pages need no merging.
+		resp, err := backends[0].Catalog.SearchPackages(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", backends[0].Name, err)
+		}
+		for i := range resp.Packages {
+			resp.Packages[i].Source = backends[0].Name
+		}
+		return resp, nil
+	}
+	size := params.Size
+	if size <= 0 {
+		size = defaultSearchSize
+	}
+
+	type result struct {
+		resp *marketplace.SearchResponse
+		err  error
+	}
+	results := make([]result, len(backends))
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := searchPages(ctx, b.Catalog, params, size)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", b.Name, err)
+			}
+			results[i] = result{resp: resp, err: err}
+		}()
+	}
+	wg.Wait()
+
+	type ranked struct {
+		pkg   marketplace.Package
+		score float64
+		order int
+	}
+	merged := map[string]*ranked{}
+	out := &marketplace.SearchResponse{Page: params.Page, Size: size}
+	var errs []error
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		out.Total += r.resp.Total
+		for rank, p := range r.resp.Packages {
+			p.Source = backends[i].Name
+			key := p.Account + "/" + p.Repository
+			score := 1.0 / float64(rankConstant+rank+1)
+			if m, ok := merged[key]; ok {
+				// The same package from several backends is a strong
+				// signal, but only the first backend's copy is kept.
+				m.score += score
+				continue
+			}
+			merged[key] = &ranked{pkg: p, score: score, order: rank*len(backends) + i}
+		}
+	}
+	if len(errs) == len(results) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	all := make([]*ranked, 0, len(merged))
+	for _, m := range merged {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].order < all[j].order
+	})
+	out.Packages = []marketplace.Package{}
+	for _, m := range all[min(len(all), params.Page*size):min(len(all), (params.Page+1)*size)] {
+		out.Packages = append(out.Packages, m.pkg)
+	}
+	return out, nil
+}
+
+// searchPages reads a backend's results from the first page up to and
+// including the requested one, stopping early once the backend runs out.
+func searchPages(ctx context.Context, c Catalog, params marketplace.SearchParams, size int) (*marketplace.SearchResponse, error) {
+	out := &marketplace.SearchResponse{}
+	for pg := 0; pg <= params.Page; pg++ {
+		p := params
+		p.Page, p.Size = pg, size
+		resp, err := c.SearchPackages(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		out.Total = resp.Total
+		out.Packages = append(out.Packages, resp.Packages...)
+		if len(resp.Packages) < size {
+			break
+		}
+	}
+	return out, nil
+}
+
+// GetRepositories routes to the backend serving the account.
+func (f *Federated) GetRepositories(ctx context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return nil, err
+	}
+	return b.Catalog.GetRepositories(ctx, account, params)
+}
+
+// GetPackageMetadata routes to the backend serving the account.
+func (f *Federated) GetPackageMetadata(ctx context.Context, account, repo, version string, useV1 bool) (*marketplace.PackageMetadata, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return nil, err
+	}
+	return b.Catalog.GetPackageMetadata(ctx, account, repo, version, useV1)
+}
+
+// GetPackageAssets routes to the backend serving the account.
+func (f *Federated) GetPackageAssets(ctx context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return nil, err
+	}
+	return b.Catalog.GetPackageAssets(ctx, account, repo, version, assetType)
+}
+
+// GetV1PackagesAccountRepositoryVersionResources routes to the backend
+// serving the account.
+func (f *Federated) GetV1PackagesAccountRepositoryVersionResources(ctx context.Context, account, repositoryName, version string) (*marketplace.PackageResources, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return nil, err
+	}
+	return b.Catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repositoryName, version)
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKind routes to the
+// backend serving the account.
+func (f *Federated) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (string, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return "", err
+	}
+	return b.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition routes to
+// the backend serving the account.
+func (f *Federated) GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind, compositionName string) (string, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return "", err
+	}
+	return b.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, account, repositoryName, version, resourceGroup, resourceKind, compositionName)
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples routes to
+// the backend serving the account.
+func (f *Federated) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (*marketplace.Examples, error) {
+	b, err := f.Route(account)
+	if err != nil {
+		return nil, err
+	}
+	return b.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+}
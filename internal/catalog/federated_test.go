@@ -0,0 +1,170 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// fakeCatalog returns canned search results and records the account of
+// metadata requests routed to it.
+type fakeCatalog struct {
+	Catalog
+
+	packages []marketplace.Package
+	err      error
+	routed   []string
+}
+
+func (f *fakeCatalog) SearchPackages(_ context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &marketplace.SearchResponse{Packages: page(f.packages, params.Page, params.Size), Total: len(f.packages)}, nil
+}
+
+func (f *fakeCatalog) GetPackageMetadata(_ context.Context, account, repo, _ string, _ bool) (*marketplace.PackageMetadata, error) {
+	f.routed = append(f.routed, account)
+	return &marketplace.PackageMetadata{Account: account, Repository: repo}, nil
+}
+
+func pkgs(refs ...string) []marketplace.Package {
+	out := make([]marketplace.Package, 0, len(refs))
+	for _, r := range refs {
+		out = append(out, marketplace.Package{Account: "a", Repository: r})
+	}
+	return out
+}
+
+func TestFederatedSearchPackages(t *testing.T) {
+	cases := map[string]struct {
+		backends []Backend
+		want     []string
+		sources  []string
+		wantErr  bool
+	}{
+		"InterleavesByRank": {
+			backends: []Backend{
+				{Name: "one", Catalog: &fakeCatalog{packages: pkgs("x", "y")}},
+				{Name: "two", Catalog: &fakeCatalog{packages: pkgs("z")}},
+			},
+			want:    []string{"x", "z", "y"},
+			sources: []string{"one", "two", "one"},
+		},
+		"DuplicatesRankHigher": {
+			backends: []Backend{
+				{Name: "one", Catalog: &fakeCatalog{packages: pkgs("x", "y")}},
+				{Name: "two", Catalog: &fakeCatalog{packages: pkgs("z", "y")}},
+			},
+			want:    []string{"y", "x", "z"},
+			sources: []string{"one", "one", "two"},
+		},
+		"SkipsFailedBackend": {
+			backends: []Backend{
+				{Name: "one", Catalog: &fakeCatalog{err: errors.New("boom")}},
+				{Name: "two", Catalog: &fakeCatalog{packages: pkgs("z")}},
+			},
+			want:    []string{"z"},
+			sources: []string{"two"},
+		},
+		"AllBackendsFail": {
+			backends: []Backend{
+				{Name: "one", Catalog: &fakeCatalog{err: errors.New("boom")}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := NewFederated(tc.backends...).SearchPackages(context.Background(), marketplace.SearchParams{})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SearchPackages() error = %v", err)
+			}
+			if len(resp.Packages) != len(tc.want) {
+				t.Fatalf("got %d packages, want %d", len(resp.Packages), len(tc.want))
+			}
+			for i, p := range resp.Packages {
+				if p.Repository != tc.want[i] || p.Source != tc.sources[i] {
+					t.Errorf("result %d = %s from %s, want %s from %s", i, p.Repository, p.Source, tc.want[i], tc.sources[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFederatedSearchPages(t *testing.T) {
+	f := NewFederated(
+		Backend{Name: "one", Catalog: &fakeCatalog{packages: pkgs("a", "b", "c", "d", "e")}},
+		Backend{Name: "two", Catalog: &fakeCatalog{packages: pkgs("v", "w", "x")}},
+	)
+	seen := map[string]int{}
+	for pg := range 5 {
+		resp, err := f.SearchPackages(context.Background(), marketplace.SearchParams{Page: pg, Size: 2})
+		if err != nil {
+			t.Fatalf("SearchPackages() page %d error = %v", pg, err)
+		}
+		if pg < 4 && len(resp.Packages) != 2 {
+			t.Errorf("page %d has %d packages, want 2", pg, len(resp.Packages))
+		}
+		if resp.Total != 8 {
+			t.Errorf("page %d total = %d, want 8", pg, resp.Total)
+		}
+		for _, p := range resp.Packages {
+			seen[p.Repository]++
+		}
+	}
+	if len(seen) != 8 {
+		t.Errorf("pages held %d distinct packages, want 8: %v", len(seen), seen)
+	}
+	for repo, n := range seen {
+		if n != 1 {
+			t.Errorf("package %s on %d pages, want 1", repo, n)
+		}
+	}
+}
+
+func TestFederatedRoute(t *testing.T) {
+	def := &fakeCatalog{}
+	private := &fakeCatalog{}
+	f := NewFederated(
+		Backend{Name: "marketplace", Catalog: def},
+		Backend{Name: "registry", Catalog: private, Accounts: []string{"acme"}},
+	)
+
+	for _, account := range []string{"upbound", "acme"} {
+		if _, err := f.GetPackageMetadata(context.Background(), account, "repo", "", false); err != nil {
+			t.Fatalf("GetPackageMetadata(%s) error = %v", account, err)
+		}
+	}
+	if len(def.routed) != 1 || def.routed[0] != "upbound" {
+		t.Errorf("default backend received %v, want [upbound]", def.routed)
+	}
+	if len(private.routed) != 1 || private.routed[0] != "acme" {
+		t.Errorf("registry backend received %v, want [acme]", private.routed)
+	}
+}
@@ -0,0 +1,229 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/xpkg"
+)
+
+// A Loader loads packages from a source that holds package contents rather
+// than an index of them, such as a registry or the local filesystem.
+type Loader interface {
+	// LoadPackage loads the supplied package version.
+	LoadPackage(ctx context.Context, account, repository, version string) (*xpkg.Package, error)
+
+	// ListPackages lists the packages in an account, or in every account
+	// if account is empty.
+	ListPackages(ctx context.Context, account string) ([]marketplace.Package, error)
+}
+
+// A Versioner lists the versions of a package. Loaders may implement it to
+// report versions in package metadata.
+type Versioner interface {
+	PackageVersions(ctx context.Context, account, repository string) ([]string, error)
+}
+
+// PackageCatalog serves packages read by a Loader.
+type PackageCatalog struct {
+	loader Loader
+}
+
+// NewPackageCatalog returns a catalog that serves packages read by the
+// supplied loader.
+func NewPackageCatalog(l Loader) *PackageCatalog {
+	return &PackageCatalog{loader: l}
+}
+
+// SearchPackages returns the packages whose account, repository, name or
+// description contain the query.
+func (c *PackageCatalog) SearchPackages(ctx context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	pkgs, err := c.loader.ListPackages(ctx, params.AccountName)
+	if err != nil {
+		return nil, err
+	}
+	query := strings.ToLower(params.Query)
+	matched := make([]marketplace.Package, 0, len(pkgs))
+	for _, p := range pkgs {
+		if params.PackageType != "" && !strings.EqualFold(p.Type, params.PackageType) {
+			continue
+		}
+		text := strings.ToLower(strings.Join([]string{p.Account, p.Repository, p.Name, p.Description}, " "))
+		if query != "" && !strings.Contains(text, query) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return &marketplace.SearchResponse{
+		Packages: page(matched, params.Page, params.Size),
+		Total:    len(matched),
+		Page:     params.Page,
+		Size:     params.Size,
+	}, nil
+}
+
+// GetRepositories returns a repository for every package in the account.
+// Packages read directly carry no visibility or other indexed fields to filter
+// on, so a filter returns ErrFilterUnsupported.
+func (c *PackageCatalog) GetRepositories(ctx context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	if params.Filter != "" {
+		return nil, fmt.Errorf("%w by packages read directly from a registry or disk", ErrFilterUnsupported)
+	}
+	pkgs, err := c.loader.ListPackages(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]marketplace.Repository, 0, len(pkgs))
+	for _, p := range pkgs {
+		repos = append(repos, marketplace.Repository{
+			Account:     p.Account,
+			Name:        p.Repository,
+			Description: p.Description,
+			Type:        p.Type,
+			Public:      p.Public,
+		})
+	}
+	return &marketplace.RepositoryResponse{
+		Repositories: page(repos, params.Page, params.Size),
+		Count:        len(repos),
+		Page:         params.Page,
+		Size:         params.Size,
+	}, nil
+}
+
+// GetPackageMetadata returns the metadata of a package version. An empty
+// version returns the metadata of the latest version.
+func (c *PackageCatalog) GetPackageMetadata(ctx context.Context, account, repo, version string, _ bool) (*marketplace.PackageMetadata, error) {
+	var versions []string
+	if v, ok := c.loader.(Versioner); ok {
+		if vs, err := v.PackageVersions(ctx, account, repo); err == nil {
+			versions = vs
+		}
+	}
+	if version == "" && len(versions) > 0 {
+		version = versions[len(versions)-1]
+	}
+	pkg, err := c.loader.LoadPackage(ctx, account, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	md := pkg.Metadata(account, repo, version)
+	if len(versions) > 0 {
+		md.Versions = versions
+		md.LatestVersion = versions[len(versions)-1]
+	}
+	return md, nil
+}
+
+// load loads the supplied package version. An empty version loads the
+// highest version the loader reports, if it is a Versioner, and otherwise
+// whatever the loader considers latest.
+func (c *PackageCatalog) load(ctx context.Context, account, repo, version string) (*xpkg.Package, error) {
+	if v, ok := c.loader.(Versioner); ok && version == "" {
+		if versions, err := v.PackageVersions(ctx, account, repo); err == nil && len(versions) > 0 {
+			version = versions[len(versions)-1]
+		}
+	}
+	return c.loader.LoadPackage(ctx, account, repo, version)
+}
+
+// GetPackageAssets returns the readme of a package version. Packages read
+// directly do not carry any other assets.
+func (c *PackageCatalog) GetPackageAssets(ctx context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error) {
+	if assetType != "docs" && assetType != "readme" {
+		return nil, fmt.Errorf("%s assets are not available for packages read directly, only docs and readme", assetType)
+	}
+	pkg, err := c.load(ctx, account, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	return &marketplace.AssetResponse{Content: pkg.Readme(), Type: assetType}, nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResources returns the resources in a
+// package version.
+func (c *PackageCatalog) GetV1PackagesAccountRepositoryVersionResources(ctx context.Context, account, repositoryName, version string) (*marketplace.PackageResources, error) {
+	pkg, err := c.load(ctx, account, repositoryName, version)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Resources(account, repositoryName), nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKind returns the JSON
+// encoded CRD or XRD that defines a group and kind.
+func (c *PackageCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (string, error) {
+	pkg, err := c.load(ctx, account, repositoryName, version)
+	if err != nil {
+		return "", err
+	}
+	def, ok := pkg.Definition(resourceGroup, resourceKind)
+	if !ok {
+		return "", fmt.Errorf("kind %s.%s not found in package %s/%s", resourceKind, resourceGroup, account, repositoryName)
+	}
+	return encode(def)
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition returns
+// the JSON encoded named composition.
+func (c *PackageCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, account, repositoryName, version, _, _, compositionName string) (string, error) {
+	pkg, err := c.load(ctx, account, repositoryName, version)
+	if err != nil {
+		return "", err
+	}
+	comp, ok := pkg.Composition(compositionName)
+	if !ok {
+		return "", fmt.Errorf("composition %s not found in package %s/%s", compositionName, account, repositoryName)
+	}
+	return encode(comp)
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples returns the
+// examples of a group and kind.
+func (c *PackageCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (*marketplace.Examples, error) {
+	pkg, err := c.load(ctx, account, repositoryName, version)
+	if err != nil {
+		return nil, err
+	}
+	return &marketplace.Examples{Examples: pkg.ExamplesFor(resourceGroup, resourceKind)}, nil
+}
+
+func encode(obj map[string]any) (string, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode object: %w", err)
+	}
+	return string(b), nil
+}
+
+// page returns the requested page of items. A size of zero returns every
+// item.
+func page[T any](items []T, pg, size int) []T {
+	if size <= 0 {
+		return items
+	}
+	start := pg * size
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+size, len(items))]
+}
@@ -0,0 +1,24 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package contextpack assembles what an agent needs to author one resource of a
+kind into a single compact bundle: the trimmed schema of its spec.forProvider,
+the best example of it, the section of the package's docs about it, how it
+references a ProviderConfig and the keys of its connection secret. A pack is
+fitted to a size budget by trimming its least useful parts first.
+*/
+package contextpack
@@ -0,0 +1,148 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package contextpack
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A trim leaves part of a pack out to make it smaller. It returns a note
+// describing what it left out, or an empty string if it left nothing out.
+type trim func(p *Pack) string
+
+// trims are applied in order until a pack fits its budget, least useful
+// first.
+var trims = []trim{
+	shortenDescriptions,
+	dropDocs,
+	dropDeepFields(2),
+	dropDeepFields(1),
+	dropOptionalDescriptions,
+	dropExample,
+	dropOptionalFields,
+	dropDescriptions,
+}
+
+// Fit trims a pack until its size is at most maxBytes, or until nothing is
+// left to trim.
+func (p *Pack) Fit(maxBytes int) {
+	for _, t := range trims {
+		if p.Size() <= maxBytes {
+			return
+		}
+		if note := t(p); note != "" {
+			p.Trimmed = append(p.Trimmed, note)
+		}
+	}
+}
+
+// shortenDescriptions shortens field descriptions to their first sentence.
+func shortenDescriptions(p *Pack) string {
+	n := 0
+	for i, f := range p.Fields {
+		if s := firstSentence(f.Description); s != f.Description {
+			p.Fields[i].Description = s
+			n++
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("shortened %d field descriptions to their first sentence", n)
+}
+
+// firstSentence returns the first sentence of s.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+func dropDocs(p *Pack) string {
+	if p.Docs == "" {
+		return ""
+	}
+	p.Docs = ""
+	return "left out the docs section"
+}
+
+// dropDeepFields returns a trim that drops the optional fields nested deeper
+// than depth.
+func dropDeepFields(depth int) trim {
+	return func(p *Pack) string {
+		return dropFields(p, fmt.Sprintf("optional fields nested below depth %d", depth), func(f Field) bool {
+			return !f.Required && f.depth() > depth
+		})
+	}
+}
+
+func dropOptionalDescriptions(p *Pack) string {
+	n := 0
+	for i, f := range p.Fields {
+		if !f.Required && f.Description != "" {
+			p.Fields[i].Description = ""
+			n++
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("left out the descriptions of %d optional fields", n)
+}
+
+func dropExample(p *Pack) string {
+	if p.Example == "" {
+		return ""
+	}
+	p.Example = ""
+	return "left out the example"
+}
+
+func dropOptionalFields(p *Pack) string {
+	return dropFields(p, "optional fields", func(f Field) bool { return !f.Required })
+}
+
+func dropDescriptions(p *Pack) string {
+	n := 0
+	for i, f := range p.Fields {
+		if f.Description != "" {
+			p.Fields[i].Description = ""
+			n++
+		}
+	}
+	if p.ProviderConfig != nil && p.ProviderConfig.Description != "" {
+		p.ProviderConfig.Description = ""
+		n++
+	}
+	if n == 0 {
+		return ""
+	}
+	return "left out all descriptions"
+}
+
+// dropFields drops the fields for which drop returns true.
+func dropFields(p *Pack, what string, drop func(Field) bool) string {
+	before := len(p.Fields)
+	p.Fields = slices.DeleteFunc(p.Fields, drop)
+	if n := before - len(p.Fields); n > 0 {
+		return fmt.Sprintf("left out %d %s", n, what)
+	}
+	return ""
+}
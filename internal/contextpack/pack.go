@@ -0,0 +1,474 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package contextpack
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// Pack is the context needed to author a resource of one kind.
+type Pack struct {
+	Account    string `json:"account" jsonschema:"required"`
+	Repository string `json:"repository" jsonschema:"required"`
+	Version    string `json:"version" jsonschema:"required"`
+	Group      string `json:"group" jsonschema:"required"`
+	Kind       string `json:"kind" jsonschema:"required"`
+
+	// APIVersion is the apiVersion resources of the kind are authored with.
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Root is the path of the object the fields are in: spec.forProvider
+	// for managed resources, otherwise spec.
+	Root   string  `json:"root,omitempty"`
+	Fields []Field `json:"fields"`
+
+	Example          string            `json:"example,omitempty"`
+	Docs             string            `json:"docs,omitempty"`
+	ProviderConfig   *ProviderConfig   `json:"providerConfig,omitempty"`
+	ConnectionSecret *ConnectionSecret `json:"connectionSecret,omitempty"`
+
+	// Trimmed describes what was left out to fit the pack into its budget.
+	Trimmed []string `json:"trimmed,omitempty"`
+
+	// Warnings describe parts of the pack that could not be fetched.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Field is a field of the kind's schema.
+type Field struct {
+	// Path is the dotted path of the field below the pack's root, with [*]
+	// for array items and {*} for map values.
+	Path        string `json:"path" jsonschema:"required"`
+	Type        string `json:"type,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// depth returns how deeply a field is nested below the pack's root.
+func (f Field) depth() int {
+	return strings.Count(f.Path, ".") + strings.Count(f.Path, "[*]") + strings.Count(f.Path, "{*}")
+}
+
+// ProviderConfig is how a managed resource references the ProviderConfig
+// that supplies its credentials.
+type ProviderConfig struct {
+	// Field is the path of the reference in the resource.
+	Field string `json:"field" jsonschema:"required"`
+
+	// APIVersion and Kind identify the ProviderConfig, if the package
+	// defines one.
+	APIVersion  string `json:"apiVersion,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ConnectionSecret is how a resource publishes its connection details.
+type ConnectionSecret struct {
+	// Field is the path of the secret reference in the resource.
+	Field string `json:"field" jsonschema:"required"`
+
+	// Keys are the keys of the secret, if known.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Size returns the size of the pack's JSON encoding in bytes, which the
+// budget it is fitted to applies to.
+func (p *Pack) Size() int {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// Build assembles the context pack of a kind defined by a package version,
+// fetching its definition, examples, docs and the package's resources
+// concurrently, and fits it into maxBytes, or leaves it whole if maxBytes is
+// zero. Only the definition is essential; parts that cannot be fetched are
+// reported as warnings.
+func Build(ctx context.Context, c catalog.Catalog, account, repository, version, group, kind string, maxBytes int) (*Pack, error) {
+	var (
+		wg                    sync.WaitGroup
+		raw                   string
+		rawErr, exErr, resErr error
+		exs                   *marketplace.Examples
+		docs                  *marketplace.AssetResponse
+		docsErr               error
+		res                   *marketplace.PackageResources
+	)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		raw, rawErr = c.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repository, version, group, kind)
+	}()
+	go func() {
+		defer wg.Done()
+		exs, exErr = c.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, account, repository, version, group, kind)
+	}()
+	go func() {
+		defer wg.Done()
+		docs, docsErr = c.GetPackageAssets(ctx, account, repository, version, "docs")
+	}()
+	go func() {
+		defer wg.Done()
+		res, resErr = c.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repository, version)
+	}()
+	wg.Wait()
+
+	if rawErr != nil {
+		return nil, fmt.Errorf("failed to get %s.%s: %w", kind, group, rawErr)
+	}
+	def, err := diff.ParseDefinition([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s.%s: %w", kind, group, err)
+	}
+
+	p := &Pack{Account: account, Repository: repository, Version: version, Group: group, Kind: kind}
+	var storage string
+	if resErr != nil {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("failed to get package resources: %v", resErr))
+	} else {
+		storage = storageVersion(res, group, kind)
+	}
+	apiVersion, schema := selectSchema(def, storage)
+	if apiVersion != "" {
+		p.APIVersion = group + "/" + apiVersion
+	}
+
+	spec := child(schema, "spec")
+	root, rootPath := child(spec, "forProvider"), "spec.forProvider"
+	if root == nil {
+		root, rootPath = spec, "spec"
+	}
+	p.Root = rootPath
+	p.Fields = extract(root, requiredPaths(spec, rootPath))
+
+	if ref := child(spec, "providerConfigRef"); ref != nil {
+		p.ProviderConfig = providerConfig(ref, res)
+	}
+
+	switch {
+	case exErr != nil:
+		p.Warnings = append(p.Warnings, fmt.Sprintf("failed to get examples: %v", exErr))
+	case exs != nil:
+		p.Example = bestExample(exs.Examples, kind)
+	}
+
+	var section string
+	switch {
+	case docsErr != nil:
+		p.Warnings = append(p.Warnings, fmt.Sprintf("failed to get docs: %v", docsErr))
+	case docs != nil:
+		section = docsSection(docs.Content, kind)
+		p.Docs = section
+	}
+
+	if keys := connectionSecretKeys(raw); child(spec, "writeConnectionSecretToRef") != nil || len(keys) > 0 {
+		if len(keys) == 0 {
+			keys = docKeys(section)
+		}
+		p.ConnectionSecret = &ConnectionSecret{Field: "spec.writeConnectionSecretToRef", Keys: keys}
+	}
+
+	if maxBytes > 0 {
+		p.Fit(maxBytes)
+	}
+	return p, nil
+}
+
+// storageVersion returns the storage version of a kind in a package's
+// resources, or an empty string if the package does not list it.
+func storageVersion(res *marketplace.PackageResources, group, kind string) string {
+	for _, c := range res.CRDs {
+		if c.Group == group && c.Kind == kind {
+			return c.StorageVersion
+		}
+	}
+	for _, x := range res.XRDs {
+		if x.Group == group && x.Kind == kind {
+			return x.ReferenceableVersion
+		}
+	}
+	return ""
+}
+
+// selectSchema returns the preferred version of a definition and its schema,
+// falling back to the version Kubernetes gives the highest priority when the
+// definition does not have it.
+func selectSchema(def *diff.Definition, preferred string) (string, map[string]any) {
+	if s, ok := def.Schemas[preferred]; ok {
+		return preferred, s
+	}
+	best := ""
+	for v := range def.Schemas {
+		if best == "" || compareVersions(v, best) > 0 {
+			best = v
+		}
+	}
+	return best, def.Schemas[best]
+}
+
+// kubeVersion matches a Kubernetes API version, such as v1 or v2beta1.
+var kubeVersion = regexp.MustCompile(`^v([1-9][0-9]*)(?:(alpha|beta)([1-9][0-9]*))?$`)
+
+// stability orders the stability levels of Kubernetes API versions.
+var stability = map[string]int{"alpha": 0, "beta": 1, "": 2}
+
+// compareVersions compares two API versions by Kubernetes version priority:
+// GA versions before beta before alpha, then higher major and minor versions
+// first. Versions that do not look like Kubernetes versions come last, in
+// reverse lexical order. It returns a positive number if a has the higher
+// priority.
+func compareVersions(a, b string) int {
+	ma, mb := kubeVersion.FindStringSubmatch(a), kubeVersion.FindStringSubmatch(b)
+	switch {
+	case ma == nil && mb == nil:
+		return strings.Compare(b, a)
+	case ma == nil:
+		return -1
+	case mb == nil:
+		return 1
+	}
+	if c := cmp.Compare(stability[ma[2]], stability[mb[2]]); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(atoi(ma[1]), atoi(mb[1])); c != 0 {
+		return c
+	}
+	return cmp.Compare(atoi(ma[3]), atoi(mb[3]))
+}
+
+// atoi parses a number matched by kubeVersion, returning zero for an empty
+// match.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// child returns the schema of a property of an object schema, or nil if it
+// has no such property.
+func child(schema map[string]any, name string) map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	c, _ := props[name].(map[string]any)
+	return c
+}
+
+// requiredParameter matches the CEL validation messages that mark a
+// parameter as required, such as "spec.forProvider.region is a required
+// parameter".
+var requiredParameter = regexp.MustCompile(`^(spec\.[\w.]+) is a required parameter`)
+
+// requiredPaths returns the paths, relative to root, of the fields a spec
+// requires: those listed as required by their parent's schema, and those a
+// validation rule on the spec requires.
+func requiredPaths(spec map[string]any, root string) map[string]bool {
+	out := map[string]bool{}
+	rules, _ := spec["x-kubernetes-validations"].([]any)
+	for _, r := range rules {
+		rule, _ := r.(map[string]any)
+		msg, _ := rule["message"].(string)
+		if m := requiredParameter.FindStringSubmatch(msg); m != nil {
+			if p, ok := strings.CutPrefix(m[1], root+"."); ok {
+				out[p] = true
+			}
+		}
+	}
+	return out
+}
+
+// extract returns the fields of a schema, in path order.
+func extract(schema map[string]any, required map[string]bool) []Field {
+	var out []Field
+	walk("", schema, required, &out)
+	return out
+}
+
+func walk(path string, schema map[string]any, required map[string]bool, out *[]Field) {
+	props, _ := schema["properties"].(map[string]any)
+	req, _ := schema["required"].([]any)
+	names := make([]string, 0, len(props))
+	for n := range props {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	for _, n := range names {
+		p := n
+		if path != "" {
+			p = path + "." + n
+		}
+		prop, _ := props[n].(map[string]any)
+		desc, _ := prop["description"].(string)
+		typ, _ := prop["type"].(string)
+		*out = append(*out, Field{
+			Path:        p,
+			Type:        typ,
+			Required:    required[p] || slices.Contains(req, any(n)),
+			Description: strings.Join(strings.Fields(desc), " "),
+		})
+		walk(p, prop, required, out)
+		if items, ok := prop["items"].(map[string]any); ok {
+			walk(p+"[*]", items, required, out)
+		}
+		if values, ok := prop["additionalProperties"].(map[string]any); ok {
+			walk(p+"{*}", values, required, out)
+		}
+	}
+}
+
+// providerConfig returns how a resource references its ProviderConfig, given
+// the schema of its spec.providerConfigRef.
+func providerConfig(ref map[string]any, res *marketplace.PackageResources) *ProviderConfig {
+	pc := &ProviderConfig{Field: "spec.providerConfigRef.name"}
+	pc.Description, _ = ref["description"].(string)
+	if def, ok := ref["default"].(map[string]any); ok {
+		pc.Default, _ = def["name"].(string)
+	}
+	if res == nil {
+		return pc
+	}
+	for _, c := range res.CRDs {
+		if c.Kind == "ProviderConfig" {
+			pc.APIVersion, pc.Kind = c.Group+"/"+c.StorageVersion, c.Kind
+			break
+		}
+	}
+	return pc
+}
+
+// bestExample returns the shortest example that defines a resource of the
+// kind, or the shortest example if none does.
+func bestExample(examples []string, kind string) string {
+	defines := regexp.MustCompile(`(?m)^kind:\s*` + regexp.QuoteMeta(kind) + `\s*$`)
+	best := ""
+	found := false
+	for _, ex := range examples {
+		ok := defines.MatchString(ex)
+		switch {
+		case ok && (!found || len(ex) < len(best)):
+			best, found = ex, true
+		case !ok && !found && (best == "" || len(ex) < len(best)):
+			best = ex
+		}
+	}
+	return strings.TrimSpace(best)
+}
+
+// heading matches a markdown heading.
+var heading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+
+// fence matches the start or end of a fenced markdown code block.
+var fence = regexp.MustCompile("^\\s*(```|~~~)")
+
+// docsSection returns the section of markdown docs whose heading names the
+// kind, up to the next heading at the same or a higher level. Lines inside
+// fenced code blocks, such as comments in YAML examples, are not headings.
+func docsSection(docs, kind string) string {
+	names := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(kind) + `\b`)
+	lines := strings.Split(docs, "\n")
+	start, level := -1, 0
+	inFence := ""
+	for i, l := range lines {
+		if f := fence.FindStringSubmatch(l); f != nil {
+			switch inFence {
+			case "":
+				inFence = f[1]
+			case f[1]:
+				inFence = ""
+			}
+			continue
+		}
+		if inFence != "" {
+			continue
+		}
+		m := heading.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		if start >= 0 && len(m[1]) <= level {
+			return strings.TrimSpace(strings.Join(lines[start:i], "\n"))
+		}
+		if start < 0 && names.MatchString(m[2]) {
+			start, level = i, len(m[1])
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(lines[start:], "\n"))
+}
+
+// connectionSecretKeys returns the connectionSecretKeys of a raw XRD, which
+// may be wrapped in another object.
+func connectionSecretKeys(raw string) []string {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return nil
+	}
+	candidates := []map[string]any{obj}
+	for _, v := range obj {
+		if m, ok := v.(map[string]any); ok {
+			candidates = append(candidates, m)
+		}
+	}
+	for _, c := range candidates {
+		spec, _ := c["spec"].(map[string]any)
+		keys, _ := spec["connectionSecretKeys"].([]any)
+		var out []string
+		for _, k := range keys {
+			if s, ok := k.(string); ok {
+				out = append(out, s)
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	return nil
+}
+
+// codeSpan matches a markdown code span.
+var codeSpan = regexp.MustCompile("`([\\w.-]+)`")
+
+// docKeys returns the code spans of the lines of a docs section that mention
+// connection details, which are the keys of the connection secret.
+func docKeys(section string) []string {
+	var out []string
+	for _, l := range strings.Split(section, "\n") {
+		if !strings.Contains(strings.ToLower(l), "connection") {
+			continue
+		}
+		for _, m := range codeSpan.FindAllStringSubmatch(l, -1) {
+			if !slices.Contains(out, m[1]) && !strings.Contains(m[1], "ConnectionSecret") {
+				out = append(out, m[1])
+			}
+		}
+	}
+	return out
+}
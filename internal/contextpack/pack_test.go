@@ -0,0 +1,215 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package contextpack
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+const bucketCRD = `{"spec":{"group":"s3.aws.upbound.io","names":{"kind":"Bucket"},"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"properties":{"spec":{
+  "x-kubernetes-validations":[{"message":"spec.forProvider.region is a required parameter","rule":"has(self.forProvider.region)"}],
+  "properties":{
+    "forProvider":{"properties":{
+      "region":{"type":"string","description":"Region is the region you'd like your resource to be created in. It is required."},
+      "tags":{"type":"object","description":"Key-value map of resource tags.","additionalProperties":{"type":"string"}},
+      "logging":{"type":"array","description":"Logging configuration.","items":{"properties":{"target":{"type":"object","properties":{"bucket":{"type":"string","description":"Target bucket."}}}}}}
+    }},
+    "providerConfigRef":{"description":"ProviderConfigReference specifies how the provider that will be used to create, observe, update, and delete this managed resource should be configured.","default":{"name":"default"},"properties":{"name":{"type":"string"}}},
+    "writeConnectionSecretToRef":{"properties":{"name":{"type":"string"}}}
+  }}}}}}]}}`
+
+const docs = "# Provider AWS S3\n\nIntro.\n\n## Bucket\n\nManages an S3 bucket.\nConnection details include `endpoint` and `region`.\n\n## BucketPolicy\n\nManages a policy.\n"
+
+// fakeCatalog serves the Bucket kind of provider-aws-s3.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	docsErr error
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, _, _ string) (*marketplace.PackageResources, error) {
+	return &marketplace.PackageResources{CRDs: []marketplace.CRDMeta{
+		{Group: "s3.aws.upbound.io", Kind: "Bucket", StorageVersion: "v1beta1"},
+		{Group: "aws.upbound.io", Kind: "ProviderConfig", StorageVersion: "v1beta1"},
+	}}, nil
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, _, _, _, _, kind string) (string, error) {
+	if kind != "Bucket" {
+		return "", errors.New("not found")
+	}
+	return bucketCRD, nil
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(_ context.Context, _, _, _, _, _ string) (*marketplace.Examples, error) {
+	return &marketplace.Examples{Examples: []string{
+		"kind: BucketPolicy\n",
+		"apiVersion: s3.aws.upbound.io/v1beta1\nkind: Bucket\nmetadata:\n  name: example\nspec:\n  forProvider:\n    region: us-west-1\n---\napiVersion: s3.aws.upbound.io/v1beta1\nkind: BucketPolicy\n",
+		"apiVersion: s3.aws.upbound.io/v1beta1\nkind: Bucket\nspec:\n  forProvider:\n    region: us-west-1\n",
+	}}, nil
+}
+
+func (f fakeCatalog) GetPackageAssets(_ context.Context, _, _, _, _ string) (*marketplace.AssetResponse, error) {
+	if f.docsErr != nil {
+		return nil, f.docsErr
+	}
+	return &marketplace.AssetResponse{Content: docs}, nil
+}
+
+func TestBuild(t *testing.T) {
+	p, err := Build(context.Background(), fakeCatalog{}, "upbound", "provider-aws-s3", "v1.21.0", "s3.aws.upbound.io", "Bucket", 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if p.APIVersion != "s3.aws.upbound.io/v1beta1" || p.Root != "spec.forProvider" {
+		t.Errorf("Build() apiVersion, root = %s, %s, want s3.aws.upbound.io/v1beta1, spec.forProvider", p.APIVersion, p.Root)
+	}
+	var paths []string
+	for _, f := range p.Fields {
+		paths = append(paths, f.Path)
+		if f.Required != (f.Path == "region") {
+			t.Errorf("Build() field %s required = %t", f.Path, f.Required)
+		}
+	}
+	want := []string{"logging", "logging[*].target", "logging[*].target.bucket", "region", "tags"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Build() fields = %v, want %v", paths, want)
+	}
+	if !strings.HasSuffix(p.Example, "region: us-west-1") || strings.Contains(p.Example, "BucketPolicy") {
+		t.Errorf("Build() example = %q, want the shortest Bucket example", p.Example)
+	}
+	if want := "## Bucket\n\nManages an S3 bucket.\nConnection details include `endpoint` and `region`."; p.Docs != want {
+		t.Errorf("Build() docs = %q, want %q", p.Docs, want)
+	}
+	if pc := p.ProviderConfig; pc == nil || pc.APIVersion != "aws.upbound.io/v1beta1" || pc.Default != "default" {
+		t.Errorf("Build() providerConfig = %+v, want aws.upbound.io/v1beta1 defaulting to default", pc)
+	}
+	if cs := p.ConnectionSecret; cs == nil || !slices.Equal(cs.Keys, []string{"endpoint", "region"}) {
+		t.Errorf("Build() connectionSecret = %+v, want keys endpoint and region", cs)
+	}
+}
+
+func TestBuildWarnings(t *testing.T) {
+	p, err := Build(context.Background(), fakeCatalog{docsErr: errors.New("boom")}, "upbound", "provider-aws-s3", "v1.21.0", "s3.aws.upbound.io", "Bucket", 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if p.Docs != "" || len(p.Warnings) != 1 {
+		t.Errorf("Build() docs, warnings = %q, %v, want a warning instead of docs", p.Docs, p.Warnings)
+	}
+
+	if _, err := Build(context.Background(), fakeCatalog{}, "upbound", "provider-aws-s3", "v1.21.0", "s3.aws.upbound.io", "Missing", 0); err == nil {
+		t.Error("Build() of a missing kind returned no error")
+	}
+}
+
+func TestFit(t *testing.T) {
+	whole, err := Build(context.Background(), fakeCatalog{}, "upbound", "provider-aws-s3", "v1.21.0", "s3.aws.upbound.io", "Bucket", 0)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	cases := map[string]struct {
+		maxBytes    int
+		wantTrimmed bool
+	}{
+		"Fits":  {maxBytes: whole.Size(), wantTrimmed: false},
+		"Small": {maxBytes: whole.Size() * 3 / 4, wantTrimmed: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := Build(context.Background(), fakeCatalog{}, "upbound", "provider-aws-s3", "v1.21.0", "s3.aws.upbound.io", "Bucket", tc.maxBytes)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if (len(p.Trimmed) > 0) != tc.wantTrimmed {
+				t.Errorf("Build() trimmed = %v, want trimmed %t", p.Trimmed, tc.wantTrimmed)
+			}
+			if p.Size() > tc.maxBytes {
+				t.Errorf("Build() size = %d, want at most %d", p.Size(), tc.maxBytes)
+			}
+			if !slices.ContainsFunc(p.Fields, func(f Field) bool { return f.Path == "region" }) {
+				t.Error("Build() left out the required region field")
+			}
+		})
+	}
+}
+
+func TestDocsSection(t *testing.T) {
+	cases := map[string]struct {
+		docs string
+		want string
+	}{
+		"NextHeading": {
+			docs: docs,
+			want: "## Bucket\n\nManages an S3 bucket.\nConnection details include `endpoint` and `region`.",
+		},
+		"CommentInFence": {
+			docs: "## Bucket\n\nExample:\n\n```yaml\n# Create a bucket\napiVersion: s3.aws.upbound.io/v1beta1\n```\n\nMore.\n\n## BucketPolicy\n",
+			want: "## Bucket\n\nExample:\n\n```yaml\n# Create a bucket\napiVersion: s3.aws.upbound.io/v1beta1\n```\n\nMore.",
+		},
+		"HeadingInFence": {
+			docs: "# Intro\n\n```\n# Bucket\n```\n\n# Bucket\n\nManages a bucket.\n",
+			want: "# Bucket\n\nManages a bucket.",
+		},
+		"Missing": {
+			docs: "# Intro\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := docsSection(tc.docs, "Bucket"); got != tc.want {
+				t.Errorf("docsSection() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSelectSchema(t *testing.T) {
+	cases := map[string]struct {
+		versions  []string
+		preferred string
+		want      string
+	}{
+		"Preferred":     {versions: []string{"v1", "v1beta1"}, preferred: "v1beta1", want: "v1beta1"},
+		"GAOverBeta":    {versions: []string{"v1", "v1beta1"}, want: "v1"},
+		"BetaOverAlpha": {versions: []string{"v1alpha1", "v1beta1"}, want: "v1beta1"},
+		"HigherMajor":   {versions: []string{"v1", "v2beta1", "v2"}, want: "v2"},
+		"HigherMinor":   {versions: []string{"v1beta2", "v1beta10"}, want: "v1beta10"},
+		"KubeOverOther": {versions: []string{"foo", "v1alpha1"}, want: "v1alpha1"},
+		"OtherLexical":  {versions: []string{"bar", "foo"}, want: "bar"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			def := &diff.Definition{Schemas: map[string]map[string]any{}}
+			for _, v := range tc.versions {
+				def.Schemas[v] = map[string]any{}
+			}
+			if got, _ := selectSchema(def, tc.preferred); got != tc.want {
+				t.Errorf("selectSchema() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
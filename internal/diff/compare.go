@@ -0,0 +1,107 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package diff
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+)
+
+// maxConcurrentFetches bounds the number of definitions fetched at once.
+const maxConcurrentFetches = 8
+
+// Compare fetches two versions of a package from a catalog and reports the
+// differences between their kinds and schemas. Kinds whose definitions cannot
+// be fetched are listed in the report's errors rather than failing it.
+func Compare(ctx context.Context, r catalog.ResourceReader, account, repository, from, to string) (*Report, error) {
+	old, err := r.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repository, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources of %s: %w", from, err)
+	}
+	cur, err := r.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repository, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resources of %s: %w", to, err)
+	}
+
+	rep := &Report{Account: account, Repository: repository, From: from, To: to, Changes: []Change{}}
+	rep.Add(Kinds(old, cur)...)
+
+	oldKinds, newKinds := kinds(old), kinds(cur)
+	var common []kind
+	for _, name := range union(oldKinds, newKinds) {
+		_, inOld := oldKinds[name]
+		if k, inNew := newKinds[name]; inOld && inNew {
+			common = append(common, k)
+		}
+	}
+
+	type result struct {
+		changes []Change
+		err     error
+	}
+	results := make([]result, len(common))
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for i, k := range common {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fd, err := definition(ctx, r, account, repository, from, k)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			td, err := definition(ctx, r, account, repository, to, k)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].changes = Definitions(fd, td)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.err != nil {
+			rep.Errors = append(rep.Errors, res.err.Error())
+			continue
+		}
+		rep.Add(res.changes...)
+	}
+	rep.Sort()
+	return rep, nil
+}
+
+func definition(ctx context.Context, r catalog.ResourceReader, account, repository, version string, k kind) (*Definition, error) {
+	raw, err := r.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repository, version, k.group, k.kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s.%s at %s: %w", k.kind, k.group, version, err)
+	}
+	d, err := ParseDefinition([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s.%s at %s: %w", k.kind, k.group, version, err)
+	}
+	// Catalogs key definitions by the group and kind they were asked for.
+	d.Group, d.Kind = k.group, k.kind
+	return d, nil
+}
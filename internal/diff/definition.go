@@ -0,0 +1,81 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Definition is the versioned schema of a CRD or XRD.
+type Definition struct {
+	Group string
+	Kind  string
+
+	// Schemas holds the OpenAPI v3 schema of each version.
+	Schemas map[string]map[string]any
+}
+
+// ParseDefinition parses a JSON encoded CRD or XRD, as returned by a catalog.
+// The definition may be wrapped in another object.
+func ParseDefinition(raw []byte) (*Definition, error) {
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode definition: %w", err)
+	}
+	spec := findSpec(obj, 2)
+	if spec == nil {
+		return nil, errors.New("response does not contain a CRD or XRD")
+	}
+
+	d := &Definition{
+		Group:   str(spec["group"]),
+		Kind:    str(object(spec["names"])["kind"]),
+		Schemas: map[string]map[string]any{},
+	}
+	versions, _ := spec["versions"].([]any)
+	for _, v := range versions {
+		ver := object(v)
+		schema := object(object(ver["schema"])["openAPIV3Schema"])
+		if name := str(ver["name"]); name != "" && schema != nil {
+			d.Schemas[name] = schema
+		}
+	}
+	return d, nil
+}
+
+// findSpec returns the spec of the first object that looks like a CRD or XRD,
+// searching up to depth levels of nesting.
+func findSpec(obj map[string]any, depth int) map[string]any {
+	if spec := object(obj["spec"]); spec != nil {
+		if _, ok := spec["versions"]; ok {
+			return spec
+		}
+	}
+	if depth == 0 {
+		return nil
+	}
+	for _, v := range obj {
+		if m := object(v); m != nil {
+			if spec := findSpec(m, depth-1); spec != nil {
+				return spec
+			}
+		}
+	}
+	return nil
+}
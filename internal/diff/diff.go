@@ -0,0 +1,182 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// A ChangeType describes what changed between two package versions.
+type ChangeType string
+
+// Change types.
+const (
+	KindAdded             ChangeType = "KindAdded"
+	KindRemoved           ChangeType = "KindRemoved"
+	VersionAdded          ChangeType = "VersionAdded"
+	VersionRemoved        ChangeType = "VersionRemoved"
+	StorageVersionChanged ChangeType = "StorageVersionChanged"
+	FieldAdded            ChangeType = "FieldAdded"
+	FieldRemoved          ChangeType = "FieldRemoved"
+	FieldTypeChanged      ChangeType = "FieldTypeChanged"
+	FieldRequired         ChangeType = "FieldRequired"
+	EnumValueAdded        ChangeType = "EnumValueAdded"
+	EnumValueRemoved      ChangeType = "EnumValueRemoved"
+	EnumRestricted        ChangeType = "EnumRestricted"
+)
+
+// Change is a single difference between two package versions.
+type Change struct {
+	Type     ChangeType `json:"type"`
+	Group    string     `json:"group"`
+	Kind     string     `json:"kind"`
+	Version  string     `json:"version,omitempty"`
+	Path     string     `json:"path,omitempty"`
+	Detail   string     `json:"detail"`
+	Breaking bool       `json:"breaking"`
+}
+
+// Report is the difference between two versions of a package.
+type Report struct {
+	Account    string   `json:"account"`
+	Repository string   `json:"repository"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Changes    []Change `json:"changes"`
+	Breaking   int      `json:"breaking"`
+
+	// Errors lists the kinds whose schemas could not be compared.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Add appends changes to the report.
+func (r *Report) Add(changes ...Change) {
+	for _, c := range changes {
+		if c.Breaking {
+			r.Breaking++
+		}
+	}
+	r.Changes = append(r.Changes, changes...)
+}
+
+// Sort orders the report's changes by kind, version and path.
+func (r *Report) Sort() {
+	slices.SortStableFunc(r.Changes, func(a, b Change) int {
+		return cmp.Or(
+			cmp.Compare(a.Group, b.Group),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Type, b.Type),
+		)
+	})
+}
+
+// kind is the version summary of a CRD or XRD.
+type kind struct {
+	group, kind string
+	versions    []string
+	storage     string
+}
+
+func kinds(r *marketplace.PackageResources) map[string]kind {
+	out := map[string]kind{}
+	if r == nil {
+		return out
+	}
+	for _, c := range r.CRDs {
+		out[c.Kind+"."+c.Group] = kind{group: c.Group, kind: c.Kind, versions: c.Versions, storage: c.StorageVersion}
+	}
+	for _, x := range r.XRDs {
+		out[x.Kind+"."+x.Group] = kind{group: x.Group, kind: x.Kind, versions: x.Versions}
+	}
+	return out
+}
+
+// Kinds returns the kinds added to and removed from a package, and the
+// changes to the served and storage versions of the kinds in both.
+func Kinds(from, to *marketplace.PackageResources) []Change {
+	old, cur := kinds(from), kinds(to)
+	var out []Change
+	for _, name := range union(old, cur) {
+		o, inOld := old[name]
+		n, inNew := cur[name]
+		switch {
+		case !inNew:
+			out = append(out, Change{Type: KindRemoved, Group: o.group, Kind: o.kind, Detail: "kind was removed", Breaking: true})
+			continue
+		case !inOld:
+			out = append(out, Change{Type: KindAdded, Group: n.group, Kind: n.kind, Detail: "kind was added"})
+			continue
+		}
+		for _, v := range o.versions {
+			if !slices.Contains(n.versions, v) {
+				out = append(out, Change{Type: VersionRemoved, Group: n.group, Kind: n.kind, Version: v, Detail: "version is no longer served", Breaking: true})
+			}
+		}
+		for _, v := range n.versions {
+			if !slices.Contains(o.versions, v) {
+				out = append(out, Change{Type: VersionAdded, Group: n.group, Kind: n.kind, Version: v, Detail: "version was added"})
+			}
+		}
+		if o.storage != "" && n.storage != "" && o.storage != n.storage {
+			out = append(out, Change{
+				Type:   StorageVersionChanged,
+				Group:  n.group,
+				Kind:   n.kind,
+				Detail: fmt.Sprintf("storage version changed from %s to %s; existing objects are migrated on write", o.storage, n.storage),
+			})
+		}
+	}
+	return out
+}
+
+// Definitions returns the schema changes between two definitions of the same
+// kind, for every version both define.
+func Definitions(from, to *Definition) []Change {
+	var out []Change
+	for _, v := range slices.Sorted(maps.Keys(from.Schemas)) {
+		n, ok := to.Schemas[v]
+		if !ok {
+			continue
+		}
+		for _, c := range Schema(from.Schemas[v], n) {
+			c.Group, c.Kind, c.Version = to.Group, to.Kind, v
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func union[V any](a, b map[string]V) []string {
+	out := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		out = append(out, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,254 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// schema decodes a JSON schema literal.
+func schema(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+type want struct {
+	Type     ChangeType
+	Path     string
+	Breaking bool
+}
+
+func TestSchema(t *testing.T) {
+	cases := map[string]struct {
+		from, to string
+		want     []want
+	}{
+		"NoChanges": {
+			from: `{"type":"object","properties":{"spec":{"type":"object","properties":{"region":{"type":"string"}}}}}`,
+			to:   `{"type":"object","properties":{"spec":{"type":"object","properties":{"region":{"type":"string"}}}}}`,
+		},
+		"FieldAdded": {
+			from: `{"properties":{"spec":{"properties":{}}}}`,
+			to:   `{"properties":{"spec":{"properties":{"tags":{"type":"object"}}}}}`,
+			want: []want{{FieldAdded, "spec.tags", false}},
+		},
+		"RequiredFieldAdded": {
+			from: `{"properties":{"spec":{"properties":{}}}}`,
+			to:   `{"properties":{"spec":{"required":["region"],"properties":{"region":{"type":"string"}}}}}`,
+			want: []want{{FieldAdded, "spec.region", true}},
+		},
+		"FieldRemoved": {
+			from: `{"properties":{"spec":{"properties":{"acl":{"type":"string"}}}}}`,
+			to:   `{"properties":{"spec":{"properties":{}}}}`,
+			want: []want{{FieldRemoved, "spec.acl", true}},
+		},
+		"FieldNewlyRequired": {
+			from: `{"properties":{"spec":{"properties":{"region":{"type":"string"}}}}}`,
+			to:   `{"properties":{"spec":{"required":["region"],"properties":{"region":{"type":"string"}}}}}`,
+			want: []want{{FieldRequired, "spec.region", true}},
+		},
+		"TypeChanged": {
+			from: `{"properties":{"spec":{"properties":{"size":{"type":"string"}}}}}`,
+			to:   `{"properties":{"spec":{"properties":{"size":{"type":"integer"}}}}}`,
+			want: []want{{FieldTypeChanged, "spec.size", true}},
+		},
+		"EnumValues": {
+			from: `{"properties":{"tier":{"type":"string","enum":["a","b"]}}}`,
+			to:   `{"properties":{"tier":{"type":"string","enum":["b","c"]}}}`,
+			want: []want{{EnumValueRemoved, "tier", true}, {EnumValueAdded, "tier", false}},
+		},
+		"EnumIntroduced": {
+			from: `{"properties":{"tier":{"type":"string"}}}`,
+			to:   `{"properties":{"tier":{"type":"string","enum":["a"]}}}`,
+			want: []want{{EnumRestricted, "tier", true}},
+		},
+		"EnumRestrictedInStatus": {
+			from: `{"properties":{"status":{"properties":{"phase":{"type":"string"}}}}}`,
+			to:   `{"properties":{"status":{"properties":{"phase":{"type":"string","enum":["Ready"]}}}}}`,
+			want: []want{{EnumRestricted, "status.phase", false}},
+		},
+		"ArrayItems": {
+			from: `{"properties":{"rules":{"type":"array","items":{"properties":{"id":{"type":"string"}}}}}}`,
+			to:   `{"properties":{"rules":{"type":"array","items":{"properties":{}}}}}`,
+			want: []want{{FieldRemoved, "rules[*].id", true}},
+		},
+		"MapValues": {
+			from: `{"properties":{"labels":{"type":"object","additionalProperties":{"type":"string"}}}}`,
+			to:   `{"properties":{"labels":{"type":"object","additionalProperties":{"type":"integer"}}}}`,
+			want: []want{{FieldTypeChanged, "labels{*}", true}},
+		},
+		"StatusIsNeverBreaking": {
+			from: `{"properties":{"status":{"properties":{"atProvider":{"properties":{"arn":{"type":"string"}}}}}}}`,
+			to:   `{"properties":{"status":{"properties":{"atProvider":{"properties":{}}}}}}`,
+			want: []want{{FieldRemoved, "status.atProvider.arn", false}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Schema(schema(t, tc.from), schema(t, tc.to))
+			if len(got) != len(tc.want) {
+				t.Fatalf("Schema() = %+v, want %d changes", got, len(tc.want))
+			}
+			for i, c := range got {
+				if g := (want{c.Type, c.Path, c.Breaking}); g != tc.want[i] {
+					t.Errorf("change %d = %+v, want %+v", i, g, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKinds(t *testing.T) {
+	crd := func(kind, storage string, versions ...string) marketplace.CRDMeta {
+		return marketplace.CRDMeta{Group: "s3.aws.upbound.io", Kind: kind, Versions: versions, StorageVersion: storage}
+	}
+
+	cases := map[string]struct {
+		from, to []marketplace.CRDMeta
+		want     []want
+	}{
+		"KindAddedAndRemoved": {
+			from: []marketplace.CRDMeta{crd("BucketACL", "v1beta1", "v1beta1")},
+			to:   []marketplace.CRDMeta{crd("BucketPolicy", "v1beta1", "v1beta1")},
+			want: []want{{KindRemoved, "", true}, {KindAdded, "", false}},
+		},
+		"VersionsAndStorage": {
+			from: []marketplace.CRDMeta{crd("Bucket", "v1beta1", "v1alpha1", "v1beta1")},
+			to:   []marketplace.CRDMeta{crd("Bucket", "v1beta2", "v1beta1", "v1beta2")},
+			want: []want{{VersionRemoved, "", true}, {VersionAdded, "", false}, {StorageVersionChanged, "", false}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Kinds(&marketplace.PackageResources{CRDs: tc.from}, &marketplace.PackageResources{CRDs: tc.to})
+			if len(got) != len(tc.want) {
+				t.Fatalf("Kinds() = %+v, want %d changes", got, len(tc.want))
+			}
+			for i, c := range got {
+				if g := (want{c.Type, c.Path, c.Breaking}); g != tc.want[i] {
+					t.Errorf("change %d = %+v, want %+v", i, g, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseDefinition(t *testing.T) {
+	cases := map[string]string{
+		"CRD":     `{"kind":"CustomResourceDefinition","spec":{"group":"s3.aws.upbound.io","names":{"kind":"Bucket"},"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"type":"object"}}}]}}`,
+		"Wrapped": `{"crd":{"spec":{"group":"s3.aws.upbound.io","names":{"kind":"Bucket"},"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"type":"object"}}}]}}}`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, err := ParseDefinition([]byte(raw))
+			if err != nil {
+				t.Fatalf("ParseDefinition() error = %v", err)
+			}
+			if d.Kind != "Bucket" || d.Schemas["v1beta1"] == nil {
+				t.Errorf("ParseDefinition() = %+v", d)
+			}
+		})
+	}
+	if _, err := ParseDefinition([]byte(`{"kind":"Composition"}`)); err == nil {
+		t.Error("expected an error parsing an object that is not a definition")
+	}
+}
+
+// fakeReader serves package resources and definitions by version.
+type fakeReader struct {
+	catalog.ResourceReader
+
+	resources   map[string]*marketplace.PackageResources
+	definitions map[string]string
+}
+
+func (f *fakeReader) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, _, version string) (*marketplace.PackageResources, error) {
+	return f.resources[version], nil
+}
+
+func (f *fakeReader) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, _, _, version, _, kind string) (string, error) {
+	d, ok := f.definitions[version+"/"+kind]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return d, nil
+}
+
+func TestCompare(t *testing.T) {
+	def := func(props string) string {
+		return `{"spec":{"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"properties":{"spec":{"properties":{` + props + `}}}}}}]}}`
+	}
+	crds := func(kinds ...string) *marketplace.PackageResources {
+		r := &marketplace.PackageResources{}
+		for _, k := range kinds {
+			r.CRDs = append(r.CRDs, marketplace.CRDMeta{Group: "s3.aws.upbound.io", Kind: k, Versions: []string{"v1beta1"}, StorageVersion: "v1beta1"})
+		}
+		return r
+	}
+	r := &fakeReader{
+		resources: map[string]*marketplace.PackageResources{
+			"v1.14.0": crds("Bucket", "BucketACL", "BucketPolicy"),
+			"v1.20.0": crds("Bucket", "BucketPolicy"),
+		},
+		definitions: map[string]string{
+			"v1.14.0/Bucket":       def(`"acl":{"type":"string"}`),
+			"v1.20.0/Bucket":       def(`"tags":{"type":"object"}`),
+			"v1.14.0/BucketPolicy": def(``),
+		},
+	}
+
+	rep, err := Compare(context.Background(), r, "upbound", "provider-aws-s3", "v1.14.0", "v1.20.0")
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	got := make([]want, 0, len(rep.Changes))
+	for _, c := range rep.Changes {
+		got = append(got, want{c.Type, c.Path, c.Breaking})
+	}
+	expect := []want{
+		{FieldRemoved, "spec.acl", true},
+		{FieldAdded, "spec.tags", false},
+		{KindRemoved, "", true},
+	}
+	if len(got) != len(expect) {
+		t.Fatalf("Compare() changes = %+v, want %+v", got, expect)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], expect[i])
+		}
+	}
+	if rep.Breaking != 2 {
+		t.Errorf("Breaking = %d, want 2", rep.Breaking)
+	}
+	if len(rep.Errors) != 1 {
+		t.Errorf("Errors = %q, want one error for BucketPolicy", rep.Errors)
+	}
+}
@@ -0,0 +1,123 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Schema returns the changes between two OpenAPI v3 schemas. Paths are dotted
+// field paths, with [*] for array items and {*} for map values.
+//
+// Removing a field, changing its type, removing an enum value, restricting a
+// field to an enum, or requiring a field are breaking changes. Changes to
+// fields under status are never breaking, since users do not write them.
+func Schema(from, to map[string]any) []Change {
+	var out []Change
+	walk("", from, to, &out)
+	return out
+}
+
+func walk(path string, from, to map[string]any, out *[]Change) {
+	add := func(t ChangeType, p, detail string, breaking bool) {
+		*out = append(*out, Change{Type: t, Path: p, Detail: detail, Breaking: breaking && !isStatus(p)})
+	}
+
+	if ot, nt := str(from["type"]), str(to["type"]); ot != "" && nt != "" && ot != nt {
+		add(FieldTypeChanged, path, fmt.Sprintf("type changed from %s to %s", ot, nt), true)
+		return
+	}
+
+	oe, ne := values(from["enum"]), values(to["enum"])
+	switch {
+	case len(oe) == 0 && len(ne) > 0:
+		add(EnumRestricted, path, fmt.Sprintf("field is now enum-restricted to %s", strings.Join(ne, ", ")), true)
+	case len(ne) > 0:
+		for _, v := range oe {
+			if !slices.Contains(ne, v) {
+				add(EnumValueRemoved, path, fmt.Sprintf("enum value %q was removed", v), true)
+			}
+		}
+		for _, v := range ne {
+			if !slices.Contains(oe, v) {
+				add(EnumValueAdded, path, fmt.Sprintf("enum value %q was added", v), false)
+			}
+		}
+	}
+
+	op, np := object(from["properties"]), object(to["properties"])
+	oreq, nreq := values(from["required"]), values(to["required"])
+	for _, name := range union(op, np) {
+		p := join(path, name)
+		o, inOld := op[name]
+		n, inNew := np[name]
+		required := slices.Contains(nreq, name)
+		switch {
+		case !inNew:
+			add(FieldRemoved, p, "field was removed", true)
+		case !inOld && required:
+			add(FieldAdded, p, "required field was added", true)
+		case !inOld:
+			add(FieldAdded, p, "field was added", false)
+		default:
+			if required && !slices.Contains(oreq, name) {
+				add(FieldRequired, p, "field is now required", true)
+			}
+			walk(p, object(o), object(n), out)
+		}
+	}
+
+	if oi, ni := object(from["items"]), object(to["items"]); oi != nil && ni != nil {
+		walk(path+"[*]", oi, ni, out)
+	}
+	if oa, na := object(from["additionalProperties"]), object(to["additionalProperties"]); oa != nil && na != nil {
+		walk(path+"{*}", oa, na, out)
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func isStatus(path string) bool {
+	return path == "status" || strings.HasPrefix(path, "status.")
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func object(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// values returns the string representations of a list of values.
+func values(v any) []string {
+	l, _ := v.([]any)
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		out = append(out, fmt.Sprint(e))
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package fields
+
+import (
+	"slices"
+	"strings"
+)
+
+// Field is a field in the schema of a kind defined by a package version.
+type Field struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+	Group      string `json:"group"`
+	Kind       string `json:"kind"`
+
+	// APIVersion is the version of the kind whose schema the field is in.
+	APIVersion  string `json:"apiVersion"`
+	Path        string `json:"path"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// skipped are the top-level fields every kind shares.
+var skipped = []string{"apiVersion", "kind", "metadata"}
+
+// Extract returns the fields of an OpenAPI v3 schema. Paths are dotted field
+// paths, with [*] for array items and {*} for map values.
+func Extract(schema map[string]any) []Field {
+	var out []Field
+	extract("", schema, &out)
+	return out
+}
+
+func extract(path string, schema map[string]any, out *[]Field) {
+	props, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for n := range props {
+		if path == "" && slices.Contains(skipped, n) {
+			continue
+		}
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	for _, n := range names {
+		p := n
+		if path != "" {
+			p = path + "." + n
+		}
+		prop, _ := props[n].(map[string]any)
+		desc, _ := prop["description"].(string)
+		typ, _ := prop["type"].(string)
+		*out = append(*out, Field{Path: p, Type: typ, Description: strings.TrimSpace(desc)})
+		extract(p, prop, out)
+		if items, ok := prop["items"].(map[string]any); ok {
+			extract(p+"[*]", items, out)
+		}
+		if values, ok := prop["additionalProperties"].(map[string]any); ok {
+			extract(p+"{*}", values, out)
+		}
+	}
+}
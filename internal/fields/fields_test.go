@@ -0,0 +1,234 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package fields
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := map[string]struct {
+		text string
+		want []string
+	}{
+		"CamelCase":  {text: "kmsKeyId", want: []string{"kms", "key", "id"}},
+		"Acronym":    {text: "KMSKey", want: []string{"kms", "key"}},
+		"Path":       {text: "spec.forProvider.serverSideEncryption", want: []string{"spec", "provider", "server", "side", "encryption"}},
+		"Plurals":    {text: "Policies for buckets", want: []string{"policy", "bucket"}},
+		"Stopwords":  {text: "The name of the bucket", want: []string{"name", "bucket"}},
+		"KeepsShort": {text: "status address", want: []string{"status", "address"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := tokenize(tc.text); !slices.Equal(got, tc.want) {
+				t.Errorf("tokenize(%q) = %v, want %v", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	schema := map[string]any{
+		"properties": map[string]any{
+			"apiVersion": map[string]any{"type": "string"},
+			"spec": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"rules": map[string]any{
+						"type":        "array",
+						"description": " Lifecycle rules. ",
+						"items": map[string]any{
+							"properties": map[string]any{"days": map[string]any{"type": "integer"}},
+						},
+					},
+					"tags": map[string]any{
+						"type":                 "object",
+						"additionalProperties": map[string]any{"properties": map[string]any{"value": map[string]any{"type": "string"}}},
+					},
+				},
+			},
+		},
+	}
+	var got []string
+	for _, f := range Extract(schema) {
+		got = append(got, f.Path+":"+f.Type+":"+f.Description)
+	}
+	want := []string{"spec:object:", "spec.rules:array:Lifecycle rules.", "spec.rules[*].days:integer:", "spec.tags:object:", "spec.tags{*}.value:string:"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Extract() = %v, want %v", got, want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	idx, _ := Open("")
+	idx.Put("upbound", "provider-aws-s3", "v1.20.0", []Field{
+		{Kind: "Bucket", Path: "spec.forProvider.region", Description: "Region is the region you'd like your resource to be created in."},
+		{Kind: "BucketServerSideEncryptionConfiguration", Path: "spec.forProvider.rule[*].applyServerSideEncryptionByDefault[*].kmsMasterKeyId", Description: "The AWS KMS master key ID used for the SSE-KMS encryption."},
+		{Kind: "BucketVersioning", Path: "spec.forProvider.versioningConfiguration[*].status", Description: "Versioning state of the bucket."},
+	})
+	idx.Put("upbound", "provider-gcp-storage", "v1.8.0", []Field{
+		{Kind: "Bucket", Path: "spec.forProvider.encryption[*].defaultKmsKeyName", Description: "The id of a Cloud KMS key that will be used to encrypt objects."},
+		{Kind: "Bucket", Path: "spec.forProvider.versioning[*].enabled", Description: "While set to true, versioning is fully enabled for this bucket."},
+	})
+
+	cases := map[string]struct {
+		query string
+		o     SearchOptions
+		want  []string
+	}{
+		"RanksNameMatches": {
+			query: "kms key",
+			want:  []string{"provider-gcp-storage:Bucket:spec.forProvider.encryption[*].defaultKmsKeyName", "provider-aws-s3:BucketServerSideEncryptionConfiguration:spec.forProvider.rule[*].applyServerSideEncryptionByDefault[*].kmsMasterKeyId"},
+		},
+		"Repository": {
+			query: "versioning",
+			o:     SearchOptions{Account: "upbound", Repository: "provider-gcp-storage"},
+			want:  []string{"provider-gcp-storage:Bucket:spec.forProvider.versioning[*].enabled"},
+		},
+		"Kind": {
+			query: "region",
+			o:     SearchOptions{Kind: "bucket"},
+			want:  []string{"provider-aws-s3:Bucket:spec.forProvider.region"},
+		},
+		"Limit": {
+			query: "encryption",
+			o:     SearchOptions{Limit: 1},
+			want:  []string{"provider-aws-s3:BucketServerSideEncryptionConfiguration:spec.forProvider.rule[*].applyServerSideEncryptionByDefault[*].kmsMasterKeyId"},
+		},
+		"NoMatch":       {query: "database"},
+		"OnlyStopwords": {query: "the of"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, h := range idx.Search(tc.query, tc.o) {
+				got = append(got, h.Repository+":"+h.Kind+":"+h.Path)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPutReplacesPackage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fields.json")
+	idx, _ := Open(path)
+	idx.Put("upbound", "provider-aws-s3", "v1.19.0", []Field{{Kind: "Bucket", Path: "spec.forProvider.acl"}})
+	idx.Put("upbound", "provider-aws-s3", "v1.20.0", []Field{{Kind: "Bucket", Path: "spec.forProvider.region"}})
+	if err := idx.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if v, _ := loaded.Version("upbound", "provider-aws-s3"); v != "v1.20.0" {
+		t.Errorf("Version() = %q, want v1.20.0", v)
+	}
+	if hits := loaded.Search("acl", SearchOptions{}); len(hits) != 0 {
+		t.Errorf("Search(acl) = %+v, want no hits from the replaced version", hits)
+	}
+	if hits := loaded.Search("region", SearchOptions{}); len(hits) != 1 || hits[0].Version != "v1.20.0" {
+		t.Errorf("Search(region) = %+v, want one hit at v1.20.0", hits)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := "Lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. " +
+		"Ut enim ad minim veniam quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. " +
+		"The KMS key used to encrypt objects in the bucket. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum."
+	got := Snippet(long, []string{"kms"})
+	if len(got) > snippetLength+6 || got[:3] != "..." || !strings.Contains(got, "KMS key") {
+		t.Errorf("Snippet() = %q, want a shortened snippet around the KMS key", got)
+	}
+	if got := Snippet("Short description.", []string{"short"}); got != "Short description." {
+		t.Errorf("Snippet() = %q, want the whole description", got)
+	}
+}
+
+// fakeReader serves the definitions of one package version.
+type fakeReader struct {
+	catalog.ResourceReader
+
+	defs  map[string]any
+	reads int
+}
+
+func (f *fakeReader) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, _, _ string) (*marketplace.PackageResources, error) {
+	f.reads++
+	r := &marketplace.PackageResources{}
+	for k := range f.defs {
+		r.CRDs = append(r.CRDs, marketplace.CRDMeta{Group: "s3.aws.upbound.io", Kind: k, StorageVersion: "v1beta2"})
+	}
+	return r, nil
+}
+
+func (f *fakeReader) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, _, _, _, _, kind string) (string, error) {
+	b, err := json.Marshal(f.defs[kind])
+	return string(b), err
+}
+
+func crd(versions ...string) map[string]any {
+	vs := make([]any, 0, len(versions))
+	for _, v := range versions {
+		vs = append(vs, map[string]any{
+			"name": v,
+			"schema": map[string]any{"openAPIV3Schema": map[string]any{
+				"properties": map[string]any{"spec": map[string]any{
+					"properties": map[string]any{v + "Field": map[string]any{"type": "string"}},
+				}},
+			}},
+		})
+	}
+	return map[string]any{"spec": map[string]any{"versions": vs}}
+}
+
+func TestUpdate(t *testing.T) {
+	r := &fakeReader{defs: map[string]any{"Bucket": crd("v1beta1", "v1beta2"), "Object": crd("v1beta1")}}
+	idx, _ := Open("")
+
+	updated, err := Update(context.Background(), r, idx, "upbound", "provider-aws-s3", "v1.20.0")
+	if err != nil || !updated {
+		t.Fatalf("Update() = %v, %v, want true, nil", updated, err)
+	}
+	var got []string
+	for _, h := range idx.Search("field", SearchOptions{}) {
+		got = append(got, h.Kind+":"+h.APIVersion+":"+h.Path)
+	}
+	slices.Sort(got)
+	want := []string{"Bucket:v1beta2:spec.v1beta2Field", "Object:v1beta1:spec.v1beta1Field"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+
+	if updated, err := Update(context.Background(), r, idx, "upbound", "provider-aws-s3", "v1.20.0"); err != nil || updated {
+		t.Errorf("second Update() = %v, %v, want false, nil", updated, err)
+	}
+	if r.reads != 1 {
+		t.Errorf("resources read %d times, want 1", r.reads)
+	}
+}
@@ -0,0 +1,315 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package fields
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/cache"
+)
+
+// FormatVersion is the version of the on-disk index format. Indexes written in
+// another format are discarded and rebuilt.
+const FormatVersion = 1
+
+const (
+	// BM25 term frequency saturation and length normalisation parameters.
+	k1 = 1.2
+	b  = 0.75
+
+	// nameWeight is how many times the terms of a field's own name count
+	// relative to the terms of its parent path and description.
+	nameWeight = 3
+
+	// DefaultLimit is the number of hits returned when no limit is given.
+	DefaultLimit = 10
+
+	snippetLength = 160
+)
+
+// file is the on-disk form of an index. Postings are rebuilt when it is
+// loaded.
+type file struct {
+	FormatVersion int       `json:"formatVersion"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// Packages maps account/repository to the indexed version.
+	Packages map[string]string `json:"packages"`
+	Fields   []Field           `json:"fields"`
+}
+
+// doc is an indexed field and its term frequencies.
+type doc struct {
+	field  Field
+	terms  map[string]int
+	length int
+}
+
+// Index is a BM25 full-text index of field paths and descriptions. It is safe
+// for concurrent use.
+type Index struct {
+	path string
+
+	mu        sync.RWMutex
+	updatedAt time.Time
+	packages  map[string]string
+
+	// docs holds the fields of each package, keyed by account/repository.
+	docs map[string][]*doc
+
+	// postings maps a term to the number of documents containing it.
+	postings map[string]int
+	total    int
+	length   int
+}
+
+// Open loads the index stored at path. A missing index, or one written in an
+// older format, opens empty. An empty path opens an index that is never
+// persisted.
+func Open(path string) (*Index, error) {
+	idx := &Index{path: path, packages: map[string]string{}, docs: map[string][]*doc{}, postings: map[string]int{}}
+	var data file
+	ok, err := cache.ReadJSON(path, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load field index: %w", err)
+	}
+	if !ok || data.FormatVersion != FormatVersion || data.Packages == nil {
+		return idx, nil
+	}
+	idx.updatedAt, idx.packages = data.UpdatedAt, data.Packages
+	for _, f := range data.Fields {
+		key := f.Account + "/" + f.Repository
+		idx.docs[key] = append(idx.docs[key], idx.add(f))
+	}
+	return idx, nil
+}
+
+// Save writes the index to disk. Saves are serialised, since they share a
+// temporary file.
+func (i *Index) Save() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	data := file{FormatVersion: FormatVersion, UpdatedAt: i.updatedAt, Packages: i.packages, Fields: make([]Field, 0, i.total)}
+	for _, key := range slices.Sorted(maps.Keys(i.docs)) {
+		for _, d := range i.docs[key] {
+			data.Fields = append(data.Fields, d.field)
+		}
+	}
+	return cache.WriteJSON(i.path, data)
+}
+
+// Version returns the indexed version of a package, if it is indexed.
+func (i *Index) Version(account, repository string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	v, ok := i.packages[account+"/"+repository]
+	return v, ok
+}
+
+// Len returns the number of indexed packages.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.packages)
+}
+
+// Put replaces the fields of a package with those of the supplied version of
+// it. The fields' package coordinates are set from the arguments.
+func (i *Index) Put(account, repository, version string, fields []Field) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key := account + "/" + repository
+	for _, d := range i.docs[key] {
+		i.remove(d)
+	}
+	docs := make([]*doc, 0, len(fields))
+	for _, f := range fields {
+		f.Account, f.Repository, f.Version = account, repository, version
+		docs = append(docs, i.add(f))
+	}
+	i.docs[key] = docs
+	i.packages[key] = version
+	i.updatedAt = time.Now().UTC()
+}
+
+// add indexes a field. The caller must hold the write lock.
+func (i *Index) add(f Field) *doc {
+	d := &doc{field: f, terms: terms(f)}
+	for t, n := range d.terms {
+		i.postings[t]++
+		d.length += n
+	}
+	i.total++
+	i.length += d.length
+	return d
+}
+
+// remove removes a field from the term statistics. The caller must hold the
+// write lock.
+func (i *Index) remove(d *doc) {
+	for t := range d.terms {
+		if i.postings[t]--; i.postings[t] <= 0 {
+			delete(i.postings, t)
+		}
+	}
+	i.total--
+	i.length -= d.length
+}
+
+// terms returns the weighted term frequencies of a field.
+func terms(f Field) map[string]int {
+	out := map[string]int{}
+	parent, name := "", f.Path
+	if j := strings.LastIndex(f.Path, "."); j >= 0 {
+		parent, name = f.Path[:j], f.Path[j+1:]
+	}
+	for _, t := range tokenize(name) {
+		out[t] += nameWeight
+	}
+	for _, t := range tokenize(parent + " " + f.Kind + " " + f.Description) {
+		out[t]++
+	}
+	return out
+}
+
+// SearchOptions restrict a search.
+type SearchOptions struct {
+	Account    string
+	Repository string
+
+	// Kind matches the kind of a field case insensitively.
+	Kind string
+
+	// Limit is the maximum number of hits. Defaults to DefaultLimit.
+	Limit int
+}
+
+func (o SearchOptions) matches(f Field) bool {
+	return (o.Account == "" || f.Account == o.Account) &&
+		(o.Repository == "" || f.Repository == o.Repository) &&
+		(o.Kind == "" || strings.EqualFold(f.Kind, o.Kind))
+}
+
+// Hit is a field matching a search.
+type Hit struct {
+	Field
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// Search returns the fields that best match a free text query, ranked by
+// BM25 score.
+func (i *Index) Search(query string, o SearchOptions) []Hit {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	qterms := tokenize(query)
+	if len(qterms) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.total == 0 {
+		return nil
+	}
+	avg := float64(i.length) / float64(i.total)
+	idf := make(map[string]float64, len(qterms))
+	for _, t := range qterms {
+		n := float64(i.postings[t])
+		idf[t] = math.Log(1 + (float64(i.total)-n+0.5)/(n+0.5))
+	}
+
+	var hits []Hit
+	for _, docs := range i.docs {
+		for _, d := range docs {
+			if !o.matches(d.field) {
+				continue
+			}
+			score := 0.0
+			for _, t := range qterms {
+				tf := float64(d.terms[t])
+				if tf == 0 {
+					continue
+				}
+				score += idf[t] * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(d.length)/avg))
+			}
+			if score > 0 {
+				hits = append(hits, Hit{Field: d.field, Score: score})
+			}
+		}
+	}
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Account+"/"+a.Repository+"/"+a.Kind+"/"+a.Path, b.Account+"/"+b.Repository+"/"+b.Kind+"/"+b.Path)
+	})
+	hits = hits[:min(len(hits), o.Limit)]
+	for j := range hits {
+		hits[j].Snippet = Snippet(hits[j].Description, qterms)
+		hits[j].Description = ""
+	}
+	return hits
+}
+
+// Snippet returns the part of a description around its first occurrence of
+// one of the supplied terms, shortened to about snippetLength characters.
+func Snippet(description string, terms []string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if len(description) <= snippetLength {
+		return description
+	}
+	lower := strings.ToLower(description)
+	start := 0
+	for _, t := range terms {
+		if j := strings.Index(lower, t); j >= 0 {
+			start = max(0, j-snippetLength/4)
+			break
+		}
+	}
+	// Start and end at word boundaries.
+	if start > 0 {
+		if j := strings.IndexByte(description[start:], ' '); j >= 0 {
+			start += j + 1
+		}
+	}
+	end := min(len(description), start+snippetLength)
+	if end < len(description) {
+		if j := strings.LastIndexByte(description[start:end], ' '); j > 0 {
+			end = start + j
+		}
+	}
+	s := description[start:end]
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(description) {
+		s += "..."
+	}
+	return s
+}
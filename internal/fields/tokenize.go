@@ -0,0 +1,93 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package fields
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are common words that carry no meaning in field descriptions.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "be": true,
+	"by": true, "can": true, "for": true, "if": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "when": true, "which": true, "will": true,
+	"with": true,
+}
+
+// tokenize splits text into lower case terms. Words are split at
+// non-alphanumeric characters and at camel case boundaries, so that field
+// names such as kmsKeyId match the words kms, key and id. Stopwords are
+// dropped and plurals reduced to their singular.
+func tokenize(text string) []string {
+	var out []string
+	for _, w := range words(text) {
+		w = strings.ToLower(w)
+		if stopwords[w] {
+			continue
+		}
+		out = append(out, stem(w))
+	}
+	return out
+}
+
+// words splits text at non-alphanumeric characters and camel case
+// boundaries. A run of capitals followed by a lower case letter ends before
+// the last capital, so KMSKey splits into KMS and Key.
+func words(text string) []string {
+	var out []string
+	rs := []rune(text)
+	start := -1
+	for i, r := range rs {
+		alnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if !alnum {
+			if start >= 0 {
+				out = append(out, string(rs[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := rs[i-1]
+		upperAfterLower := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1])
+		if upperAfterLower || acronymEnd {
+			out = append(out, string(rs[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		out = append(out, string(rs[start:]))
+	}
+	return out
+}
+
+// stem reduces a plural to its singular with a few suffix rules.
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	default:
+		return w
+	}
+}
@@ -0,0 +1,132 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package fields
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// maxConcurrentFetches bounds the number of definitions fetched at once.
+const maxConcurrentFetches = 8
+
+// definition is a kind defined by a package and the version of it whose
+// schema is indexed.
+type definition struct {
+	group, kind, version string
+}
+
+// Update indexes the fields of every kind defined by a package version,
+// replacing any fields indexed for an earlier version. It does nothing if the
+// version is already indexed. Kinds whose definitions cannot be fetched are
+// skipped; an error is returned only if none can be.
+func Update(ctx context.Context, r catalog.ResourceReader, idx *Index, account, repository, version string) (bool, error) {
+	if v, ok := idx.Version(account, repository); ok && v == version {
+		return false, nil
+	}
+	res, err := r.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repository, version)
+	if err != nil {
+		return false, fmt.Errorf("failed to get resources of %s/%s:%s: %w", account, repository, version, err)
+	}
+	fields, err := Fetch(ctx, r, account, repository, version, res)
+	if err != nil {
+		return false, err
+	}
+	idx.Put(account, repository, version, fields)
+	return true, nil
+}
+
+// Fetch returns the fields of every kind a package version defines, given the
+// version's resources, without indexing them. Kinds whose definitions cannot
+// be fetched are skipped; an error is returned only if none can be.
+func Fetch(ctx context.Context, r catalog.ResourceReader, account, repository, version string, res *marketplace.PackageResources) ([]Field, error) {
+	defs := make([]definition, 0, len(res.CRDs)+len(res.XRDs))
+	for _, c := range res.CRDs {
+		defs = append(defs, definition{group: c.Group, kind: c.Kind, version: c.StorageVersion})
+	}
+	for _, x := range res.XRDs {
+		defs = append(defs, definition{group: x.Group, kind: x.Kind, version: x.ReferenceableVersion})
+	}
+
+	results := make([][]Field, len(defs))
+	errs := make([]error, len(defs))
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for j, d := range defs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[j], errs[j] = fetch(ctx, r, account, repository, version, d)
+		}()
+	}
+	wg.Wait()
+
+	var fields []Field
+	failed := 0
+	for j := range defs {
+		if errs[j] != nil {
+			failed++
+			continue
+		}
+		fields = append(fields, results[j]...)
+	}
+	if len(defs) > 0 && failed == len(defs) {
+		return nil, errs[0]
+	}
+	return fields, nil
+}
+
+// fetch returns the fields of a kind's schema.
+func fetch(ctx context.Context, r catalog.ResourceReader, account, repository, version string, d definition) ([]Field, error) {
+	raw, err := r.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repository, version, d.group, d.kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s.%s: %w", d.kind, d.group, err)
+	}
+	def, err := diff.ParseDefinition([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s.%s: %w", d.kind, d.group, err)
+	}
+	apiVersion, schema := selectSchema(def, d.version)
+	fields := Extract(schema)
+	for j := range fields {
+		fields[j].Group, fields[j].Kind, fields[j].APIVersion = d.group, d.kind, apiVersion
+	}
+	return fields, nil
+}
+
+// selectSchema returns the preferred version of a definition and its schema,
+// falling back to the lexically greatest version when the definition does not
+// have it.
+func selectSchema(def *diff.Definition, preferred string) (string, map[string]any) {
+	if s, ok := def.Schemas[preferred]; ok {
+		return preferred, s
+	}
+	last := ""
+	for v := range def.Schemas {
+		if last == "" || v > last {
+			last = v
+		}
+	}
+	return last, def.Schemas[last]
+}
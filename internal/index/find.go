@@ -0,0 +1,139 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package index
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// defaultLimit is the number of matches Find returns when no limit is given.
+const defaultLimit = 20
+
+// PackageVersion is a package version that defines a kind.
+type PackageVersion struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+}
+
+// Match is a kind that matches a query, and the packages that define it.
+type Match struct {
+	Group      string           `json:"group"`
+	Kind       string           `json:"kind"`
+	Plural     string           `json:"plural"`
+	ShortNames []string         `json:"shortNames,omitempty"`
+	Type       string           `json:"type"`
+	Score      int              `json:"score"`
+	Packages   []PackageVersion `json:"packages"`
+}
+
+// Find returns the kinds whose kind, plural or short names match the query,
+// best match first. Matches may be exact, prefixes, substrings, or within a
+// small edit distance. A query of the form <name>.<group> only matches kinds
+// whose group starts with <group>.
+func (i *Index) Find(query string, limit int) []Match {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	name, group, _ := strings.Cut(strings.ToLower(strings.TrimSpace(query)), ".")
+	if name == "" {
+		return nil
+	}
+
+	i.mu.RLock()
+	matches := map[string]*Match{}
+	for _, e := range i.data.Entries {
+		if group != "" && !strings.HasPrefix(e.Group, group) {
+			continue
+		}
+		s := score(name, strings.ToLower(e.Kind))
+		for _, n := range append([]string{e.Plural}, e.ShortNames...) {
+			s = max(s, score(name, strings.ToLower(n)))
+		}
+		if s == 0 {
+			continue
+		}
+		key := e.Kind + "." + e.Group
+		m, ok := matches[key]
+		if !ok {
+			m = &Match{Group: e.Group, Kind: e.Kind, Plural: e.Plural, ShortNames: e.ShortNames, Type: e.Type, Score: s}
+			matches[key] = m
+		}
+		m.Packages = append(m.Packages, PackageVersion{Account: e.Account, Repository: e.Repository, Version: e.Version})
+	}
+	i.mu.RUnlock()
+
+	out := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		slices.SortFunc(m.Packages, func(a, b PackageVersion) int {
+			return cmp.Or(cmp.Compare(a.Account, b.Account), cmp.Compare(a.Repository, b.Repository))
+		})
+		out = append(out, *m)
+	}
+	slices.SortFunc(out, func(a, b Match) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(len(a.Kind), len(b.Kind)),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Group, b.Group),
+		)
+	})
+	return out[:min(len(out), limit)]
+}
+
+// score rates how well a lower case candidate name matches a lower case query,
+// from 0 (no match) to 100 (exact match).
+func score(query, candidate string) int {
+	switch {
+	case candidate == "":
+		return 0
+	case query == candidate:
+		return 100
+	case strings.HasPrefix(candidate, query):
+		return max(60, 90-(len(candidate)-len(query)))
+	case strings.Contains(candidate, query):
+		return 50
+	}
+	// Tolerate typos in all but the shortest queries.
+	if d := distance(query, candidate); d <= max(1, len(query)/4) {
+		return 40 - 10*d
+	}
+	return 0
+}
+
+// distance returns the Levenshtein distance between two strings.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,197 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package index
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/cache"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// FormatVersion is the version of the on-disk index format. Indexes written in
+// another format are discarded and rebuilt.
+const FormatVersion = 1
+
+// Definition types.
+const (
+	TypeCRD = "CRD"
+	TypeXRD = "XRD"
+)
+
+// Entry is a kind defined by a package version.
+type Entry struct {
+	Group      string   `json:"group"`
+	Kind       string   `json:"kind"`
+	Plural     string   `json:"plural"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Type       string   `json:"type"`
+	Account    string   `json:"account"`
+	Repository string   `json:"repository"`
+	Version    string   `json:"version"`
+}
+
+// file is the on-disk form of an index.
+type file struct {
+	FormatVersion int       `json:"formatVersion"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// CompletedAt is when an Update last indexed every package, as opposed
+	// to packages being put one at a time.
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+
+	// Failed lists the packages, as account/repository, that the last
+	// Update could not read. Their kinds may be missing or out of date.
+	Failed []string `json:"failed,omitempty"`
+
+	// Packages maps account/repository to the indexed version.
+	Packages map[string]string `json:"packages"`
+	Entries  []Entry           `json:"entries"`
+}
+
+// Index maps kinds to the packages that define them. It is safe for
+// concurrent use.
+type Index struct {
+	path string
+
+	mu   sync.RWMutex
+	data file
+}
+
+// Open loads the index stored at path. A missing index, or one written in an
+// older format, opens empty. An empty path opens an index that is never
+// persisted.
+func Open(path string) (*Index, error) {
+	idx := &Index{path: path, data: file{FormatVersion: FormatVersion, Packages: map[string]string{}}}
+	var data file
+	ok, err := cache.ReadJSON(path, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kind index: %w", err)
+	}
+	if ok && data.FormatVersion == FormatVersion && data.Packages != nil {
+		idx.data = data
+	}
+	return idx, nil
+}
+
+// Save writes the index to disk. Saves are serialised, since they share a
+// temporary file.
+func (i *Index) Save() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return cache.WriteJSON(i.path, i.data)
+}
+
+// Version returns the indexed version of a package, if it is indexed.
+func (i *Index) Version(account, repository string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	v, ok := i.data.Packages[account+"/"+repository]
+	return v, ok
+}
+
+// Len returns the number of indexed packages.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.data.Packages)
+}
+
+// UpdatedAt returns when the index was last updated.
+func (i *Index) UpdatedAt() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.data.UpdatedAt
+}
+
+// Complete returns true if an Update has indexed every package, rather than
+// only the packages put one at a time.
+func (i *Index) Complete() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return !i.data.CompletedAt.IsZero()
+}
+
+// Failed returns the packages, as account/repository, that the last Update
+// could not read and that have not been put since. A complete index with
+// failed packages is partial.
+func (i *Index) Failed() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return slices.Clone(i.data.Failed)
+}
+
+// complete records that every package has been read, except the supplied
+// failed ones.
+func (i *Index) complete(failed []string) {
+	slices.Sort(failed)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.data.CompletedAt = time.Now().UTC()
+	i.data.Failed = failed
+}
+
+// Put replaces the entries of a package with the kinds defined by the supplied
+// version of it. Kinds whose plural name is unknown are given the plural
+// Kubernetes would default to.
+func (i *Index) Put(account, repository, version string, res *marketplace.PackageResources) {
+	entries := make([]Entry, 0, len(res.CRDs)+len(res.XRDs))
+	for _, c := range res.CRDs {
+		entries = append(entries, Entry{Group: c.Group, Kind: c.Kind, Plural: c.Plural, ShortNames: c.ShortNames, Type: TypeCRD})
+	}
+	for _, x := range res.XRDs {
+		entries = append(entries, Entry{Group: x.Group, Kind: x.Kind, Plural: x.Plural, Type: TypeXRD})
+	}
+	for j := range entries {
+		entries[j].Account, entries[j].Repository, entries[j].Version = account, repository, version
+		if entries[j].Plural == "" {
+			entries[j].Plural = Plural(entries[j].Kind)
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	kept := i.data.Entries[:0]
+	for _, e := range i.data.Entries {
+		if e.Account != account || e.Repository != repository {
+			kept = append(kept, e)
+		}
+	}
+	i.data.Entries = append(kept, entries...)
+	i.data.Packages[account+"/"+repository] = version
+	i.data.Failed = slices.DeleteFunc(i.data.Failed, func(f string) bool { return f == account+"/"+repository })
+	i.data.UpdatedAt = time.Now().UTC()
+}
+
+// Plural guesses the plural resource name of a kind the way Kubernetes does
+// for CRDs that do not declare one.
+func Plural(kind string) string {
+	k := strings.ToLower(kind)
+	switch {
+	case strings.HasSuffix(k, "s"), strings.HasSuffix(k, "x"), strings.HasSuffix(k, "z"),
+		strings.HasSuffix(k, "ch"), strings.HasSuffix(k, "sh"):
+		return k + "es"
+	case strings.HasSuffix(k, "y") && len(k) > 1 && !strings.ContainsRune("aeiou", rune(k[len(k)-2])):
+		return k[:len(k)-1] + "ies"
+	default:
+		return k + "s"
+	}
+}
@@ -0,0 +1,197 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+func crds(group string, kinds ...string) *marketplace.PackageResources {
+	r := &marketplace.PackageResources{}
+	for _, k := range kinds {
+		r.CRDs = append(r.CRDs, marketplace.CRDMeta{Group: group, Kind: k})
+	}
+	return r
+}
+
+func TestFind(t *testing.T) {
+	idx, _ := Open("")
+	idx.Put("upbound", "provider-aws-s3", "v1.20.0", crds("s3.aws.upbound.io", "Bucket", "BucketPolicy"))
+	idx.Put("upbound", "provider-gcp-storage", "v1.8.0", crds("storage.gcp.upbound.io", "Bucket"))
+	idx.Put("upbound", "provider-aws-rds", "v1.20.0", &marketplace.PackageResources{
+		CRDs: []marketplace.CRDMeta{{Group: "rds.aws.upbound.io", Kind: "Instance", Plural: "instances", ShortNames: []string{"rdsi"}}},
+	})
+
+	cases := map[string]struct {
+		query string
+		want  []string
+	}{
+		"Exact":      {query: "Bucket", want: []string{"Bucket.s3.aws.upbound.io", "Bucket.storage.gcp.upbound.io", "BucketPolicy.s3.aws.upbound.io"}},
+		"Plural":     {query: "buckets", want: []string{"Bucket.s3.aws.upbound.io", "Bucket.storage.gcp.upbound.io"}},
+		"ShortName":  {query: "rdsi", want: []string{"Instance.rds.aws.upbound.io"}},
+		"Typo":       {query: "bukcetpolicy", want: []string{"BucketPolicy.s3.aws.upbound.io"}},
+		"Substring":  {query: "policy", want: []string{"BucketPolicy.s3.aws.upbound.io"}},
+		"WithGroup":  {query: "bucket.storage", want: []string{"Bucket.storage.gcp.upbound.io"}},
+		"NoMatch":    {query: "database", want: []string{}},
+		"EmptyQuery": {query: "", want: []string{}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := idx.Find(tc.query, 0)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Find(%q) = %+v, want %v", tc.query, got, tc.want)
+			}
+			for i, m := range got {
+				if m.Kind+"."+m.Group != tc.want[i] {
+					t.Errorf("match %d = %s.%s, want %s", i, m.Kind, m.Group, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// fakeCatalog serves one page of providers and counts resource reads.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	packages []marketplace.Package
+	reads    int
+
+	// fail names a repository whose resources cannot be read.
+	fail string
+}
+
+func (f *fakeCatalog) SearchPackages(_ context.Context, p marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	if p.PackageType != "provider" || p.Page > 0 {
+		return &marketplace.SearchResponse{}, nil
+	}
+	return &marketplace.SearchResponse{Packages: f.packages, Total: len(f.packages)}, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, repo, _ string) (*marketplace.PackageResources, error) {
+	f.reads++
+	if repo == f.fail {
+		return nil, errors.New("unavailable")
+	}
+	return crds(repo+".upbound.io", "Thing"), nil
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kinds.json")
+	c := &fakeCatalog{packages: []marketplace.Package{
+		{Account: "upbound", Repository: "a", Version: "v1.0.0"},
+		{Account: "upbound", Repository: "b", Version: "v1.0.0"},
+	}}
+
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	idx.Put("upbound", "a", "v0.9.0", &marketplace.PackageResources{})
+	if idx.Complete() {
+		t.Error("Complete() = true after putting one package")
+	}
+	if _, err := Update(context.Background(), c, idx, UpdateOptions{}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// A reopened index only reads packages whose version changed.
+	c.packages[1].Version = "v1.1.0"
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if reopened.Len() != 2 || !reopened.Complete() {
+		t.Fatalf("Len() = %d, Complete() = %t, want 2 and complete", reopened.Len(), reopened.Complete())
+	}
+	stats, err := Update(context.Background(), c, reopened, UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Updated != 1 || c.reads != 3 {
+		t.Errorf("Updated = %d after %d reads, want 1 after 3", stats.Updated, c.reads)
+	}
+	if m := reopened.Find("thing.b", 0); len(m) != 1 || m[0].Packages[0].Version != "v1.1.0" {
+		t.Errorf("Find() = %+v, want b at v1.1.0", m)
+	}
+}
+
+func TestUpdateFederated(t *testing.T) {
+	one := &fakeCatalog{packages: []marketplace.Package{{Account: "one", Repository: "a", Version: "v1.0.0"}}}
+	two := &fakeCatalog{packages: []marketplace.Package{
+		{Account: "two", Repository: "b", Version: "v1.0.0"},
+		{Account: "two", Repository: "c", Version: "v1.0.0"},
+	}}
+	c := catalog.NewFederated(
+		catalog.Backend{Name: "one", Catalog: one, Accounts: []string{"one"}},
+		catalog.Backend{Name: "two", Catalog: two},
+	)
+
+	idx, _ := Open("")
+	stats, err := Update(context.Background(), c, idx, UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Packages != 3 || idx.Len() != 3 {
+		t.Errorf("Update() listed %d and indexed %d packages, want 3 from both backends", stats.Packages, idx.Len())
+	}
+}
+
+func TestUpdateFailed(t *testing.T) {
+	c := &fakeCatalog{fail: "b", packages: []marketplace.Package{
+		{Account: "upbound", Repository: "a", Version: "v1.0.0"},
+		{Account: "upbound", Repository: "b", Version: "v1.0.0"},
+	}}
+
+	idx, _ := Open("")
+	stats, err := Update(context.Background(), c, idx, UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stats.Failed != 1 || !idx.Complete() {
+		t.Fatalf("Failed = %d, Complete() = %t, want 1 failed and complete", stats.Failed, idx.Complete())
+	}
+	if got := idx.Failed(); len(got) != 1 || got[0] != "upbound/b" {
+		t.Errorf("Failed() = %v, want [upbound/b]", got)
+	}
+
+	// A package put after the update is no longer failed.
+	idx.Put("upbound", "b", "v1.0.0", crds("b.upbound.io", "Thing"))
+	if got := idx.Failed(); len(got) != 0 {
+		t.Errorf("Failed() after Put = %v, want none", got)
+	}
+
+	// A later update that reads every package clears them.
+	idx, _ = Open("")
+	if _, err := Update(context.Background(), c, idx, UpdateOptions{}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	c.fail = ""
+	if _, err := Update(context.Background(), c, idx, UpdateOptions{}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := idx.Failed(); len(got) != 0 {
+		t.Errorf("Failed() after a clean update = %v, want none", got)
+	}
+}
@@ -0,0 +1,180 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+const (
+	// pageSize is the number of search results requested at once.
+	pageSize = 100
+
+	// maxConcurrentFetches bounds the number of packages read at once.
+	maxConcurrentFetches = 8
+
+	// DefaultMaxPackages bounds the packages of each type an update reads.
+	DefaultMaxPackages = 2000
+)
+
+// DefaultPackageTypes are the package types that define kinds.
+var DefaultPackageTypes = []string{"provider", "configuration"}
+
+// UpdateOptions configure an index update.
+type UpdateOptions struct {
+	// PackageTypes to index. Defaults to DefaultPackageTypes.
+	PackageTypes []string
+
+	// MaxPackages bounds the packages of each type read. Defaults to
+	// DefaultMaxPackages.
+	MaxPackages int
+}
+
+// Stats summarise an index update.
+type Stats struct {
+	Packages int `json:"packages"`
+	Updated  int `json:"updated"`
+	Failed   int `json:"failed"`
+}
+
+// Update indexes the current version of every package found by searching the
+// catalog, marks the index complete and saves it. Packages already indexed at
+// their current version are not read again. Packages that cannot be read are
+// recorded as failed, leaving the index partial.
+func Update(ctx context.Context, c catalog.Catalog, idx *Index, o UpdateOptions) (Stats, error) {
+	if len(o.PackageTypes) == 0 {
+		o.PackageTypes = DefaultPackageTypes
+	}
+	if o.MaxPackages <= 0 {
+		o.MaxPackages = DefaultMaxPackages
+	}
+
+	var pkgs []marketplace.Package
+	for _, t := range o.PackageTypes {
+		found, err := list(ctx, c, t, o.MaxPackages)
+		if err != nil {
+			return Stats{}, err
+		}
+		pkgs = append(pkgs, found...)
+	}
+
+	var (
+		mu     sync.Mutex
+		stats  = Stats{Packages: len(pkgs)}
+		failed []string
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, maxConcurrentFetches)
+	)
+	for _, p := range pkgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			updated, err := updatePackage(ctx, c, idx, p)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				stats.Failed++
+				failed = append(failed, p.Account+"/"+p.Repository)
+			case updated:
+				stats.Updated++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	idx.complete(failed)
+	return stats, idx.Save()
+}
+
+// list pages through the search results for a package type. The backends of
+// a federated catalog are listed one at a time, since paging through merged
+// results rereads every earlier page. Like a federated search, backends that
+// fail are skipped unless all of them do.
+func list(ctx context.Context, c catalog.Catalog, packageType string, maxPackages int) ([]marketplace.Package, error) {
+	if f, ok := c.(*catalog.Federated); ok {
+		var (
+			out  []marketplace.Package
+			seen = map[string]bool{}
+			errs []error
+		)
+		for _, b := range f.Backends() {
+			found, err := list(ctx, b.Catalog, packageType, maxPackages)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+				continue
+			}
+			for _, p := range found {
+				if key := p.Account + "/" + p.Repository; !seen[key] {
+					seen[key] = true
+					out = append(out, p)
+				}
+			}
+		}
+		if len(errs) > 0 && len(errs) == len(f.Backends()) {
+			return nil, errors.Join(errs...)
+		}
+		return out, nil
+	}
+
+	var out []marketplace.Package
+	for page := 0; len(out) < maxPackages; page++ {
+		resp, err := c.SearchPackages(ctx, marketplace.SearchParams{PackageType: packageType, Size: pageSize, Page: page})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s packages: %w", packageType, err)
+		}
+		out = append(out, resp.Packages...)
+		if len(resp.Packages) < pageSize || (resp.Total > 0 && len(out) >= resp.Total) {
+			break
+		}
+	}
+	return out[:min(len(out), maxPackages)], nil
+}
+
+// updatePackage indexes the current version of a package unless it is already
+// indexed.
+func updatePackage(ctx context.Context, c catalog.Catalog, idx *Index, p marketplace.Package) (bool, error) {
+	version := p.Version
+	if version == "" {
+		md, err := c.GetPackageMetadata(ctx, p.Account, p.Repository, "", false)
+		if err != nil {
+			return false, err
+		}
+		version = md.LatestVersion
+	}
+	if v, ok := idx.Version(p.Account, p.Repository); ok && v == version {
+		return false, nil
+	}
+	res, err := c.GetV1PackagesAccountRepositoryVersionResources(ctx, p.Account, p.Repository, version)
+	if err != nil {
+		return false, err
+	}
+	idx.Put(p.Account, p.Repository, version, res)
+	return true, nil
+}
@@ -0,0 +1,229 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/xpkg"
+)
+
+const (
+	// Account is the account local packages are served under.
+	Account = "local"
+
+	// Version is the version local packages are served as.
+	Version = "local"
+
+	xpkgExt = ".xpkg"
+)
+
+// Entry is a package found on disk.
+type Entry struct {
+	Path    string
+	Package *xpkg.Package
+}
+
+// Store reads packages from a set of configured paths. Each path may be a
+// .xpkg file, a package directory, or a directory containing either.
+type Store struct {
+	paths []string
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+type cached struct {
+	modTime time.Time
+	size    int64
+	pkg     *xpkg.Package
+}
+
+// NewStore creates a store serving packages from the supplied paths.
+func NewStore(paths ...string) *Store {
+	return &Store{
+		paths: paths,
+		cache: map[string]cached{},
+	}
+}
+
+// Paths returns the configured package paths.
+func (s *Store) Paths() []string {
+	return s.paths
+}
+
+// Load reads the package at the supplied path. Packages read from .xpkg files
+// are cached until the file changes.
+func (s *Store) Load(path string) (*xpkg.Package, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local package: %w", err)
+	}
+	if fi.IsDir() {
+		return xpkg.Read(path)
+	}
+
+	s.mu.Lock()
+	c, ok := s.cache[path]
+	s.mu.Unlock()
+	if ok && c.modTime.Equal(fi.ModTime()) && c.size == fi.Size() {
+		return c.pkg, nil
+	}
+
+	pkg, err := xpkg.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[path] = cached{modTime: fi.ModTime(), size: fi.Size(), pkg: pkg}
+	s.mu.Unlock()
+	return pkg, nil
+}
+
+// List reads every package found under the configured paths. Packages that
+// cannot be read are skipped and reported in the returned error.
+func (s *Store) List() ([]Entry, error) {
+	var out []Entry
+	var errs []error
+	for _, p := range s.expand() {
+		pkg, err := s.Load(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			continue
+		}
+		out = append(out, Entry{Path: p, Package: pkg})
+	}
+	return out, errors.Join(errs...)
+}
+
+// Get returns the configured package with the supplied name.
+func (s *Store) Get(name string) (*Entry, error) {
+	entries, err := s.List()
+	for i := range entries {
+		if entries[i].Package.Name() == name {
+			return &entries[i], nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("local package %q not found: %w", name, err)
+	}
+	return nil, fmt.Errorf("local package %q not found", name)
+}
+
+// expand resolves the configured paths to the individual packages they hold.
+func (s *Store) expand() []string {
+	var out []string
+	for _, p := range s.paths {
+		fi, err := os.Stat(p)
+		if err != nil || !fi.IsDir() || xpkg.IsPackageDir(p) {
+			out = append(out, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			out = append(out, p)
+			continue
+		}
+		for _, e := range entries {
+			child := filepath.Join(p, e.Name())
+			switch {
+			case !e.IsDir() && strings.HasSuffix(e.Name(), xpkgExt):
+				out = append(out, child)
+			case e.IsDir() && xpkg.IsPackageDir(child):
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// Allowed returns the supplied path with symbolic links resolved, and true if
+// it is one of, or is beneath one of, the configured paths. Only allowed paths
+// may be read on request, and they must be read through the returned path so
+// that a link beneath a configured path cannot reach outside it. Links inside
+// package directories are not followed when they are read.
+func (s *Store) Allowed(path string) (string, bool) {
+	abs, err := resolve(path)
+	if err != nil {
+		return "", false
+	}
+	for _, p := range s.paths {
+		root, err := resolve(p)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return abs, true
+		}
+	}
+	return "", false
+}
+
+// resolve returns the absolute path of an existing file or directory, with
+// every symbolic link in it resolved.
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// LoadPackage loads the configured package with the supplied name. Local
+// packages only have a single version, so the version is ignored.
+func (s *Store) LoadPackage(_ context.Context, account, repository, _ string) (*xpkg.Package, error) {
+	if account != Account {
+		return nil, fmt.Errorf("local packages are served under the %q account, not %q", Account, account)
+	}
+	e, err := s.Get(repository)
+	if err != nil {
+		return nil, err
+	}
+	return e.Package, nil
+}
+
+// ListPackages lists the configured packages. Packages that cannot be read
+// are left out unless none can be read.
+func (s *Store) ListPackages(_ context.Context, account string) ([]marketplace.Package, error) {
+	if account != "" && account != Account {
+		return nil, nil
+	}
+	entries, err := s.List()
+	if len(entries) == 0 && err != nil {
+		return nil, err
+	}
+	out := make([]marketplace.Package, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, marketplace.Package{
+			Account:     Account,
+			Repository:  e.Package.Name(),
+			Name:        e.Package.Name(),
+			Version:     Version,
+			Description: e.Package.Description(),
+			Type:        string(e.Package.Type()),
+		})
+	}
+	return out, nil
+}
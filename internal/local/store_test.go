@@ -0,0 +1,105 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, f := range []string{filepath.Join(root, "inside.xpkg"), filepath.Join(outside, "secret")} {
+		if err := os.WriteFile(f, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "root")
+	if err := os.Symlink(root, link); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		paths []string
+		path  string
+		want  bool
+	}{
+		"Inside":             {paths: []string{root}, path: filepath.Join(root, "inside.xpkg"), want: true},
+		"Root":               {paths: []string{root}, path: root, want: true},
+		"Outside":            {paths: []string{root}, path: filepath.Join(outside, "secret")},
+		"Traversal":          {paths: []string{root}, path: filepath.Join(root, "..", filepath.Base(outside), "secret")},
+		"SymlinkedFile":      {paths: []string{root}, path: filepath.Join(root, "escape")},
+		"SymlinkedDirectory": {paths: []string{root}, path: filepath.Join(root, "dir", "secret")},
+		"SymlinkedRoot":      {paths: []string{link}, path: filepath.Join(root, "inside.xpkg"), want: true},
+		"Missing":            {paths: []string{root}, path: filepath.Join(root, "missing.xpkg")},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, got := NewStore(tc.paths...).Allowed(tc.path); got != tc.want {
+				t.Errorf("Allowed(%q) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadSkipsSymlinkedFiles(t *testing.T) {
+	root := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "secret.yaml")
+	pkg := filepath.Join(root, "pkg")
+	if err := os.MkdirAll(filepath.Join(pkg, "examples"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		secret:                                   "apiVersion: v1\nkind: Secret\n",
+		filepath.Join(pkg, "crossplane.yaml"):    "apiVersion: meta.pkg.crossplane.io/v1\nkind: Configuration\nmetadata:\n  name: test\n",
+		filepath.Join(pkg, "examples", "a.yaml"): "apiVersion: v1\nkind: ConfigMap\n",
+	}
+	for f, content := range files {
+		if err := os.WriteFile(f, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(pkg, "examples", "x.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(root)
+	resolved, ok := s.Allowed(pkg)
+	if !ok {
+		t.Fatalf("Allowed(%q) = false, want true", pkg)
+	}
+	p, err := s.Load(resolved)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, e := range p.Examples {
+		if e.Path == "examples/x.yaml" {
+			t.Errorf("Load() read symlinked example %s: %q", e.Path, e.Content)
+		}
+	}
+	if len(p.Examples) != 1 {
+		t.Errorf("Load() returned %d examples, want 1", len(p.Examples))
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
@@ -90,34 +91,26 @@ func (c *Client) SearchPackages(ctx context.Context, params SearchParams) (*Sear
 	}
 
 	q := u.Query()
-	if params.Query != "" {
-		if params.UseV1 {
-			q.Set("query", params.Query)
-		} else {
-			// V2 uses filter parameter with AIP-160 format
-			q.Set("filter", fmt.Sprintf("query = '%s'", params.Query))
+	// V1 takes each field as a parameter, while V2 takes a single filter in
+	// AIP-160 format whose conditions must all hold.
+	var filters []string
+	for _, f := range []struct{ name, value string }{
+		{"query", params.Query},
+		{"family", params.Family},
+		{"packageType", params.PackageType},
+		{"accountName", params.AccountName},
+		{"tier", params.Tier},
+	} {
+		switch {
+		case f.value == "":
+		case params.UseV1:
+			q.Set(f.name, f.value)
+		default:
+			filters = append(filters, fmt.Sprintf("%s = '%s'", f.name, strings.ReplaceAll(f.value, "'", "\\'")))
 		}
 	}
-	if params.Family != "" {
-		if params.UseV1 {
-			q.Set("family", params.Family)
-		} else {
-			q.Set("filter", fmt.Sprintf("family = '%s'", params.Family))
-		}
-	}
-	if params.PackageType != "" {
-		if params.UseV1 {
-			q.Set("packageType", params.PackageType)
-		} else {
-			q.Set("filter", fmt.Sprintf("packageType = '%s'", params.PackageType))
-		}
-	}
-	if params.AccountName != "" {
-		if params.UseV1 {
-			q.Set("accountName", params.AccountName)
-		} else {
-			q.Set("filter", fmt.Sprintf("accountName = '%s'", params.AccountName))
-		}
+	if len(filters) > 0 {
+		q.Set("filter", strings.Join(filters, " AND "))
 	}
 	if params.Size > 0 {
 		q.Set("size", fmt.Sprintf("%d", params.Size))
@@ -128,13 +121,6 @@ func (c *Client) SearchPackages(ctx context.Context, params SearchParams) (*Sear
 	if params.Public != nil {
 		q.Set("public", fmt.Sprintf("%t", *params.Public))
 	}
-	if params.Tier != "" {
-		if params.UseV1 {
-			q.Set("tier", params.Tier)
-		} else {
-			q.Set("filter", fmt.Sprintf("tier = '%s'", params.Tier))
-		}
-	}
 	if params.Starred != nil && *params.Starred {
 		q.Set("starred", "true")
 	}
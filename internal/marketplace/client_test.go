@@ -17,6 +17,11 @@
 package marketplace
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -46,3 +51,45 @@ func TestSetToken(t *testing.T) {
 		t.Errorf("Expected token to be %s, got %s", token, client.Token)
 	}
 }
+
+func TestSearchPackagesQuery(t *testing.T) {
+	cases := map[string]struct {
+		params SearchParams
+		want   url.Values
+	}{
+		"V2CombinesFilters": {
+			params: SearchParams{Query: "provider-aws-s3", Family: "upbound/provider-family-aws", PackageType: "provider", Size: 20},
+			want: url.Values{
+				"filter": {"query = 'provider-aws-s3' AND family = 'upbound/provider-family-aws' AND packageType = 'provider'"},
+				"size":   {"20"},
+			},
+		},
+		"V2EscapesQuotes": {
+			params: SearchParams{Query: "it's"},
+			want:   url.Values{"filter": {`query = 'it\'s'`}},
+		},
+		"V1Parameters": {
+			params: SearchParams{Query: "s3", PackageType: "provider", Tier: "official", UseV1: true},
+			want:   url.Values{"query": {"s3"}, "packageType": {"provider"}, "tier": {"official"}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query()
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			c := NewClient()
+			c.SetBaseURL(srv.URL)
+			if _, err := c.SearchPackages(context.Background(), tc.params); err != nil {
+				t.Fatalf("SearchPackages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SearchPackages() query = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
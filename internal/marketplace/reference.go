@@ -0,0 +1,149 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package marketplace
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	nameRe   = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+	tagRe    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestRe = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// marketplacePaths are the package type path segments of marketplace package
+// URLs, such as https://marketplace.upbound.io/providers/upbound/provider-aws-s3/v1.20.0.
+var marketplacePaths = map[string]bool{
+	"providers":      true,
+	"configurations": true,
+	"functions":      true,
+}
+
+// Reference identifies a package, and optionally a version or digest of it.
+type Reference struct {
+	Registry   string
+	Account    string
+	Repository string
+	Version    string
+	Digest     string
+}
+
+// ParseReference parses a package reference. It accepts OCI references such as
+// xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0 and
+// upbound/provider-aws-s3@sha256:<hex>, and marketplace package URLs. The
+// registry, version and digest are optional, but the account is not.
+func ParseReference(s string) (Reference, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Reference{}, fmt.Errorf("package reference is empty")
+	}
+	if strings.Contains(s, "://") {
+		return parseURL(s)
+	}
+
+	var ref Reference
+	name := s
+	if n, d, ok := strings.Cut(name, "@"); ok {
+		if !digestRe.MatchString(d) {
+			return Reference{}, fmt.Errorf("package reference %q has an invalid digest %q: expected sha256:<64 hex characters>", s, d)
+		}
+		name, ref.Digest = n, d
+	}
+	// A tag follows the last colon, unless that colon is part of a registry
+	// host:port in the first path segment.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Version = name[:i], name[i+1:]
+		if !tagRe.MatchString(ref.Version) {
+			return Reference{}, fmt.Errorf("package reference %q has an invalid version %q", s, ref.Version)
+		}
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) > 1 && isRegistry(parts[0]) {
+		ref.Registry, parts = parts[0], parts[1:]
+	}
+	switch len(parts) {
+	case 1:
+		return Reference{}, fmt.Errorf("package reference %q is ambiguous: it has no account, use <account>/<repository>, for example upbound/%s", s, parts[0])
+	case 2:
+		ref.Account, ref.Repository = parts[0], parts[1]
+	default:
+		return Reference{}, fmt.Errorf("package reference %q has too many path segments: expected [<registry>/]<account>/<repository>", s)
+	}
+	return ref, ref.validate(s)
+}
+
+// parseURL parses a marketplace package URL, or an OCI reference written with
+// a scheme.
+func parseURL(s string) (Reference, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Reference{}, fmt.Errorf("package reference %q is not a valid URL: %w", s, err)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if !marketplacePaths[parts[0]] {
+		// Treat anything else as a registry reference.
+		return ParseReference(u.Host + u.Path)
+	}
+	if len(parts) < 3 {
+		return Reference{}, fmt.Errorf("marketplace URL %q does not name a package: expected /%s/<account>/<repository>[/<version>]", s, parts[0])
+	}
+	ref := Reference{Account: parts[1], Repository: parts[2]}
+	if len(parts) > 3 {
+		ref.Version = parts[3]
+		if !tagRe.MatchString(ref.Version) {
+			return Reference{}, fmt.Errorf("marketplace URL %q has an invalid version %q", s, ref.Version)
+		}
+	}
+	return ref, ref.validate(s)
+}
+
+func (r Reference) validate(s string) error {
+	if !nameRe.MatchString(r.Account) {
+		return fmt.Errorf("package reference %q has an invalid account %q", s, r.Account)
+	}
+	if !nameRe.MatchString(r.Repository) {
+		return fmt.Errorf("package reference %q has an invalid repository %q", s, r.Repository)
+	}
+	return nil
+}
+
+// isRegistry returns true if the first segment of a reference is a registry
+// host rather than an account, following the Docker convention.
+func isRegistry(segment string) bool {
+	return strings.ContainsAny(segment, ".:") || segment == "localhost"
+}
+
+// String returns the reference in OCI form.
+func (r Reference) String() string {
+	var b strings.Builder
+	if r.Registry != "" {
+		b.WriteString(r.Registry + "/")
+	}
+	b.WriteString(r.Account + "/" + r.Repository)
+	if r.Version != "" {
+		b.WriteString(":" + r.Version)
+	}
+	if r.Digest != "" {
+		b.WriteString("@" + r.Digest)
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package marketplace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	cases := map[string]struct {
+		ref     string
+		want    Reference
+		wantErr string
+	}{
+		"Full": {
+			ref:  "xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0",
+			want: Reference{Registry: "xpkg.upbound.io", Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"NoRegistry": {
+			ref:  "upbound/provider-aws-s3:v1.20.0",
+			want: Reference{Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"NoVersion": {
+			ref:  "upbound/provider-aws-s3",
+			want: Reference{Account: "upbound", Repository: "provider-aws-s3"},
+		},
+		"Digest": {
+			ref:  "upbound/provider-aws-s3@" + digest,
+			want: Reference{Account: "upbound", Repository: "provider-aws-s3", Digest: digest},
+		},
+		"VersionAndDigest": {
+			ref:  "xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0@" + digest,
+			want: Reference{Registry: "xpkg.upbound.io", Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0", Digest: digest},
+		},
+		"RegistryPort": {
+			ref:  "localhost:5000/acme/configuration-platform:v0.1.0",
+			want: Reference{Registry: "localhost:5000", Account: "acme", Repository: "configuration-platform", Version: "v0.1.0"},
+		},
+		"MarketplaceURL": {
+			ref:  "https://marketplace.upbound.io/providers/upbound/provider-aws-s3/v1.20.0",
+			want: Reference{Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"RegistryURL": {
+			ref:  "https://xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0",
+			want: Reference{Registry: "xpkg.upbound.io", Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"NoAccount": {
+			ref:     "provider-aws-s3:v1.20.0",
+			wantErr: "ambiguous",
+		},
+		"RegistryNoAccount": {
+			ref:     "xpkg.upbound.io/provider-aws-s3",
+			wantErr: "ambiguous",
+		},
+		"TooManySegments": {
+			ref:     "xpkg.upbound.io/upbound/aws/provider-aws-s3",
+			wantErr: "too many path segments",
+		},
+		"BadDigest": {
+			ref:     "upbound/provider-aws-s3@sha256:abc",
+			wantErr: "invalid digest",
+		},
+		"EmptyVersion": {
+			ref:     "upbound/provider-aws-s3:",
+			wantErr: "invalid version",
+		},
+		"UpperCaseRepository": {
+			ref:     "upbound/Provider-AWS",
+			wantErr: "invalid repository",
+		},
+		"Empty": {
+			ref:     " ",
+			wantErr: "empty",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseReference(tc.ref)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ParseReference(%q) error = %v, want error containing %q", tc.ref, err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReference(%q) error = %v", tc.ref, err)
+			}
+			if got != tc.want {
+				t.Errorf("ParseReference(%q) = %+v, want %+v", tc.ref, got, tc.want)
+			}
+		})
+	}
+}
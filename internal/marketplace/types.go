@@ -66,6 +66,10 @@ type Package struct {
 	Tags        []string       `json:"tags,omitempty"`
 	Keywords    []string       `json:"keywords,omitempty"`
 	Metadata    map[string]any `json:"metadata,omitempty"`
+
+	// Source is the name of the catalog the package was found in, when
+	// searching several catalogs at once.
+	Source string `json:"source,omitempty"`
 }
 
 // PackageMetadata represents detailed package metadata.
@@ -202,6 +206,10 @@ type CRDMeta struct {
 	Versions       []string `json:"versions"`
 	StorageVersion string   `json:"storageVersion"`
 	Scope          string   `json:"scope"`
+
+	// Plural and ShortNames are only known for packages read directly.
+	Plural     string   `json:"plural,omitempty"`
+	ShortNames []string `json:"shortNames,omitempty"`
 }
 
 // XRDMeta contains CompositeResourceDefinition metadata.
@@ -210,6 +218,9 @@ type XRDMeta struct {
 	Kind                 string   `json:"kind"`
 	Versions             []string `json:"versions"`
 	ReferenceableVersion string   `json:"referenceableVersion"`
+
+	// Plural is only known for packages read directly.
+	Plural string `json:"plural,omitempty"`
 }
 
 // CompositionMeta contains Composition metadata.
@@ -0,0 +1,222 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/upbound/marketplace-mcp-server/internal/args"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// defineTool returns a tool whose input schema is generated from the
+// argument struct A, and whose output schema from the result type R.
+func defineTool[A, R any](name, description string) mcp.Tool {
+	return mcp.Tool{
+		Name:            name,
+		Description:     description,
+		InputSchema:     args.Schema[A](),
+		RawOutputSchema: outputSchema[R](),
+	}
+}
+
+// bind returns a tool handler that decodes and validates the arguments of a
+// call into A before calling h. Invalid arguments are reported as an error
+// result without calling h.
+func bind[A any](h func(context.Context, mcp.CallToolRequest, A) (*mcp.CallToolResult, error)) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var a A
+		if err := args.Decode(req.GetArguments(), &a); err != nil {
+			return mcp.NewToolResultError(err.Error()), err
+		}
+		return h(ctx, req, a)
+	}
+}
+
+// referenceArg is the package argument, which every package tool accepts as
+// an alternative to separate account, repository and version arguments.
+type referenceArg struct {
+	Package string `arg:"package" desc:"Package reference, as an alternative to the account, repository and version arguments. For example xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0, upbound/provider-aws-s3@sha256:<digest> or a marketplace package URL."`
+}
+
+// localPathArg is the package_path argument of the tools that read local
+// packages.
+type localPathArg struct {
+	PackagePath string `arg:"package_path" desc:"Path to a local .xpkg file or package directory. Must be within a configured local package path. When set, account, repository and version are not required."`
+}
+
+// packageArgs name a package.
+type packageArgs struct {
+	referenceArg
+	Account    string `arg:"account" desc:"Account/organization name. For example upbound."`
+	Repository string `arg:"repository" desc:"Repository name. For example provider-aws-s3."`
+}
+
+// ref returns the package the arguments name, at the supplied version.
+func (a packageArgs) ref(version string) (marketplace.Reference, error) {
+	return packageRef(a.Package, a.Account, a.Repository, version)
+}
+
+// packageVersionArgs name a package version.
+type packageVersionArgs struct {
+	packageArgs
+	Version string `arg:"version" desc:"The version of the package. For example v1.23.1."`
+}
+
+// ref returns the package version the arguments name.
+func (a packageVersionArgs) ref() (marketplace.Reference, error) {
+	return a.packageArgs.ref(a.Version)
+}
+
+// resourceArgs name a package version whose resources are read, either from
+// a catalog or from a local path.
+type resourceArgs struct {
+	referenceArg
+	localPathArg
+	Account        string `arg:"account" desc:"Account/organization name. For example upbound."`
+	RepositoryName string `arg:"repository_name" desc:"The name of the repository. For example provider-aws-s3."`
+	Version        string `arg:"version" desc:"The version of the package. For example v1.23.1."`
+}
+
+// ref returns the package version the arguments name.
+func (a resourceArgs) ref() (marketplace.Reference, error) {
+	return packageRef(a.Package, a.Account, a.RepositoryName, a.Version)
+}
+
+// groupKindArgs name a kind of resource.
+type groupKindArgs struct {
+	ResourceGroup string `arg:"resource_group,required" desc:"The group of the resource. For example s3.aws.upbound.io."`
+	ResourceKind  string `arg:"resource_kind,required" desc:"The kind of the resource. For example Bucket."`
+}
+
+type searchPackagesArgs struct {
+	referenceArg
+	Query       string `arg:"query" desc:"Search query for packages"`
+	Family      string `arg:"family" desc:"Family repository key to filter by"`
+	PackageType string `arg:"package_type" desc:"Type of package (provider, configuration, function)"`
+	AccountName string `arg:"account_name" desc:"Account/organization name to filter by"`
+	Tier        string `arg:"tier" desc:"Package tier (official, community, etc.)"`
+	Public      *bool  `arg:"public" desc:"Filter by public/private packages"`
+	Size        int    `arg:"size" desc:"Number of results to return" default:"20" min:"1" max:"500"`
+	Page        int    `arg:"page" desc:"Page number (0-indexed)" default:"0" min:"0"`
+	UseV1       bool   `arg:"use_v1" desc:"Use v1 API instead of v2" default:"false"`
+}
+
+type getPackageMetadataArgs struct {
+	packageArgs
+	localPathArg
+	Version string `arg:"version" desc:"Package version (optional, gets latest if not specified)"`
+	UseV1   bool   `arg:"use_v1" desc:"Use v1 API instead of v2" default:"false"`
+}
+
+type getPackageAssetsArgs struct {
+	packageVersionArgs
+	localPathArg
+	AssetType string `arg:"asset_type,required" desc:"Type of asset to retrieve" enum:"docs,icon,readme,releaseNotes,sbom"`
+}
+
+type getRepositoriesArgs struct {
+	referenceArg
+	Account string `arg:"account" desc:"Account/organization name"`
+	Filter  string `arg:"filter" desc:"AIP-160 formatted filter (v2 only)"`
+	Size    int    `arg:"size" desc:"Number of results to return" default:"20" min:"1" max:"100"`
+	Page    int    `arg:"page" desc:"Page number (0-indexed)" default:"0" min:"0"`
+	UseV1   bool   `arg:"use_v1" desc:"Use v1 API instead of v2" default:"false"`
+}
+
+type getPrivateRepositoriesArgs struct {
+	referenceArg
+	Account string `arg:"account" desc:"Account/organization name"`
+	Size    int    `arg:"size" desc:"Number of results to return" default:"20" min:"1" max:"100"`
+	Page    int    `arg:"page" desc:"Page number (0-indexed)" default:"0" min:"0"`
+}
+
+type getCompositionArgs struct {
+	resourceArgs
+	groupKindArgs
+	CompositionName string `arg:"composition_name,required" desc:"The name of the composition."`
+}
+
+type getGroupKindArgs struct {
+	resourceArgs
+	groupKindArgs
+}
+
+type getKindContextArgs struct {
+	packageVersionArgs
+	groupKindArgs
+}
+
+type diffPackageVersionsArgs struct {
+	packageArgs
+	FromVersion  string `arg:"from_version,required" desc:"The version to compare from. For example v1.14.0."`
+	ToVersion    string `arg:"to_version,required" desc:"The version to compare to. For example v1.20.0."`
+	BreakingOnly bool   `arg:"breaking_only" desc:"Only report breaking changes" default:"false"`
+}
+
+type planUpgradeArgs struct {
+	packageArgs
+	CurrentVersion string `arg:"current_version" desc:"The installed version. For example v1.14.0. Defaults to the version in the package reference."`
+	TargetVersion  string `arg:"target_version" desc:"The version to upgrade to. Defaults to the latest version."`
+}
+
+type recommendProvidersArgs struct {
+	Resources []resourceKindArg `arg:"resources" desc:"Managed resource kinds. For example [{\"apiVersion\": \"s3.aws.upbound.io/v1beta1\", \"kind\": \"Bucket\"}]."`
+	Manifests string            `arg:"manifests" desc:"YAML manifests of managed resources, separated by ---"`
+}
+
+// resourceKindArg is the apiVersion and kind of a managed resource.
+type resourceKindArg struct {
+	APIVersion string `arg:"apiVersion,required"`
+	Kind       string `arg:"kind,required"`
+}
+
+type findKindArgs struct {
+	Query   string `arg:"query,required" desc:"Kind, plural or short name to find, optionally followed by a group prefix. For example Bucket, buckets or bucket.s3."`
+	Limit   int    `arg:"limit" desc:"Maximum number of kinds to return" default:"20" min:"1"`
+	Refresh bool   `arg:"refresh" desc:"Start updating the index with the current version of every package in the background, searching the current index meanwhile" default:"false"`
+}
+
+type searchFieldsArgs struct {
+	referenceArg
+	Query      string `arg:"query,required" desc:"Free text describing the field. For example kms key or server side encryption."`
+	Account    string `arg:"account" desc:"Account/organization name of a package to search. For example upbound."`
+	Repository string `arg:"repository" desc:"Repository name of a package to search. For example provider-aws-s3."`
+	Version    string `arg:"version" desc:"Version of the package to index. Defaults to the latest version."`
+	Kind       string `arg:"kind" desc:"Only return fields of this kind. For example Bucket."`
+	Limit      int    `arg:"limit" desc:"Maximum number of fields to return" default:"10" min:"1"`
+}
+
+type diffSnapshotsArgs struct {
+	From string `arg:"from" desc:"File name of the older snapshot in the snapshot directory. Defaults to the second newest snapshot."`
+	To   string `arg:"to" desc:"File name of the newer snapshot in the snapshot directory. Defaults to the newest snapshot."`
+	Top  int    `arg:"top" desc:"Number of download and star changes to list in markdown and text output, or 0 for all" default:"20" min:"0"`
+}
+
+type verifyPackageArgs struct {
+	packageVersionArgs
+	RequireSignature  bool     `arg:"require_signature" desc:"Report a violation unless a trusted key verified a signature" default:"true"`
+	RequireProvenance bool     `arg:"require_provenance" desc:"Report a violation unless a trusted key verified a SLSA provenance attestation" default:"false"`
+	TrustedBuilders   []string `arg:"trusted_builders" desc:"Builder ID prefixes that verified provenance must come from"`
+}
+
+type reloadAuthArgs struct {
+	RandomString string `arg:"random_string" desc:"Dummy parameter for no-parameter tools"`
+}
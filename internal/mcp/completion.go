@@ -0,0 +1,376 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/mod/semver"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// maxCompletions bounds the values returned for an argument completion.
+const maxCompletions = 100
+
+// completionTTL is how long the catalog data arguments complete to is cached.
+const completionTTL = 5 * time.Minute
+
+// maxCompletionEntries bounds the entries of the completion cache.
+const maxCompletionEntries = 1000
+
+// maxCompletionRepositories bounds the repositories of an account read to
+// complete repository arguments, which are read a page of
+// repositoryPageSize at a time.
+const (
+	maxCompletionRepositories = 2000
+	repositoryPageSize        = 100
+)
+
+// knownAccounts are the accounts account arguments always complete to, in
+// addition to the accounts the server is configured with or has read.
+var knownAccounts = []string{"upbound", "crossplane-contrib"}
+
+// completer completes the arguments of prompts and resource templates from
+// the catalog. Arguments are completed by name, so the arguments tools share
+// with prompts and resource templates, such as account, repository, version,
+// resource_group and resource_kind, complete the same way everywhere.
+type completer struct {
+	s     *Server
+	cache completionCache
+}
+
+// newCompleter returns a completer that reads the catalog of a server.
+func newCompleter(s *Server) *completer {
+	return &completer{s: s, cache: completionCache{ttl: completionTTL, now: time.Now}}
+}
+
+// CompletePromptArgument completes a prompt argument. Kind arguments of the
+// build_composition_for_xrd prompt complete to XRDs, and those of other
+// prompts to CRDs.
+func (c *completer) CompletePromptArgument(ctx context.Context, prompt string, arg mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	kinds := crdKinds
+	if prompt == "build_composition_for_xrd" {
+		kinds = xrdKinds
+	}
+	return completion(c.values(ctx, arg, cc.Arguments, kinds), arg.Value), nil
+}
+
+// CompleteResourceArgument completes a variable of a resource template.
+func (c *completer) CompleteResourceArgument(ctx context.Context, _ string, arg mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	return completion(c.values(ctx, arg, cc.Arguments, crdKinds|xrdKinds), arg.Value), nil
+}
+
+// kindSet selects the kinds kind arguments complete to.
+type kindSet int
+
+const (
+	crdKinds kindSet = 1 << iota
+	xrdKinds
+)
+
+// values returns the values an argument may take, given the arguments
+// already chosen.
+func (c *completer) values(ctx context.Context, arg mcp.CompleteArgument, chosen map[string]string, kinds kindSet) []string {
+	switch arg.Name {
+	case "cloud":
+		return clouds
+	case "account", "account_name":
+		return c.accounts()
+	case "package", "provider":
+		return c.packages(ctx, arg.Value)
+	case "repository", "repository_name":
+		return c.repositories(ctx, first(chosen, "account", "account_name"))
+	case "version", "current_version", "target_version", "from_version", "to_version":
+		ref, ok := chosenPackage(chosen)
+		if !ok {
+			return nil
+		}
+		return c.versions(ctx, ref)
+	case "group", "resource_group":
+		return c.groups(ctx, chosen, kinds)
+	case "kind", "resource_kind":
+		return c.kinds(ctx, chosen, kinds)
+	}
+	return nil
+}
+
+// first returns the first of the named arguments that has been chosen.
+func first(chosen map[string]string, names ...string) string {
+	for _, n := range names {
+		if v := chosen[n]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// chosenPackage returns the package named by the arguments already chosen,
+// either as a package reference or as separate account, repository and
+// version arguments.
+func chosenPackage(chosen map[string]string) (marketplace.Reference, bool) {
+	if pkg := chosen["package"]; pkg != "" {
+		ref, err := marketplace.ParseReference(pkg)
+		return ref, err == nil
+	}
+	ref := marketplace.Reference{
+		Account:    first(chosen, "account", "account_name"),
+		Repository: first(chosen, "repository", "repository_name"),
+		Version:    chosen["version"],
+	}
+	return ref, ref.Account != "" && ref.Repository != ""
+}
+
+// accounts returns the known accounts, the accounts served by configured
+// catalogs or synced in the background, and the accounts of recently read
+// packages.
+func (c *completer) accounts() []string {
+	values := slices.Clone(knownAccounts)
+	for _, b := range c.s.catalog.Backends() {
+		values = append(values, b.Accounts...)
+	}
+	values = append(values, c.s.syncAccounts...)
+
+	c.s.recent.mu.Lock()
+	defer c.s.recent.mu.Unlock()
+	for _, uri := range c.s.recent.uris {
+		account, _, _ := strings.Cut(strings.TrimPrefix(uri, packageScheme), "/")
+		values = append(values, account)
+	}
+	return values
+}
+
+// packages returns account/repository pairs: the repositories of the account
+// typed so far, or the accounts, followed by a slash, until one is typed.
+func (c *completer) packages(ctx context.Context, value string) []string {
+	account, _, ok := strings.Cut(value, "/")
+	if !ok {
+		accounts := c.accounts()
+		values := make([]string, 0, len(accounts))
+		for _, a := range accounts {
+			values = append(values, a+"/")
+		}
+		return values
+	}
+	repos := c.repositories(ctx, account)
+	values := make([]string, 0, len(repos))
+	for _, r := range repos {
+		values = append(values, account+"/"+r)
+	}
+	return values
+}
+
+// repositories returns the repositories of an account. Every page of them is
+// read, so that values beyond the first page complete too.
+func (c *completer) repositories(ctx context.Context, account string) []string {
+	if account == "" {
+		return nil
+	}
+	return c.cache.get("repositories/"+account, func() ([]string, error) {
+		var values []string
+		for page := 0; len(values) < maxCompletionRepositories; page++ {
+			repos, err := c.s.catalog.GetRepositories(ctx, account, marketplace.RepositoryParams{Size: repositoryPageSize, Page: page})
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range repos.Repositories {
+				values = append(values, r.Name)
+			}
+			if len(repos.Repositories) < repositoryPageSize || (repos.Count > 0 && len(values) >= repos.Count) {
+				break
+			}
+		}
+		return values[:min(len(values), maxCompletionRepositories)], nil
+	})
+}
+
+// versions returns the versions of a package, newest first.
+func (c *completer) versions(ctx context.Context, ref marketplace.Reference) []string {
+	return c.cache.get("versions/"+ref.Account+"/"+ref.Repository, func() ([]string, error) {
+		md, err := c.s.catalog.GetPackageMetadata(ctx, ref.Account, ref.Repository, "", false)
+		if err != nil {
+			return nil, err
+		}
+		values := slices.Clone(md.Versions)
+		semver.Sort(values)
+		slices.Reverse(values)
+		return values, nil
+	})
+}
+
+// latest returns the latest version of a package.
+func (c *completer) latest(ctx context.Context, ref marketplace.Reference) (string, bool) {
+	values := c.cache.get("latest/"+ref.Account+"/"+ref.Repository, func() ([]string, error) {
+		md, err := c.s.catalog.GetPackageMetadata(ctx, ref.Account, ref.Repository, "", false)
+		if err != nil {
+			return nil, err
+		}
+		return []string{md.LatestVersion}, nil
+	})
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
+
+// groupKinds returns the group and kind of each kind defined by the chosen
+// package version, or by its latest version if no version is chosen, as
+// group/kind pairs.
+func (c *completer) groupKinds(ctx context.Context, chosen map[string]string, kinds kindSet) []string {
+	ref, ok := chosenPackage(chosen)
+	if !ok || ref.Digest != "" && ref.Version == "" {
+		return nil
+	}
+	if ref.Version == "" || ref.Version == "latest" {
+		if ref.Version, ok = c.latest(ctx, ref); !ok {
+			return nil
+		}
+	}
+	pairs := c.cache.get("kinds/"+ref.Account+"/"+ref.Repository+"/"+ref.Version, func() ([]string, error) {
+		res, err := c.s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, ref.Account, ref.Repository, ref.Version)
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for _, crd := range res.CRDs {
+			values = append(values, "crd/"+crd.Group+"/"+crd.Kind)
+		}
+		for _, xrd := range res.XRDs {
+			values = append(values, "xrd/"+xrd.Group+"/"+xrd.Kind)
+		}
+		return values, nil
+	})
+
+	var out []string
+	for _, p := range pairs {
+		set, groupKind, _ := strings.Cut(p, "/")
+		if set == "crd" && kinds&crdKinds != 0 || set == "xrd" && kinds&xrdKinds != 0 {
+			out = append(out, groupKind)
+		}
+	}
+	return out
+}
+
+// groups returns the groups of the kinds the chosen package defines.
+func (c *completer) groups(ctx context.Context, chosen map[string]string, kinds kindSet) []string {
+	var values []string
+	for _, gk := range c.groupKinds(ctx, chosen, kinds) {
+		group, _, _ := strings.Cut(gk, "/")
+		values = append(values, group)
+	}
+	return values
+}
+
+// kinds returns the kinds the chosen package defines, in the chosen group if
+// one is.
+func (c *completer) kinds(ctx context.Context, chosen map[string]string, kinds kindSet) []string {
+	group := first(chosen, "group", "resource_group")
+	var values []string
+	for _, gk := range c.groupKinds(ctx, chosen, kinds) {
+		g, kind, _ := strings.Cut(gk, "/")
+		if group == "" || g == group {
+			values = append(values, kind)
+		}
+	}
+	return values
+}
+
+// completion returns the distinct values that start with prefix, ignoring
+// case.
+func completion(values []string, prefix string) *mcp.Completion {
+	matches := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(strings.ToLower(v), strings.ToLower(prefix)) && !slices.Contains(matches, v) {
+			matches = append(matches, v)
+		}
+	}
+	out := &mcp.Completion{Values: matches, Total: len(matches)}
+	if len(matches) > maxCompletions {
+		out.Values, out.HasMore = matches[:maxCompletions], true
+	}
+	return out
+}
+
+// completionCache caches the catalog data arguments complete to. It holds at
+// most maxCompletionEntries entries, evicting expired entries first and then
+// those closest to expiring.
+type completionCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]completionEntry
+}
+
+type completionEntry struct {
+	values  []string
+	expires time.Time
+}
+
+// get returns the cached values of a key, loading them if they are missing
+// or have expired. Values that fail to load are not cached.
+func (c *completionCache) get(key string, load func() ([]string, error)) []string {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok && !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return e.values
+	}
+
+	values, err := load()
+	if err != nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]completionEntry{}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCompletionEntries {
+		c.evict()
+	}
+	c.entries[key] = completionEntry{values: values, expires: c.now().Add(c.ttl)}
+	return values
+}
+
+// evict removes the expired entries, or if none have expired the entry
+// closest to expiring. The caller must hold the lock.
+func (c *completionCache) evict() {
+	now := c.now()
+	soonest := ""
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if soonest == "" || e.expires.Before(c.entries[soonest].expires) {
+			soonest = key
+		}
+	}
+	if len(c.entries) >= maxCompletionEntries {
+		delete(c.entries, soonest)
+	}
+}
@@ -0,0 +1,238 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+	"github.com/upbound/marketplace-mcp-server/internal/syncer"
+	"github.com/upbound/marketplace-mcp-server/internal/verify"
+	"github.com/upbound/marketplace-mcp-server/internal/watch"
+)
+
+const (
+	// EnvLocalPackagePaths is a list of local package paths, separated by the
+	// OS path list separator, that are served under the local account.
+	EnvLocalPackagePaths = "LOCAL_PACKAGE_PATHS"
+
+	// EnvRegistryCatalogs is a comma separated list of OCI registries to read
+	// packages from directly, each written as <url>=<account>[|<account>...].
+	// Credentials may be supplied as user info in the URL.
+	EnvRegistryCatalogs = "REGISTRY_CATALOGS"
+
+	// EnvSnapshotPaths is a list of snapshot archives, separated by the OS
+	// path list separator, that are served as read-only catalogs.
+	EnvSnapshotPaths = "SNAPSHOT_PATHS"
+
+	// EnvSnapshotDir is a directory of snapshots the diff_snapshots tool
+	// compares.
+	EnvSnapshotDir = "SNAPSHOT_DIR"
+
+	// EnvVerificationKeys is a list of PEM encoded public key files, or
+	// directories of them, separated by the OS path list separator, that are
+	// trusted when verifying package signatures.
+	EnvVerificationKeys = "VERIFICATION_KEYS"
+
+	// EnvCacheDir is the directory indexes are persisted in. It defaults to
+	// a directory in the user's cache directory.
+	EnvCacheDir = "CACHE_DIR"
+
+	// EnvSyncAccounts is a comma separated list of accounts whose packages a
+	// background worker keeps indexed. Only the HTTP server runs the worker.
+	EnvSyncAccounts = "SYNC_ACCOUNTS"
+
+	// EnvSyncInterval is the time between background syncs, as a Go
+	// duration such as 6h.
+	EnvSyncInterval = "SYNC_INTERVAL"
+
+	// EnvSyncRate is the maximum number of catalog requests per second the
+	// background worker sends.
+	EnvSyncRate = "SYNC_RATE"
+
+	// EnvWatchInterval is the time between polls of the packages clients
+	// are subscribed to, as a Go duration such as 5m. Only the HTTP server
+	// supports subscriptions.
+	EnvWatchInterval = "WATCH_INTERVAL"
+
+	// EnvTemplatesDir is a directory of text/template files that override
+	// or extend the built-in templates rendering markdown and text output.
+	EnvTemplatesDir = "TEMPLATES_DIR"
+
+	// EnvToolsConfig is a YAML file with enabled and disabled lists of the
+	// tools and toolsets to register.
+	EnvToolsConfig = "TOOLS_CONFIG"
+
+	// EnvEnabledTools is a comma separated list of the tools and toolsets to
+	// register. Every tool is registered when it is empty.
+	EnvEnabledTools = "ENABLED_TOOLS"
+
+	// EnvDisabledTools is a comma separated list of the tools and toolsets
+	// not to register.
+	EnvDisabledTools = "DISABLED_TOOLS"
+)
+
+// DefaultCacheDir returns the directory indexes are persisted in when
+// EnvCacheDir is not set, or an empty string if the user has no cache
+// directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "marketplace-mcp-server")
+}
+
+// OptionsFromEnv returns the server options configured through environment
+// variables.
+func OptionsFromEnv() []Option {
+	opts := []Option{WithCacheDir(DefaultCacheDir())}
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		opts = []Option{WithCacheDir(dir)}
+	}
+	if paths := os.Getenv(EnvLocalPackagePaths); paths != "" {
+		opts = append(opts, WithLocalPackages(filepath.SplitList(paths)...))
+	}
+	if v := os.Getenv(EnvRegistryCatalogs); v != "" {
+		for i, entry := range strings.Split(v, ",") {
+			o, err := registryCatalog(strings.TrimSpace(entry))
+			if err != nil {
+				log.Printf("Warning: Ignoring registry catalog %s: %v", registryCatalogName(i, entry), err)
+				continue
+			}
+			opts = append(opts, o)
+		}
+	}
+	if paths := os.Getenv(EnvSnapshotPaths); paths != "" {
+		for _, p := range filepath.SplitList(paths) {
+			snap, err := snapshot.Open(p)
+			if err != nil {
+				log.Printf("Warning: Ignoring snapshot: %v", err)
+				continue
+			}
+			opts = append(opts, WithSnapshot("snapshot:"+filepath.Base(p), snap))
+		}
+	}
+	if dir := os.Getenv(EnvSnapshotDir); dir != "" {
+		opts = append(opts, WithSnapshotDir(dir))
+	}
+	if dir := os.Getenv(EnvTemplatesDir); dir != "" {
+		t, err := render.LoadTemplates(dir)
+		if err != nil {
+			log.Printf("Warning: Ignoring templates: %v", err)
+		} else {
+			opts = append(opts, WithTemplates(t))
+		}
+	}
+	if paths := os.Getenv(EnvVerificationKeys); paths != "" {
+		keys, err := verify.LoadKeys(filepath.SplitList(paths)...)
+		if err != nil {
+			log.Printf("Warning: Ignoring verification keys: %v", err)
+		} else {
+			opts = append(opts, WithVerificationKeys(keys...))
+		}
+	}
+	return opts
+}
+
+// SyncOptionsFromEnv returns the background sync options configured through
+// environment variables.
+func SyncOptionsFromEnv() []Option {
+	v := os.Getenv(EnvSyncAccounts)
+	if v == "" {
+		return nil
+	}
+	var accounts []string
+	for _, a := range strings.Split(v, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			accounts = append(accounts, a)
+		}
+	}
+	var opts []syncer.Option
+	if v := os.Getenv(EnvSyncInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Printf("Warning: Ignoring invalid %s %q", EnvSyncInterval, v)
+		} else {
+			opts = append(opts, syncer.WithInterval(d))
+		}
+	}
+	if v := os.Getenv(EnvSyncRate); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r <= 0 {
+			log.Printf("Warning: Ignoring invalid %s %q", EnvSyncRate, v)
+		} else {
+			opts = append(opts, syncer.WithRate(r))
+		}
+	}
+	return []Option{WithSync(accounts, opts...)}
+}
+
+// WatchOptionsFromEnv returns the options that make packages subscribable,
+// configured through environment variables.
+func WatchOptionsFromEnv() []Option {
+	var opts []watch.Option
+	if v := os.Getenv(EnvWatchInterval); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Printf("Warning: Ignoring invalid %s %q", EnvWatchInterval, v)
+		} else {
+			opts = append(opts, watch.WithInterval(d))
+		}
+	}
+	return []Option{WithWatch(opts...)}
+}
+
+// registryCatalog parses a registry catalog entry of the form
+// <url>=<account>[|<account>...].
+func registryCatalog(entry string) (Option, error) {
+	raw, accts, _ := strings.Cut(entry, "=")
+	if accts == "" {
+		return nil, fmt.Errorf("no accounts given, expected <url>=<account>[|<account>...]")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL")
+	}
+	ropts := []registry.Option{}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		ropts = append(ropts, registry.WithCredentials(u.User.Username(), password))
+		u.User = nil
+	}
+	ropts = append(ropts, registry.WithBaseURL(u.String()))
+	return WithRegistryCatalog(u.Host, registry.NewClient(ropts...), strings.Split(accts, "|")...), nil
+}
+
+// registryCatalogName identifies the i-th entry of EnvRegistryCatalogs in log
+// messages without revealing the credentials it may embed.
+func registryCatalogName(i int, entry string) string {
+	raw, _, _ := strings.Cut(strings.TrimSpace(entry), "=")
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return fmt.Sprintf("%q", u.Redacted())
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
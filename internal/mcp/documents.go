@@ -0,0 +1,565 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/contextpack"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/fields"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/recommend"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+	"github.com/upbound/marketplace-mcp-server/internal/syncer"
+	"github.com/upbound/marketplace-mcp-server/internal/upgrade"
+	"github.com/upbound/marketplace-mcp-server/internal/verify"
+)
+
+// searchResultsDocument describes search results.
+func searchResultsDocument(result *marketplace.SearchResponse) *render.Document {
+	doc := render.NewDocument("Search Results")
+	if result == nil || len(result.Packages) == 0 {
+		return doc.Paragraph("No packages found.")
+	}
+	doc.Field("Total", strconv.Itoa(result.Total)).Field("Page", result.Page)
+
+	rows := make([][]string, 0, len(result.Packages))
+	for _, pkg := range result.Packages {
+		rows = append(rows, []string{
+			pkg.Account + "/" + pkg.Repository,
+			pkg.Version,
+			pkg.Type,
+			pkg.Tier,
+			count(pkg.Downloads),
+			strings.Join(pkg.Tags, ", "),
+			pkg.Source,
+			pkg.Description,
+		})
+	}
+	return doc.Table([]string{"Package", "Version", "Type", "Tier", "Downloads", "Tags", "Source", "Description"}, rows)
+}
+
+// packageMetadataDocument describes the metadata of a package.
+func packageMetadataDocument(md *marketplace.PackageMetadata) *render.Document {
+	if md == nil {
+		return render.NewDocument("Package").Paragraph("No package metadata.")
+	}
+	doc := render.NewDocument(fmt.Sprintf("Package %s/%s", md.Account, md.Repository)).
+		Field("Name", md.Name).
+		Field("Description", md.Description).
+		Field("Version", md.Version).
+		Field("Latest version", md.LatestVersion).
+		Field("Type", md.Type).
+		Field("Tier", md.Tier).
+		Field("Public", md.Public).
+		Field("License", md.License).
+		Field("Homepage", md.Homepage).
+		Field("Documentation", md.Documentation).
+		Field("Tags", md.Tags).
+		Field("Keywords", md.Keywords).
+		Field("Stars", md.Stars).
+		Field("Downloads", md.Downloads).
+		Field("Created", md.CreatedAt).
+		Field("Updated", md.UpdatedAt)
+
+	doc.Section("Versions").List(md.Versions...)
+
+	deps := make([][]string, 0, len(md.Dependencies))
+	for _, d := range md.Dependencies {
+		deps = append(deps, []string{d.Name, d.Version, d.Constraints})
+	}
+	doc.Section("Dependencies").Table([]string{"Package", "Version", "Constraints"}, deps)
+
+	crds := make([][]string, 0, len(md.CRDs))
+	for _, c := range md.CRDs {
+		crds = append(crds, []string{c.Kind, c.Group, c.Version, c.Description})
+	}
+	doc.Section("Custom Resource Definitions").Table([]string{"Kind", "Group", "Version", "Description"}, crds)
+
+	comps := make([][]string, 0, len(md.Compositions))
+	for _, c := range md.Compositions {
+		comps = append(comps, []string{c.Name, strconv.Itoa(len(c.Resources)), c.Description})
+	}
+	doc.Section("Compositions").Table([]string{"Name", "Resources", "Description"}, comps)
+
+	fns := make([][]string, 0, len(md.Functions))
+	for _, f := range md.Functions {
+		fns = append(fns, []string{f.Name, f.Version, f.Image, f.Description})
+	}
+	doc.Section("Functions").Table([]string{"Name", "Version", "Image", "Description"}, fns)
+
+	exs := make([][]string, 0, len(md.Examples))
+	for _, e := range md.Examples {
+		exs = append(exs, []string{e.Name, e.Type, e.Description})
+	}
+	return doc.Section("Examples").Table([]string{"Name", "Type", "Description"}, exs)
+}
+
+// packageAssetsDocument describes an asset of a package.
+func packageAssetsDocument(assets *marketplace.AssetResponse, assetType string) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Package Assets (%s)", assetType))
+	if assets == nil {
+		return doc.Paragraph(fmt.Sprintf("No %s assets found.", assetType))
+	}
+	doc.Field("URL", assets.URL).Field("Type", assets.Type)
+	switch {
+	case assets.Content == "" && assets.URL == "":
+		return doc.Paragraph("No content available.")
+	case assets.Content == "":
+		return doc
+	case assetType == "sbom":
+		return doc.Code("json", assets.Content)
+	case assetType == "icon":
+		return doc.Paragraph("Icon asset retrieved (binary data).")
+	default:
+		return doc.Paragraph(assets.Content)
+	}
+}
+
+// repositoriesDocument describes the repositories of an account.
+func repositoriesDocument(repos *marketplace.RepositoryResponse) *render.Document {
+	doc := render.NewDocument("Repositories")
+	if repos == nil || len(repos.Repositories) == 0 {
+		return doc.Paragraph("No repositories found.")
+	}
+	doc.Field("Count", strconv.Itoa(repos.Count)).Field("Page", repos.Page)
+
+	rows := make([][]string, 0, len(repos.Repositories))
+	for _, r := range repos.Repositories {
+		rows = append(rows, []string{
+			r.Name,
+			r.Type,
+			strconv.FormatBool(r.Public),
+			count(r.PackageCount),
+			date(r.UpdatedAt),
+			r.Description,
+		})
+	}
+	return doc.Table([]string{"Name", "Type", "Public", "Packages", "Updated", "Description"}, rows)
+}
+
+// packageResourcesDocument describes the resources of a package version.
+func packageResourcesDocument(res *marketplace.PackageResources) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Resources of %s/%s", res.Account, res.Repository)).
+		Field("Type", string(res.PackageType)).
+		Field("Tier", res.Tier).
+		Field("Public", res.Public).
+		Field("Digest", res.PkgDigest)
+	if res.FamilyRepoKey != nil {
+		doc.Field("Family", *res.FamilyRepoKey)
+	}
+	doc.Field("Summary", fmt.Sprintf("%d CRDs, %d XRDs, %d compositions", len(res.CRDs), len(res.XRDs), len(res.Compositions)))
+
+	crds := make([][]string, 0, len(res.CRDs))
+	for _, c := range res.CRDs {
+		crds = append(crds, []string{c.Kind, c.Group, c.StorageVersion, strings.Join(c.Versions, ", "), c.Scope})
+	}
+	slices.SortFunc(crds, slices.Compare[[]string])
+	doc.Section("Custom Resource Definitions").Table([]string{"Kind", "Group", "Storage Version", "Versions", "Scope"}, crds)
+
+	xrds := make([][]string, 0, len(res.XRDs))
+	for _, x := range res.XRDs {
+		xrds = append(xrds, []string{x.Kind, x.Group, x.ReferenceableVersion, strings.Join(x.Versions, ", ")})
+	}
+	slices.SortFunc(xrds, slices.Compare[[]string])
+	doc.Section("Composite Resource Definitions").Table([]string{"Kind", "Group", "Referenceable Version", "Versions"}, xrds)
+
+	comps := make([][]string, 0, len(res.Compositions))
+	for _, c := range res.Compositions {
+		comps = append(comps, []string{c.Name, c.XrdKind, c.XrdAPIVersion, strconv.Itoa(c.ResourceCount)})
+	}
+	doc.Section("Compositions").Table([]string{"Name", "XR Kind", "XR API Version", "Resources"}, comps)
+
+	doc.Section("Highlights")
+	for _, k := range slices.Sorted(maps.Keys(res.Highlights)) {
+		doc.Field(k, strings.Join(res.Highlights[k], " … "))
+	}
+	return doc
+}
+
+// kindList lists the kinds defined by CRDs.
+func kindList(crds []marketplace.CRDMeta) []string {
+	lines := make([]string, 0, len(crds))
+	for _, c := range crds {
+		lines = append(lines, fmt.Sprintf("%s.%s (%s)", c.Kind, c.Group, c.StorageVersion))
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// definitionDocument summarises a Kubernetes object by its kind and name.
+// The object itself is only rendered as JSON or YAML.
+func definitionDocument(def Definition) *render.Document {
+	kind, _ := def["kind"].(string)
+	apiVersion, _ := def["apiVersion"].(string)
+	meta, _ := def["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	return render.NewDocument("Definition").
+		Field("Kind", kind).
+		Field("Name", name).
+		Field("API Version", apiVersion).
+		Paragraph("See the structured content, or request json or yaml output, for the full object.")
+}
+
+// examplesDocument describes the examples of a kind.
+func examplesDocument(exs *marketplace.Examples, group, kind string) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Examples of %s.%s", kind, group))
+	if exs == nil || len(exs.Examples) == 0 {
+		return doc.Paragraph("No examples found.")
+	}
+	for _, ex := range exs.Examples {
+		doc.Code("yaml", ex)
+	}
+	return doc
+}
+
+// kindContextDocument describes the context needed to author a resource of a
+// kind.
+func kindContextDocument(p *contextpack.Pack) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Authoring %s.%s", p.Kind, p.Group)).
+		Field("Package", fmt.Sprintf("%s/%s:%s", p.Account, p.Repository, p.Version)).
+		Field("API Version", p.APIVersion)
+
+	fields := make([][]string, 0, len(p.Fields))
+	for _, f := range p.Fields {
+		required := ""
+		if f.Required {
+			required = "yes"
+		}
+		fields = append(fields, []string{f.Path, f.Type, required, f.Description})
+	}
+	doc.Section("Fields of "+p.Root).Table([]string{"Field", "Type", "Required", "Description"}, fields)
+
+	if pc := p.ProviderConfig; pc != nil {
+		doc.Section("ProviderConfig").
+			Field("Field", pc.Field).
+			Field("Kind", pc.Kind).
+			Field("API Version", pc.APIVersion).
+			Field("Default", pc.Default).
+			Field("Description", pc.Description)
+	}
+	if cs := p.ConnectionSecret; cs != nil {
+		doc.Section("Connection Secret").
+			Field("Field", cs.Field).
+			Field("Keys", strings.Join(cs.Keys, ", "))
+	}
+	if p.Example != "" {
+		doc.Section("Example").Code("yaml", p.Example)
+	}
+	if p.Docs != "" {
+		doc.Section("Docs").Paragraph(p.Docs)
+	}
+	doc.Section("Trimmed to Fit").List(p.Trimmed...)
+	doc.Section("Warnings").List(p.Warnings...)
+	return doc
+}
+
+// diffReportDocument describes a package version diff, breaking changes
+// first.
+func diffReportDocument(report *diff.Report, breakingOnly bool) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Changes from %s/%s %s to %s", report.Account, report.Repository, report.From, report.To)).
+		Field("Breaking", strconv.Itoa(report.Breaking)).
+		Field("Non-breaking", strconv.Itoa(len(report.Changes)-report.Breaking))
+
+	var breaking, other []diff.Change
+	for _, c := range report.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		} else {
+			other = append(other, c)
+		}
+	}
+	doc.Section("Breaking Changes").Table(changeHeader, changeRows(breaking))
+	if !breakingOnly {
+		doc.Section("Non-breaking Changes").Table(changeHeader, changeRows(other))
+	}
+	return doc.Section("Not Compared").List(report.Errors...)
+}
+
+var changeHeader = []string{"Change", "Kind", "Version", "Field", "Detail"}
+
+// changeRows returns the table rows of schema changes.
+func changeRows(changes []diff.Change) [][]string {
+	rows := make([][]string, 0, len(changes))
+	for _, c := range changes {
+		rows = append(rows, []string{string(c.Type), c.Kind + "." + c.Group, c.Version, c.Path, c.Detail})
+	}
+	return rows
+}
+
+// upgradePlanDocument describes an upgrade plan.
+func upgradePlanDocument(plan *upgrade.Report) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Upgrade Plan: %s/%s %s -> %s", plan.Account, plan.Repository, plan.From, plan.To)).
+		Field("Releases", strconv.Itoa(len(plan.Releases))).
+		Field("Breaking schema changes", strconv.Itoa(len(plan.SchemaChanges))).
+		Field("Breaking changes in release notes", strconv.Itoa(len(plan.Breaking))).
+		Field("Deprecations", strconv.Itoa(len(plan.Deprecations)))
+
+	doc.Section("Breaking Schema Changes").Table(changeHeader, changeRows(plan.SchemaChanges))
+	doc.List(prefixed("Not compared: ", plan.SchemaErrors)...)
+	doc.Section("Breaking Changes in Release Notes").List(notes(plan.Breaking)...)
+	doc.Section("Deprecations").List(notes(plan.Deprecations)...)
+
+	for _, r := range plan.Releases {
+		doc.Section("Release " + r.Version)
+		switch {
+		case r.Error != "":
+			doc.Paragraph(r.Error)
+		case r.URL != "":
+			doc.Field("Release notes", r.URL)
+		case len(r.Notable) == 0:
+			doc.Paragraph("No notable changes.")
+		}
+		doc.List(r.Notable...)
+		if r.Omitted > 0 {
+			doc.Paragraph(fmt.Sprintf("... and %d more", r.Omitted))
+		}
+	}
+	return doc
+}
+
+// notes lists release notes by the version they were made in.
+func notes(ns []upgrade.Note) []string {
+	out := make([]string, 0, len(ns))
+	for _, n := range ns {
+		out = append(out, n.Version+": "+n.Text)
+	}
+	return out
+}
+
+// recommendationDocument describes recommended providers.
+func recommendationDocument(result *recommend.Result) *render.Document {
+	doc := render.NewDocument("Recommended Providers")
+	if len(result.Providers) == 0 {
+		doc.Paragraph("No providers found.")
+	}
+	for _, p := range result.Providers {
+		doc.Section(fmt.Sprintf("%s/%s:%s", p.Account, p.Repository, p.Version)).
+			Field("Family", p.Family).
+			Field("CRDs installed", strconv.Itoa(p.CRDCount)).
+			Field("Serves", p.Kinds)
+	}
+	return doc.Section("No Provider Found").List(result.Unresolved...)
+}
+
+// kindMatchesDocument describes kind index matches.
+func kindMatchesDocument(result KindMatches) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Kinds matching %q", result.Query))
+	if result.Building {
+		doc.Paragraph("The kind index is still being built, so these matches may be incomplete. Call find_kind again shortly for complete results.")
+	}
+	if len(result.Failed) > 0 {
+		doc.Paragraph(fmt.Sprintf("The kind index is partial: %d packages could not be read, so their kinds may be missing. Call find_kind with refresh to retry them.", len(result.Failed))).
+			List(result.Failed...)
+	}
+	if len(result.Matches) == 0 {
+		return doc.Paragraph("No kinds found.")
+	}
+	rows := make([][]string, 0, len(result.Matches))
+	for _, m := range result.Matches {
+		pkgs := make([]string, 0, len(m.Packages))
+		for _, p := range m.Packages {
+			pkgs = append(pkgs, fmt.Sprintf("%s/%s:%s", p.Account, p.Repository, p.Version))
+		}
+		rows = append(rows, []string{m.Kind + "." + m.Group, m.Type, m.Plural, strings.Join(m.ShortNames, ", "), strings.Join(pkgs, ", ")})
+	}
+	return doc.Table([]string{"Kind", "Type", "Plural", "Short Names", "Packages"}, rows)
+}
+
+// fieldHitsDocument describes field search hits.
+func fieldHitsDocument(query string, hits []fields.Hit) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Fields matching %q", query))
+	if len(hits) == 0 {
+		return doc.Paragraph("No fields found.")
+	}
+	rows := make([][]string, 0, len(hits))
+	for _, h := range hits {
+		rows = append(rows, []string{
+			h.Path,
+			h.Type,
+			fmt.Sprintf("%s.%s %s", h.Kind, h.Group, h.APIVersion),
+			fmt.Sprintf("%s/%s:%s", h.Account, h.Repository, h.Version),
+			fmt.Sprintf("%.2f", h.Score),
+			h.Snippet,
+		})
+	}
+	return doc.Table([]string{"Field", "Type", "Kind", "Package", "Score", "Description"}, rows)
+}
+
+// snapshotDiffDocument describes the changes between two snapshots. At most
+// top popularity changes are listed; zero lists them all.
+func snapshotDiffDocument(r *snapshot.DiffReport, top int) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Marketplace changes %s to %s", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly)))
+	section := func(title string, n int) bool {
+		doc.Section(fmt.Sprintf("%s (%d)", title, n))
+		if n == 0 {
+			doc.Paragraph("None.")
+		}
+		return n > 0
+	}
+	packages := func(ps []snapshot.PackageChange) {
+		rows := make([][]string, 0, len(ps))
+		for _, p := range ps {
+			rows = append(rows, []string{p.Account + "/" + p.Repository, p.Type, p.Tier, p.Version})
+		}
+		doc.Table([]string{"Package", "Type", "Tier", "Version"}, rows)
+	}
+
+	if section("New packages", len(r.NewPackages)) {
+		packages(r.NewPackages)
+	}
+	if section("New versions", len(r.NewVersions)) {
+		items := make([]string, 0, len(r.NewVersions))
+		for _, v := range r.NewVersions {
+			items = append(items, fmt.Sprintf("%s/%s: %s", v.Account, v.Repository, strings.Join(v.Versions, ", ")))
+		}
+		doc.List(items...)
+	}
+	if section("Deprecated packages", len(r.DeprecatedPackages)) {
+		packages(r.DeprecatedPackages)
+	}
+	if section("Removed packages", len(r.RemovedPackages)) {
+		packages(r.RemovedPackages)
+	}
+	if section("Tier changes", len(r.TierChanges)) {
+		rows := make([][]string, 0, len(r.TierChanges))
+		for _, t := range r.TierChanges {
+			rows = append(rows, []string{t.Account + "/" + t.Repository, cmp.Or(t.From, "none"), cmp.Or(t.To, "none")})
+		}
+		doc.Table([]string{"Package", "From", "To"}, rows)
+	}
+	if section("Downloads and stars", len(r.Popularity)) {
+		pop := r.Popularity
+		if top > 0 && len(pop) > top {
+			pop = pop[:top]
+		}
+		rows := make([][]string, 0, len(pop))
+		for _, p := range pop {
+			rows = append(rows, []string{
+				p.Account + "/" + p.Repository,
+				strconv.Itoa(p.Downloads), fmt.Sprintf("%+d", p.DownloadsDelta),
+				strconv.Itoa(p.Stars), fmt.Sprintf("%+d", p.StarsDelta),
+			})
+		}
+		doc.Table([]string{"Package", "Downloads", "Downloads change", "Stars", "Stars change"}, rows)
+		if len(pop) < len(r.Popularity) {
+			doc.Paragraph(fmt.Sprintf("%d more packages changed.", len(r.Popularity)-len(pop)))
+		}
+	}
+	return doc
+}
+
+// verificationDocument describes the verification of a package.
+func verificationDocument(res *verify.Result) *render.Document {
+	doc := render.NewDocument(fmt.Sprintf("Verification of %s:%s", res.Repository, res.Reference)).
+		Field("Digest", res.Digest).
+		Field("Signed", strconv.FormatBool(res.Signed)).
+		Field("Verified", strconv.FormatBool(res.Verified))
+	if res.DigestMatch != nil {
+		doc.Field("Marketplace digest", res.MarketplaceDigest).
+			Field("Digest matches marketplace", strconv.FormatBool(*res.DigestMatch))
+	}
+
+	sigs := make([][]string, 0, len(res.Signatures))
+	for _, s := range res.Signatures {
+		sigs = append(sigs, []string{s.Signer, s.Identity, strconv.FormatBool(s.Verified), s.Error})
+	}
+	doc.Section("Signatures").Table([]string{"Signer", "Identity", "Verified", "Error"}, sigs)
+
+	atts := make([][]string, 0, len(res.Attestations))
+	for _, a := range res.Attestations {
+		row := []string{a.PredicateType, a.Signer, strconv.FormatBool(a.Verified), "", "", a.Error}
+		if a.Provenance != nil {
+			row[3], row[4] = a.Provenance.BuilderID, a.Provenance.SourceURI
+		}
+		atts = append(atts, row)
+	}
+	doc.Section("Attestations").Table([]string{"Predicate", "Signer", "Verified", "Builder", "Source", "Error"}, atts)
+
+	doc.Section("Policy")
+	if len(res.Violations) == 0 {
+		return doc.Paragraph("Passed.")
+	}
+	return doc.List(res.Violations...)
+}
+
+// syncStatusDocument describes the status of the background sync worker.
+func syncStatusDocument(status syncer.Status) *render.Document {
+	doc := render.NewDocument("Background Sync")
+	if status.State == "" {
+		return doc.Paragraph("Background sync is not configured.")
+	}
+	return doc.
+		Field("State", status.State).
+		Field("Accounts", status.Accounts).
+		Field("Cycle", strconv.Itoa(status.Cycle)).
+		Field("Syncing account", status.Account).
+		Field("Packages checked", strconv.Itoa(status.Checked)).
+		Field("Packages indexed", strconv.Itoa(status.Indexed)).
+		Field("Packages failed", strconv.Itoa(status.Failed)).
+		Field("Started", status.StartedAt).
+		Field("Last completed", status.LastCompletedAt).
+		Field("Next run", status.NextRunAt).
+		Field("Last error", status.LastError).
+		Field("Last error at", status.LastErrorAt)
+}
+
+// authStatusDocument describes the result of reloading authentication.
+func authStatusDocument(status AuthStatus) *render.Document {
+	doc := render.NewDocument("Authentication")
+	if status.ServerURL == "" {
+		return doc.
+			Paragraph("Successfully reloaded authentication token from UP CLI profile, but failed to reload server URL.").
+			Field("Warning", status.Warning)
+	}
+	return doc.
+		Paragraph("Successfully reloaded authentication and server configuration from UP CLI profile.").
+		Field("Server URL", status.ServerURL)
+}
+
+// count formats a count, leaving zero counts blank.
+func count(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// date formats the date of a timestamp, leaving zero timestamps blank.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// prefixed prefixes each of a list of strings.
+func prefixed(prefix string, ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, prefix+s)
+	}
+	return out
+}
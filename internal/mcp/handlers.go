@@ -18,488 +18,673 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/pkg/errors"
 
+	"github.com/upbound/marketplace-mcp-server/internal/args"
+	"github.com/upbound/marketplace-mcp-server/internal/auth"
+	"github.com/upbound/marketplace-mcp-server/internal/budget"
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/contextpack"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/fields"
+	"github.com/upbound/marketplace-mcp-server/internal/index"
+	"github.com/upbound/marketplace-mcp-server/internal/local"
 	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/recommend"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+	"github.com/upbound/marketplace-mcp-server/internal/upgrade"
+	"github.com/upbound/marketplace-mcp-server/internal/verify"
 )
 
 // handleSearchPackages handles the search_packages tool.
-func (s *Server) handleSearchPackages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters using built-in methods
-	query := req.GetString("query", "")
-	family := req.GetString("family", "")
-	packageType := req.GetString("package_type", "")
-	accountName := req.GetString("account_name", "")
-	tier := req.GetString("tier", "")
-	size := req.GetInt("size", 20)
-	page := req.GetInt("page", 0)
-	useV1 := req.GetBool("use_v1", false)
-
-	// Handle public parameter (optional boolean)
-	var public *bool
-	args := req.GetArguments()
-	if val, ok := args["public"]; ok {
-		if b, ok := val.(bool); ok {
-			public = &b
+func (s *Server) handleSearchPackages(ctx context.Context, req mcp.CallToolRequest, a searchPackagesArgs) (*mcp.CallToolResult, error) {
+	// A package reference narrows the search to its account and name
+	if a.Package != "" {
+		ref, err := marketplace.ParseReference(a.Package)
+		if err == nil {
+			err = s.checkRegistry(ref)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), err
+		}
+		if a.AccountName == "" {
+			a.AccountName = ref.Account
+		}
+		if a.Query == "" {
+			a.Query = ref.Repository
 		}
 	}
 
 	// Prepare search parameters
 	params := marketplace.SearchParams{
-		Query:       query,
-		Family:      family,
-		PackageType: packageType,
-		AccountName: accountName,
-		Tier:        tier,
-		Size:        size,
-		Page:        page,
-		UseV1:       useV1,
-		Public:      public,
+		Query:       a.Query,
+		Family:      a.Family,
+		PackageType: a.PackageType,
+		AccountName: a.AccountName,
+		Tier:        a.Tier,
+		Size:        a.Size,
+		Page:        a.Page,
+		UseV1:       a.UseV1,
+		Public:      a.Public,
 	}
 
 	// Perform search
-	result, err := s.client.SearchPackages(ctx, params)
+	result, err := s.catalog.SearchPackages(ctx, params)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), err
 	}
 
-	return mcp.NewToolResultText(formatSearchResults(result)), nil
+	return s.structuredResult(req, result, searchResultsDocument(result))
 }
 
 // handleGetPackageMetadata handles the get_package_metadata tool.
-func (s *Server) handleGetPackageMetadata(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract required parameters
-	account, err := req.RequireString("account")
-	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+func (s *Server) handleGetPackageMetadata(ctx context.Context, req mcp.CallToolRequest, a getPackageMetadataArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		md := pkg.Metadata(local.Account, pkg.Name(), local.Version)
+		return s.structuredResult(req, md, packageMetadataDocument(md))
 	}
 
-	repository, err := req.RequireString("repository")
+	// Extract the package, whose version is optional
+	ref, err := a.ref(a.Version)
 	if err != nil {
-		return mcp.NewToolResultError("repository parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	version, err := s.catalogVersion(ref, false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
 	}
-
-	// Extract optional parameters
-	version := req.GetString("version", "")
-	useV1 := req.GetBool("use_v1", false)
 
 	// Get package metadata
-	metadata, err := s.client.GetPackageMetadata(ctx, account, repository, version, useV1)
+	metadata, err := s.catalog.GetPackageMetadata(ctx, ref.Account, ref.Repository, version, a.UseV1)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get package metadata: %v", err)), err
 	}
+	s.rememberPackage(ref.Account, ref.Repository, metadata.Version)
 
-	return mcp.NewToolResultText(formatPackageMetadata(metadata)), nil
+	return s.structuredResult(req, metadata, packageMetadataDocument(metadata))
 }
 
 // handleGetPackageAssets handles the get_package_assets tool.
-func (s *Server) handleGetPackageAssets(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract required parameters
-	account, err := req.RequireString("account")
-	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
-	}
-
-	repository, err := req.RequireString("repository")
-	if err != nil {
-		return mcp.NewToolResultError("repository parameter is required"), err
+func (s *Server) handleGetPackageAssets(ctx context.Context, req mcp.CallToolRequest, a getPackageAssetsArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		if a.AssetType != "docs" && a.AssetType != "readme" {
+			return mcp.NewToolResultError(fmt.Sprintf("Local packages only have docs and readme assets, not %s", a.AssetType)), nil
+		}
+		assets := &marketplace.AssetResponse{Content: pkg.Readme()}
+		return s.structuredResult(req, assets, packageAssetsDocument(assets, a.AssetType))
 	}
 
-	version, err := req.RequireString("version")
+	// Extract the package and version
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("version parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-
-	assetType, err := req.RequireString("asset_type")
+	version, err := s.catalogVersion(ref, true)
 	if err != nil {
-		return mcp.NewToolResultError("asset_type parameter is required"), err
-	}
-
-	// Validate asset type
-	validAssetTypes := map[string]bool{
-		"docs":         true,
-		"icon":         true,
-		"readme":       true,
-		"releaseNotes": true,
-		"sbom":         true,
-	}
-	if !validAssetTypes[assetType] {
-		return mcp.NewToolResultError(fmt.Sprintf("Invalid asset_type: %s. Must be one of: docs, icon, readme, releaseNotes, sbom", assetType)), nil
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
 	// Get package assets
-	assets, err := s.client.GetPackageAssets(ctx, account, repository, version, assetType)
+	assets, err := s.catalog.GetPackageAssets(ctx, ref.Account, ref.Repository, version, a.AssetType)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get package assets: %v", err)), nil
 	}
+	s.rememberPackage(ref.Account, ref.Repository, version)
 
-	return mcp.NewToolResultText(formatPackageAssets(assets, assetType)), nil
+	return s.structuredResult(req, assets, packageAssetsDocument(assets, a.AssetType))
 }
 
 // handleGetRepositories handles the get_repositories tool.
-func (s *Server) handleGetRepositories(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract required parameters
-	account, err := req.RequireString("account")
+func (s *Server) handleGetRepositories(ctx context.Context, req mcp.CallToolRequest, a getRepositoriesArgs) (*mcp.CallToolResult, error) {
+	account, err := s.repositoryAccount(a.Account, a.Package)
 	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
-	// Extract optional parameters
-	filter := req.GetString("filter", "")
-	size := req.GetInt("size", 20)
-	page := req.GetInt("page", 0)
-	useV1 := req.GetBool("use_v1", false)
-
 	// Prepare repository parameters
 	params := marketplace.RepositoryParams{
-		Filter: filter,
-		Size:   size,
-		Page:   page,
-		UseV1:  useV1,
+		Filter: a.Filter,
+		Size:   a.Size,
+		Page:   a.Page,
+		UseV1:  a.UseV1,
 	}
 
 	// Get repositories
-	repos, err := s.client.GetRepositories(ctx, account, params)
+	repos, err := s.catalog.GetRepositories(ctx, account, params)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
 	}
 
-	return mcp.NewToolResultText(formatRepositories(repos)), nil
+	return s.structuredResult(req, repos, repositoriesDocument(repos))
 }
 
-// handleGetPackagesAccountRepositoryVersionResources handles the get_repositories tool.
-func (s *Server) handleGetPackagesAccountRepositoryVersionResources(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract required parameters
-	account, err := req.RequireString("account")
+// handleGetPrivateRepositories handles the get_private_repositories tool.
+// Catalogs that cannot filter by visibility return an error rather than
+// listing public repositories as private.
+func (s *Server) handleGetPrivateRepositories(ctx context.Context, req mcp.CallToolRequest, a getPrivateRepositoriesArgs) (*mcp.CallToolResult, error) {
+	account, err := s.repositoryAccount(a.Account, a.Package)
 	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	repositoryName, err := req.RequireString("repository_name")
-	if err != nil {
-		return mcp.NewToolResultError("repository_name parameter is required"), err
+
+	params := marketplace.RepositoryParams{
+		Filter: "public = false",
+		Size:   a.Size,
+		Page:   a.Page,
 	}
-	version, err := req.RequireString("version")
-	if err != nil {
-		return mcp.NewToolResultError("version parameter is required"), err
+	repos, err := s.catalog.GetRepositories(ctx, account, params)
+	if errors.Is(err, catalog.ErrFilterUnsupported) {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot list private repositories of account %q: its catalog does not record repository visibility", account)), err
 	}
-
-	// Get repositories
-	repos, err := s.client.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repositoryName, version)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get private repositories: %v", err)), err
 	}
 
-	b, err := json.Marshal(repos)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not marshal response")
-	}
+	return s.structuredResult(req, repos, repositoriesDocument(repos))
+}
 
-	return mcp.NewToolResultText(string(b)), nil
+// repositoryAccount returns the account whose repositories to list, given
+// directly or by a package reference.
+func (s *Server) repositoryAccount(account, pkg string) (string, error) {
+	if pkg != "" && account == "" {
+		ref, err := marketplace.ParseReference(pkg)
+		if err == nil {
+			err = s.checkRegistry(ref)
+		}
+		if err != nil {
+			return "", err
+		}
+		account = ref.Account
+	}
+	if account == "" {
+		return "", errors.New("account parameter is required")
+	}
+	return account, nil
 }
 
 // handleGetPackagesAccountRepositoryVersionResources handles the get_repositories tool.
-func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *Server) handleGetPackagesAccountRepositoryVersionResources(ctx context.Context, req mcp.CallToolRequest, a resourceArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		res := pkg.Resources(local.Account, pkg.Name())
+		return s.structuredResult(req, res, packageResourcesDocument(res))
+	}
+
 	// Extract required parameters
-	account, err := req.RequireString("account")
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	repositoryName, err := req.RequireString("repository_name")
+	version, err := s.catalogVersion(ref, true)
 	if err != nil {
-		return mcp.NewToolResultError("repository_name parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	version, err := req.RequireString("version")
+
+	// Get repositories
+	repos, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, ref.Account, ref.Repository, version)
 	if err != nil {
-		return mcp.NewToolResultError("version parameter is required"), err
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
 	}
-	resourceGroup, err := req.RequireString("resource_group")
-	if err != nil {
-		return mcp.NewToolResultError("resource_group parameter is required"), err
+	s.rememberPackage(ref.Account, ref.Repository, version)
+
+	return s.structuredResult(req, repos, packageResourcesDocument(repos))
+}
+
+// handleGetPackagesAccountRepositoryVersionResources handles the get_repositories tool.
+func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, req mcp.CallToolRequest, a getCompositionArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		comp, found := pkg.Composition(a.CompositionName)
+		if !found {
+			return mcp.NewToolResultError(fmt.Sprintf("Composition %s not found in local package %s", a.CompositionName, pkg.Name())), nil
+		}
+		return s.structuredResult(req, Definition(comp), definitionDocument(comp))
 	}
-	resourceKind, err := req.RequireString("resource_kind")
+
+	// Extract required parameters
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("resource_kind parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	compositionName, err := req.RequireString("composition_name")
+	version, err := s.catalogVersion(ref, true)
 	if err != nil {
-		return mcp.NewToolResultError("composition_name parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
 	// Get repositories
-	raw, err := s.client.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, account, repositoryName, version, resourceGroup, resourceKind, compositionName)
+	raw, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, ref.Account, ref.Repository, version, a.ResourceGroup, a.ResourceKind, a.CompositionName)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
 	}
+	s.rememberPackage(ref.Account, ref.Repository, version)
 
-	return mcp.NewToolResultText(raw), nil
+	return s.definitionResult(req, raw)
 }
 
 // handleGetPackagesAccountRepositoryVersionResourcesGroupKind handles the get_repositories tool.
-func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, req mcp.CallToolRequest, a getGroupKindArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		def, found := pkg.Definition(a.ResourceGroup, a.ResourceKind)
+		if !found {
+			return mcp.NewToolResultError(fmt.Sprintf("Kind %s.%s not found in local package %s", a.ResourceKind, a.ResourceGroup, pkg.Name())), nil
+		}
+		return s.structuredResult(req, Definition(def), definitionDocument(def))
+	}
+
 	// Extract required parameters
-	account, err := req.RequireString("account")
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	repositoryName, err := req.RequireString("repository_name")
+	version, err := s.catalogVersion(ref, true)
 	if err != nil {
-		return mcp.NewToolResultError("repository_name parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	version, err := req.RequireString("version")
+
+	// Get repositories
+	raw, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, ref.Account, ref.Repository, version, a.ResourceGroup, a.ResourceKind)
 	if err != nil {
-		return mcp.NewToolResultError("version parameter is required"), err
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
+	}
+	s.rememberPackage(ref.Account, ref.Repository, version)
+
+	return s.definitionResult(req, raw)
+}
+
+// handleGetPackagesAccountRepositoryVersionResourcesGroupKind handles the get_repositories tool.
+func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, req mcp.CallToolRequest, a getGroupKindArgs) (*mcp.CallToolResult, error) {
+	if pkg, ok, err := s.localPackage(a.PackagePath); ok {
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read local package: %v", err)), err
+		}
+		exs := &marketplace.Examples{Examples: pkg.ExamplesFor(a.ResourceGroup, a.ResourceKind)}
+		return s.structuredResult(req, exs, examplesDocument(exs, a.ResourceGroup, a.ResourceKind))
 	}
-	resourceGroup, err := req.RequireString("resource_group")
+
+	// Extract required parameters
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("resource_group parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	resourceKind, err := req.RequireString("resource_kind")
+	version, err := s.catalogVersion(ref, true)
 	if err != nil {
-		return mcp.NewToolResultError("resource_kind parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
 	// Get repositories
-	raw, err := s.client.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+	exs, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, ref.Account, ref.Repository, version, a.ResourceGroup, a.ResourceKind)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
 	}
+	s.rememberPackage(ref.Account, ref.Repository, version)
 
-	return mcp.NewToolResultText(raw), nil
+	return s.structuredResult(req, exs, examplesDocument(exs, a.ResourceGroup, a.ResourceKind))
 }
 
-// handleGetPackagesAccountRepositoryVersionResourcesGroupKind handles the get_repositories tool.
-func (s *Server) handleGetPackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract required parameters
-	account, err := req.RequireString("account")
+// defaultKindContextBytes is the budget the get_kind_context tool fits its
+// result into when the call does not set one, about 4000 tokens.
+const defaultKindContextBytes = 4000 * budget.BytesPerToken
+
+// handleGetKindContext handles the get_kind_context tool.
+func (s *Server) handleGetKindContext(ctx context.Context, req mcp.CallToolRequest, a getKindContextArgs) (*mcp.CallToolResult, error) {
+	ref, err := a.ref()
 	if err != nil {
-		return mcp.NewToolResultError("account parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	repositoryName, err := req.RequireString("repository_name")
+	version, err := s.catalogVersion(ref, false)
 	if err != nil {
-		return mcp.NewToolResultError("repository_name parameter is required"), err
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	version, err := req.RequireString("version")
-	if err != nil {
-		return mcp.NewToolResultError("version parameter is required"), err
+	if version == "" {
+		md, err := s.catalog.GetPackageMetadata(ctx, ref.Account, ref.Repository, "", false)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get package metadata: %v", err)), err
+		}
+		version = md.LatestVersion
 	}
-	resourceGroup, err := req.RequireString("resource_group")
-	if err != nil {
-		return mcp.NewToolResultError("resource_group parameter is required"), err
+
+	// Fit the pack into the result's budget, so it is trimmed rather than
+	// truncated.
+	var b budgetArgs
+	if err := args.Decode(req.GetArguments(), &b); err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	maxBytes := budget.Bytes(b.MaxTokens, b.MaxBytes)
+	if maxBytes == 0 {
+		maxBytes = defaultKindContextBytes
 	}
-	resourceKind, err := req.RequireString("resource_kind")
+
+	pack, err := contextpack.Build(ctx, s.catalog, ref.Account, ref.Repository, version, a.ResourceGroup, a.ResourceKind, maxBytes)
 	if err != nil {
-		return mcp.NewToolResultError("resource_kind parameter is required"), err
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to get kind context: %v", err)), err
 	}
+	s.rememberPackage(ref.Account, ref.Repository, version)
 
-	// Get repositories
-	exs, err := s.client.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+	return s.structuredResult(req, pack, kindContextDocument(pack))
+}
+
+// handleDiffPackageVersions handles the diff_package_versions tool.
+func (s *Server) handleDiffPackageVersions(ctx context.Context, req mcp.CallToolRequest, a diffPackageVersionsArgs) (*mcp.CallToolResult, error) {
+	ref, err := a.ref("")
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get repositories: %v", err)), err
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	if ref.Version != "" || ref.Digest != "" {
+		err := errors.New("package reference must not include a version; use from_version and to_version")
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
-	b, err := json.Marshal(exs)
+	report, err := diff.Compare(ctx, s.catalog, ref.Account, ref.Repository, a.FromVersion, a.ToVersion)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal response")
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to compare package versions: %v", err)), err
 	}
 
-	return mcp.NewToolResultText(string(b)), nil
+	return s.structuredResult(req, report, diffReportDocument(report, a.BreakingOnly))
 }
 
-// handleReloadAuth handles the reload_auth tool.
-func (s *Server) handleReloadAuth(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Try to reload authentication token from UP CLI config
-	token, err := s.authManager.GetCurrentToken()
-	if err == nil {
-		s.client.SetToken(token.AccessToken)
+// handlePlanUpgrade handles the plan_upgrade tool.
+func (s *Server) handlePlanUpgrade(ctx context.Context, req mcp.CallToolRequest, a planUpgradeArgs) (*mcp.CallToolResult, error) {
+	ref, err := a.ref("")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
 
-		// Also reload server URL
-		if serverURL, err := s.authManager.GetCurrentServerURL(); err == nil {
-			s.client.SetBaseURL(serverURL)
-			return mcp.NewToolResultText(fmt.Sprintf("Successfully reloaded authentication and server configuration.\nServer URL: %s\nAuthentication: Loaded from UP CLI profile", serverURL)), nil
-		}
-		return mcp.NewToolResultText("Successfully reloaded authentication token from UP CLI profile, but failed to reload server URL."), nil
+	current := a.CurrentVersion
+	if current == "" {
+		current = ref.Version
+	}
+	if current == "" {
+		err := errors.New("current_version parameter is required, or a package reference with a :<version> tag")
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	return mcp.NewToolResultError(fmt.Sprintf("Failed to reload authentication from UP CLI: %v. Please ensure you are logged in with 'up login'.", err)), nil
-}
 
-// formatSearchResults formats search results for display.
-func formatSearchResults(result *marketplace.SearchResponse) string {
-	if result == nil {
-		return "No search results"
+	plan, err := upgrade.Plan(ctx, s.catalog, ref.Account, ref.Repository, current, a.TargetVersion)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to plan upgrade: %v", err)), err
 	}
 
-	output := fmt.Sprintf("Search Results (Total: %d)\n", result.Total)
-	output += "=====================================\n\n"
+	return s.structuredResult(req, plan, upgradePlanDocument(plan))
+}
 
-	for i, pkg := range result.Packages {
-		output += fmt.Sprintf("%d. %s/%s\n", i+1, pkg.Account, pkg.Repository)
-		if pkg.Name != "" {
-			output += fmt.Sprintf("   Name: %s\n", pkg.Name)
+// handleRecommendProviders handles the recommend_providers tool.
+func (s *Server) handleRecommendProviders(ctx context.Context, req mcp.CallToolRequest, a recommendProvidersArgs) (*mcp.CallToolResult, error) {
+	kinds := make([]recommend.GroupKind, 0, len(a.Resources))
+	for _, r := range a.Resources {
+		gk, err := recommend.ParseGroupKind(r.APIVersion, r.Kind)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), err
 		}
-		if pkg.Description != "" {
-			output += fmt.Sprintf("   Description: %s\n", pkg.Description)
+		kinds = append(kinds, gk)
+	}
+	if a.Manifests != "" {
+		gks, err := recommend.ParseManifests(a.Manifests)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), err
 		}
-		if pkg.Version != "" {
-			output += fmt.Sprintf("   Version: %s\n", pkg.Version)
+		kinds = append(kinds, gks...)
+	}
+	if len(kinds) == 0 {
+		err := errors.New("resources or manifests parameter is required")
+		return mcp.NewToolResultError(err.Error()), err
+	}
+
+	result, err := recommend.Recommend(ctx, s.catalog, kinds)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to recommend providers: %v", err)), err
+	}
+
+	return s.structuredResult(req, result, recommendationDocument(result))
+}
+
+// handleFindKind handles the find_kind tool.
+func (s *Server) handleFindKind(ctx context.Context, req mcp.CallToolRequest, a findKindArgs) (*mcp.CallToolResult, error) {
+	building := s.buildKindIndex(ctx, a.Refresh)
+
+	result := KindMatches{Query: a.Query, Matches: s.kinds.Find(a.Query, a.Limit), Building: building, Failed: s.kinds.Failed()}
+	return s.structuredResult(req, result, kindMatchesDocument(result))
+}
+
+// buildKindIndex starts building the kind index in the background unless it
+// is complete, or a refresh is requested, and returns true while it is being
+// built. Packages put into the index by the background sync do not make it
+// complete, so they do not prevent a build.
+func (s *Server) buildKindIndex(ctx context.Context, refresh bool) bool {
+	s.kindsMu.Lock()
+	defer s.kindsMu.Unlock()
+	if s.kindsBuilding {
+		return true
+	}
+	if s.kinds.Complete() && !refresh {
+		return false
+	}
+	s.kindsBuilding = true
+
+	// The build outlives the call that started it.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		stats, err := index.Update(ctx, s.catalog, s.kinds, index.UpdateOptions{})
+		if err != nil {
+			log.Printf("Warning: Failed to build kind index: %v", err)
+		} else {
+			log.Printf("Updated kind index: %d packages, %d updated, %d failed", stats.Packages, stats.Updated, stats.Failed)
 		}
-		if pkg.Type != "" {
-			output += fmt.Sprintf("   Type: %s\n", pkg.Type)
+		s.kindsMu.Lock()
+		defer s.kindsMu.Unlock()
+		s.kindsBuilding = false
+	}()
+	return true
+}
+
+// handleSearchFields handles the search_fields tool.
+func (s *Server) handleSearchFields(ctx context.Context, req mcp.CallToolRequest, a searchFieldsArgs) (*mcp.CallToolResult, error) {
+	o := fields.SearchOptions{Kind: a.Kind, Limit: a.Limit}
+	if a.Package != "" || a.Account != "" {
+		ref, err := packageRef(a.Package, a.Account, a.Repository, a.Version)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), err
 		}
-		if pkg.Tier != "" {
-			output += fmt.Sprintf("   Tier: %s\n", pkg.Tier)
+		version, err := s.catalogVersion(ref, false)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), err
 		}
-		if len(pkg.Tags) > 0 {
-			output += fmt.Sprintf("   Tags: %v\n", pkg.Tags)
+		if err := s.updateFieldIndex(ctx, ref.Account, ref.Repository, version); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to index fields of %s/%s: %v", ref.Account, ref.Repository, err)), err
 		}
-		output += "\n"
+		o.Account, o.Repository = ref.Account, ref.Repository
 	}
 
-	return output
+	if s.fields.Len() == 0 {
+		return mcp.NewToolResultError("The field index is empty. Give a package to index and search its fields, or configure the background sync."), nil
+	}
+	hits := s.fields.Search(a.Query, o)
+	return s.structuredResult(req, FieldHits{Query: a.Query, Hits: hits}, fieldHitsDocument(a.Query, hits))
 }
 
-// formatPackageMetadata formats package metadata for display.
-func formatPackageMetadata(metadata *marketplace.PackageMetadata) string {
-	if metadata == nil {
-		return "No package metadata"
+// updateFieldIndex indexes the fields of a package version, or of its latest
+// version if none is given, and saves the field index.
+func (s *Server) updateFieldIndex(ctx context.Context, account, repository, version string) error {
+	if version == "" {
+		md, err := s.catalog.GetPackageMetadata(ctx, account, repository, "", false)
+		if err != nil {
+			return err
+		}
+		version = md.LatestVersion
 	}
 
-	output := fmt.Sprintf("Package: %s/%s\n", metadata.Account, metadata.Repository)
-	output += "=====================================\n\n"
-
-	if metadata.Name != "" {
-		output += fmt.Sprintf("Name: %s\n", metadata.Name)
-	}
-	if metadata.Description != "" {
-		output += fmt.Sprintf("Description: %s\n", metadata.Description)
+	updated, err := fields.Update(ctx, s.catalog, s.fields, account, repository, version)
+	if err != nil || !updated {
+		return err
 	}
-	if metadata.Version != "" {
-		output += fmt.Sprintf("Version: %s\n", metadata.Version)
+	if err := s.fields.Save(); err != nil {
+		log.Printf("Warning: Failed to save field index: %v", err)
 	}
-	if metadata.Type != "" {
-		output += fmt.Sprintf("Type: %s\n", metadata.Type)
+	return nil
+}
+
+// handleDiffSnapshots handles the diff_snapshots tool.
+func (s *Server) handleDiffSnapshots(_ context.Context, req mcp.CallToolRequest, a diffSnapshotsArgs) (*mcp.CallToolResult, error) {
+	from, to, err := snapshotFiles(s.snapshotDir, a.From, a.To)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	if metadata.Tier != "" {
-		output += fmt.Sprintf("Tier: %s\n", metadata.Tier)
+	fm, err := snapshot.OpenManifest(from)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	if len(metadata.Tags) > 0 {
-		output += fmt.Sprintf("Tags: %v\n", metadata.Tags)
+	tm, err := snapshot.OpenManifest(to)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
 	}
-	if !metadata.CreatedAt.IsZero() {
-		output += fmt.Sprintf("Created: %s\n", metadata.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	r := snapshot.Diff(fm, tm)
+	return s.structuredResult(req, r, snapshotDiffDocument(r, a.Top))
+}
+
+// snapshotFiles returns the paths of the snapshots to compare. Snapshots are
+// named by their file name in the snapshot directory; by default the two most
+// recently modified snapshots are compared.
+func snapshotFiles(dir, from, to string) (string, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to list snapshots")
 	}
-	if !metadata.UpdatedAt.IsZero() {
-		output += fmt.Sprintf("Updated: %s\n", metadata.UpdatedAt.Format("2006-01-02 15:04:05"))
+	type file struct {
+		name string
+		mod  time.Time
 	}
-	if metadata.Downloads > 0 {
-		output += fmt.Sprintf("Downloads: %d\n", metadata.Downloads)
+	var files []file
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, file{name: e.Name(), mod: info.ModTime()})
 	}
+	slices.SortFunc(files, func(a, b file) int { return b.mod.Compare(a.mod) })
 
-	// Add CRDs information if available
-	if len(metadata.CRDs) > 0 {
-		output += "\nCustom Resource Definitions (CRDs):\n"
-		output += "-----------------------------------\n"
-		for i, crd := range metadata.CRDs {
-			output += fmt.Sprintf("%d. %s\n", i+1, crd.Name)
-			if crd.Group != "" {
-				output += fmt.Sprintf("   Group: %s\n", crd.Group)
-			}
-			if crd.Version != "" {
-				output += fmt.Sprintf("   Version: %s\n", crd.Version)
-			}
-			if crd.Kind != "" {
-				output += fmt.Sprintf("   Kind: %s\n", crd.Kind)
-			}
-			if crd.Description != "" {
-				output += fmt.Sprintf("   Description: %s\n", crd.Description)
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	if to == "" && len(names) > 0 {
+		to = names[0]
+	}
+	if from == "" {
+		for _, n := range names {
+			if n != to {
+				from = n
+				break
 			}
-			output += "\n"
 		}
 	}
+	for _, n := range []string{from, to} {
+		if n == "" {
+			return "", "", errors.Errorf("two snapshots are needed to compare, but the snapshot directory has %d", len(names))
+		}
+		if !slices.Contains(names, n) {
+			return "", "", errors.Errorf("snapshot %q not found; available snapshots: %s", n, strings.Join(names, ", "))
+		}
+	}
+	return filepath.Join(dir, from), filepath.Join(dir, to), nil
+}
 
-	return output
+// handleGetSyncStatus handles the get_sync_status tool.
+func (s *Server) handleGetSyncStatus(_ context.Context, req mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, error) {
+	status, _ := s.SyncStatus()
+	return s.structuredResult(req, status, syncStatusDocument(status))
 }
 
-// formatPackageAssets formats package assets for display.
-func formatPackageAssets(assets *marketplace.AssetResponse, assetType string) string {
-	if assets == nil {
-		return fmt.Sprintf("No %s assets found", assetType)
+// handleVerifyPackage handles the verify_package tool.
+func (s *Server) handleVerifyPackage(ctx context.Context, req mcp.CallToolRequest, a verifyPackageArgs) (*mcp.CallToolResult, error) {
+	ref, err := a.ref()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	// A digest is verified as-is, while a version is resolved to one.
+	reference := ref.Digest
+	if reference == "" {
+		reference = ref.Version
+	}
+	if reference == "" {
+		err := errors.New("version parameter is required, or a package reference with a :<version> tag or @<digest>")
+		return mcp.NewToolResultError(err.Error()), err
 	}
 
-	output := fmt.Sprintf("Package Assets (%s):\n", assetType)
-	output += "=====================================\n\n"
+	if ref.Account == local.Account {
+		return mcp.NewToolResultError("local packages are not published to a registry and cannot be verified"), nil
+	}
+	if err := s.checkRegistry(ref); err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
 
-	switch assetType {
-	case "docs", "readme", "releaseNotes":
-		switch {
-		case assets.Content != "":
-			output += assets.Content
-		case assets.URL != "":
-			output += fmt.Sprintf("Asset URL: %s", assets.URL)
-		default:
-			output += "No content available"
-		}
-	case "icon":
-		switch {
-		case assets.URL != "":
-			output += fmt.Sprintf("Icon URL: %s", assets.URL)
-		default:
-			output += "Icon asset retrieved (binary data)"
-		}
-	case "sbom":
-		switch {
-		case assets.Content != "":
-			output += assets.Content
-		case assets.URL != "":
-			output += fmt.Sprintf("SBOM URL: %s", assets.URL)
-		default:
-			output += "No SBOM content available"
-		}
-	default:
-		switch {
-		case assets.Content != "":
-			output += assets.Content
-		case assets.URL != "":
-			output += fmt.Sprintf("Asset URL: %s", assets.URL)
-		default:
-			output += "No asset content available"
-		}
+	res, err := s.verifier.Verify(ctx, s.packageRegistry(ref.Account), ref.Account+"/"+ref.Repository, reference)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to verify package: %v", err)), err
 	}
 
-	return output
-}
+	// The catalog's digest is only informative; a package it does not know
+	// can still be verified.
+	if ref.Version != "" {
+		if r, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, ref.Account, ref.Repository, ref.Version); err == nil {
+			res.CompareDigest(r.PkgDigest)
+		}
+	}
 
-// formatRepositories formats repositories for display.
-func formatRepositories(repos *marketplace.RepositoryResponse) string {
-	if repos == nil {
-		return "No repositories found"
+	policy := verify.Policy{
+		RequireSignature:  a.RequireSignature,
+		RequireProvenance: a.RequireProvenance,
+		TrustedBuilders:   a.TrustedBuilders,
 	}
+	res.Violations = policy.Check(res)
 
-	output := fmt.Sprintf("Repositories (Count: %d)\n", repos.Count)
-	output += "=====================================\n\n"
+	return s.structuredResult(req, res, verificationDocument(res))
+}
 
-	for i, repo := range repos.Repositories {
-		output += fmt.Sprintf("%d. %s\n", i+1, repo.Name)
-		if repo.Description != "" {
-			output += fmt.Sprintf("   Description: %s\n", repo.Description)
-		}
-		if repo.Type != "" {
-			output += fmt.Sprintf("   Type: %s\n", repo.Type)
-		}
-		if !repo.CreatedAt.IsZero() {
-			output += fmt.Sprintf("   Created: %s\n", repo.CreatedAt.Format("2006-01-02 15:04:05"))
-		}
-		if !repo.UpdatedAt.IsZero() {
-			output += fmt.Sprintf("   Updated: %s\n", repo.UpdatedAt.Format("2006-01-02 15:04:05"))
-		}
-		if repo.PackageCount > 0 {
-			output += fmt.Sprintf("   Package Count: %d\n", repo.PackageCount)
+// handleReloadAuth handles the reload_auth tool.
+func (s *Server) handleReloadAuth(_ context.Context, req mcp.CallToolRequest, _ reloadAuthArgs) (*mcp.CallToolResult, error) {
+	// Try to reload authentication token from UP CLI config
+	token, err := s.authManager.GetCurrentToken()
+	if err == nil {
+		s.client.SetToken(token.AccessToken)
+		s.RefreshTools()
+
+		// Also reload server URL
+		serverURL, err := s.authManager.GetCurrentServerURL()
+		if err != nil {
+			status := AuthStatus{Loaded: true, Warning: fmt.Sprintf("token loaded but server URL not reloaded: %v", err)}
+			return s.structuredResult(req, status, authStatusDocument(status))
 		}
-		output += "\n"
+		s.client.SetBaseURL(serverURL)
+		status := AuthStatus{ServerURL: serverURL, Loaded: true}
+		return s.structuredResult(req, status, authStatusDocument(status))
 	}
-
-	return output
+	// Without a profile to load the server is no longer authenticated, but
+	// a profile that could not be read may still hold the current session.
+	if errors.Is(err, auth.ErrNoProfile) {
+		s.client.SetToken("")
+		s.RefreshTools()
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("Failed to reload authentication from UP CLI: %v. Please ensure you are logged in with 'up login'.", err)), nil
 }
@@ -0,0 +1,37 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/upbound/marketplace-mcp-server/internal/xpkg"
+)
+
+// localPackage returns the local package at the path given by the
+// package_path argument. The returned bool is false if no path is given.
+func (s *Server) localPackage(path string) (*xpkg.Package, bool, error) {
+	if path == "" {
+		return nil, false, nil
+	}
+	resolved, ok := s.localPackages.Allowed(path)
+	if !ok {
+		return nil, true, fmt.Errorf("package path %s is not within a configured local package path", path)
+	}
+	pkg, err := s.localPackages.Load(resolved)
+	return pkg, true, err
+}
@@ -0,0 +1,263 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+	"github.com/upbound/marketplace-mcp-server/internal/upgrade"
+)
+
+// clouds are the values the cloud prompt argument completes to.
+var clouds = []string{"aws", "azure", "gcp"}
+
+// registerPrompts registers prompts for common Crossplane workflows. Each
+// prompt embeds live catalog data in its messages.
+func (s *Server) registerPrompts() {
+	s.mcpServer.AddPrompt(mcp.NewPrompt("find_provider_for_service",
+		mcp.WithPromptDescription("Find the provider that manages a cloud service, and the kinds it offers for it"),
+		mcp.WithArgument("service", mcp.RequiredArgument(), mcp.ArgumentDescription("Cloud service to manage, e.g. S3 or Cloud SQL")),
+		mcp.WithArgument("cloud", mcp.ArgumentDescription("Cloud the service belongs to: aws, azure or gcp")),
+	), s.handleFindProviderForService)
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt("build_composition_for_xrd",
+		mcp.WithPromptDescription("Write a composition for a composite resource definition"),
+		mcp.WithArgument("package", mcp.RequiredArgument(), mcp.ArgumentDescription("Package that defines the XRD, e.g. upbound/configuration-aws-network:v0.20.0")),
+		mcp.WithArgument("kind", mcp.RequiredArgument(), mcp.ArgumentDescription("Kind of the XRD, e.g. XNetwork")),
+		mcp.WithArgument("provider", mcp.ArgumentDescription("Provider whose managed resources the composition should use, e.g. upbound/provider-aws-ec2")),
+	), s.handleBuildCompositionForXRD)
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt("review_package_upgrade",
+		mcp.WithPromptDescription("Review the risk of upgrading a package, including breaking schema changes and dependency changes"),
+		mcp.WithArgument("package", mcp.RequiredArgument(), mcp.ArgumentDescription("Package to upgrade, e.g. upbound/provider-aws-s3")),
+		mcp.WithArgument("current_version", mcp.RequiredArgument(), mcp.ArgumentDescription("Currently installed version")),
+		mcp.WithArgument("target_version", mcp.ArgumentDescription("Version to upgrade to (defaults to the latest)")),
+	), s.handleReviewPackageUpgrade)
+
+	s.mcpServer.AddPrompt(mcp.NewPrompt("explain_managed_resource",
+		mcp.WithPromptDescription("Explain a managed resource, its required fields and how to use it"),
+		mcp.WithArgument("package", mcp.RequiredArgument(), mcp.ArgumentDescription("Provider that defines the kind, e.g. upbound/provider-aws-s3")),
+		mcp.WithArgument("kind", mcp.RequiredArgument(), mcp.ArgumentDescription("Kind of the managed resource, e.g. Bucket")),
+	), s.handleExplainManagedResource)
+}
+
+// handleFindProviderForService renders the find_provider_for_service prompt.
+func (s *Server) handleFindProviderForService(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	service := req.Params.Arguments["service"]
+	if service == "" {
+		return nil, fmt.Errorf("service argument is required")
+	}
+	cloud := req.Params.Arguments["cloud"]
+
+	query := strings.TrimSpace(cloud + " " + service)
+	result, err := s.catalog.SearchPackages(ctx, marketplace.SearchParams{Query: query, PackageType: "provider", Size: 10})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search providers: %w", err)
+	}
+
+	text := fmt.Sprintf("I want to manage %s", service)
+	if cloud != "" {
+		text += " on " + strings.ToUpper(cloud)
+	}
+	text += ` with Crossplane. Using the providers below, which the marketplace returned when searching providers for "` + query + `", recommend the provider to install and explain why. ` +
+		"Use get_package_version_resources to list the kinds the recommended provider offers for the service, " +
+		"and finish with the Provider manifest to install it, pinned to its latest version.\n\n" +
+		searchResultsDocument(result).Markdown()
+
+	return mcp.NewGetPromptResult("Find the provider for "+service, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	}), nil
+}
+
+// handleBuildCompositionForXRD renders the build_composition_for_xrd prompt.
+func (s *Server) handleBuildCompositionForXRD(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	ref, err := s.promptPackage(ctx, req.Params.Arguments["package"])
+	if err != nil {
+		return nil, err
+	}
+	kind := req.Params.Arguments["kind"]
+	if kind == "" {
+		return nil, fmt.Errorf("kind argument is required")
+	}
+
+	res, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, ref.Account, ref.Repository, ref.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package resources: %w", err)
+	}
+	i := slices.IndexFunc(res.XRDs, func(x marketplace.XRDMeta) bool { return strings.EqualFold(x.Kind, kind) })
+	if i < 0 {
+		return nil, fmt.Errorf("package %s does not define an XRD of kind %s", ref, kind)
+	}
+	xrd := res.XRDs[i]
+	def, err := s.resourceContents(ctx, resourceURIOf(ref, "crds", xrd.Group, xrd.Kind))
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Write a Crossplane Composition for the %s.%s composite resource defined by %s. ", xrd.Kind, xrd.Group, ref) +
+		"Use a Pipeline mode composition with function-patch-and-transform, patch every field of the XRD's spec onto the composed resources, " +
+		"and write the composed resources' connection details and status back to the composite resource. The XRD is attached below."
+	var existing []string
+	for _, c := range res.Compositions {
+		if c.XrdKind == xrd.Kind {
+			existing = append(existing, fmt.Sprintf("- %s (%d resources)", c.Name, c.ResourceCount))
+		}
+	}
+	if len(existing) > 0 {
+		text += "\n\nThe package already contains these compositions for the XRD, which you can use as a reference with get_package_version_composition_resources:\n" + strings.Join(existing, "\n")
+	}
+	messages := []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(def)),
+	}
+
+	if p := req.Params.Arguments["provider"]; p != "" {
+		provider, err := s.promptPackage(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		pres, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, provider.Account, provider.Repository, provider.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider resources: %w", err)
+		}
+		messages = append(messages, mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(
+			fmt.Sprintf("Compose managed resources of %s, which offers these kinds. Read the schema of a kind with get_package_version_groupkind_resources before using it.\n\n%s", provider, render.NewDocument("").List(kindList(pres.CRDs)...).Markdown()),
+		)))
+	}
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("Build a composition for %s.%s", xrd.Kind, xrd.Group), messages), nil
+}
+
+// handleReviewPackageUpgrade renders the review_package_upgrade prompt.
+func (s *Server) handleReviewPackageUpgrade(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	ref, err := marketplace.ParseReference(req.Params.Arguments["package"])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRegistry(ref); err != nil {
+		return nil, err
+	}
+	current := req.Params.Arguments["current_version"]
+	if current == "" {
+		current = ref.Version
+	}
+	if current == "" {
+		return nil, fmt.Errorf("current_version argument is required")
+	}
+
+	plan, err := upgrade.Plan(ctx, s.catalog, ref.Account, ref.Repository, current, req.Params.Arguments["target_version"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan upgrade: %w", err)
+	}
+
+	text := fmt.Sprintf("Review the upgrade of %s/%s from %s to %s. ", ref.Account, ref.Repository, plan.From, plan.To) +
+		"Using the upgrade plan below, summarise the risk of the upgrade, list every breaking change and what a user must change in their manifests because of it, " +
+		"and recommend whether to upgrade directly or through intermediate versions.\n\n" +
+		upgradePlanDocument(plan).Markdown()
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("Review the upgrade of %s/%s to %s", ref.Account, ref.Repository, plan.To), []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	}), nil
+}
+
+// handleExplainManagedResource renders the explain_managed_resource prompt.
+func (s *Server) handleExplainManagedResource(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	ref, err := s.promptPackage(ctx, req.Params.Arguments["package"])
+	if err != nil {
+		return nil, err
+	}
+	kind := req.Params.Arguments["kind"]
+	if kind == "" {
+		return nil, fmt.Errorf("kind argument is required")
+	}
+
+	res, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, ref.Account, ref.Repository, ref.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package resources: %w", err)
+	}
+	i := slices.IndexFunc(res.CRDs, func(c marketplace.CRDMeta) bool { return strings.EqualFold(c.Kind, kind) })
+	if i < 0 {
+		return nil, fmt.Errorf("package %s does not define a kind %s", ref, kind)
+	}
+	crd := res.CRDs[i]
+	def, err := s.resourceContents(ctx, resourceURIOf(ref, "crds", crd.Group, crd.Kind))
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Explain the %s.%s managed resource of %s: what it manages, its required fields, ", crd.Kind, crd.Group, ref) +
+		"the fields most users set, how it references other resources, and what it publishes as connection details. " +
+		"Finish with a minimal working manifest. The CRD and the package's examples of the kind are attached below."
+	messages := []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(def)),
+	}
+	if exs, err := s.resourceContents(ctx, resourceURIOf(ref, "examples", crd.Group, crd.Kind)); err == nil {
+		messages = append(messages, mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(exs)))
+	}
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("Explain %s.%s", crd.Kind, crd.Group), messages), nil
+}
+
+// promptPackage parses a package argument, resolving its version to the
+// latest version if it has none.
+func (s *Server) promptPackage(ctx context.Context, arg string) (marketplace.Reference, error) {
+	if arg == "" {
+		return marketplace.Reference{}, fmt.Errorf("package argument is required")
+	}
+	ref, err := marketplace.ParseReference(arg)
+	if err != nil {
+		return ref, err
+	}
+	version, err := s.catalogVersion(ref, false)
+	if err != nil {
+		return ref, err
+	}
+	if version == "" || version == "latest" {
+		md, err := s.catalog.GetPackageMetadata(ctx, ref.Account, ref.Repository, "", false)
+		if err != nil {
+			return ref, fmt.Errorf("failed to get latest version of %s: %w", ref, err)
+		}
+		version = md.LatestVersion
+	}
+	ref.Version = version
+	return ref, nil
+}
+
+// resourceURIOf returns the URI of a resource within a package version.
+func resourceURIOf(ref marketplace.Reference, section string, args ...string) string {
+	return strings.Join(append([]string{packageScheme + ref.Account, ref.Repository, ref.Version, section}, args...), "/")
+}
+
+// resourceContents reads a resource within a package version, so that it can
+// be embedded in a prompt.
+func (s *Server) resourceContents(ctx context.Context, uri string) (mcp.TextResourceContents, error) {
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	contents, err := s.handleReadVersionResource(ctx, req)
+	if err != nil {
+		return mcp.TextResourceContents{}, err
+	}
+	return contents[0].(mcp.TextResourceContents), nil //nolint:forcetypeassert // Version resources are always text.
+}
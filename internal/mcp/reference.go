@@ -0,0 +1,109 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/upbound/marketplace-mcp-server/internal/local"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+)
+
+// packageRef returns the package named either by a package reference or by
+// separate account, repository and version arguments. Separate arguments may
+// accompany a package reference only if they agree with it, except that a
+// version may supply one the reference lacks.
+func packageRef(pkg, account, repository, version string) (marketplace.Reference, error) {
+	if pkg == "" {
+		if account == "" {
+			return marketplace.Reference{}, errors.New("account parameter is required, or a package reference such as upbound/provider-aws-s3:v1.20.0")
+		}
+		if repository == "" {
+			return marketplace.Reference{}, errors.New("repository parameter is required, or a package reference such as upbound/provider-aws-s3:v1.20.0")
+		}
+		return marketplace.Reference{Account: account, Repository: repository, Version: version}, nil
+	}
+
+	ref, err := marketplace.ParseReference(pkg)
+	if err != nil {
+		return marketplace.Reference{}, err
+	}
+	for _, c := range []struct{ arg, got, want string }{
+		{"account", account, ref.Account},
+		{"repository", repository, ref.Repository},
+		{"version", version, ref.Version},
+	} {
+		if c.got == "" || c.got == c.want {
+			continue
+		}
+		if c.arg == "version" && ref.Version == "" {
+			ref.Version = version
+			continue
+		}
+		return marketplace.Reference{}, fmt.Errorf("package %q conflicts with %s %q: supply one or the other", pkg, c.arg, c.got)
+	}
+	return ref, nil
+}
+
+// catalogVersion returns the version of a package reference that catalogs look
+// packages up by. Catalogs index packages by version tag, so a reference that
+// pins only a digest is rejected, as is one naming a registry that does not
+// serve its account.
+func (s *Server) catalogVersion(ref marketplace.Reference, required bool) (string, error) {
+	if err := s.checkRegistry(ref); err != nil {
+		return "", err
+	}
+	switch {
+	case ref.Version != "":
+		return ref.Version, nil
+	case ref.Digest != "":
+		return "", fmt.Errorf("package %s pins a digest but no version: catalogs look packages up by version, so add a :<version> tag", ref)
+	case required:
+		return "", errors.New("version parameter is required, or a package reference with a :<version> tag")
+	default:
+		return "", nil
+	}
+}
+
+// packageRegistry returns the registry that serves an account's packages.
+func (s *Server) packageRegistry(account string) *registry.Client {
+	if r, ok := s.registries[account]; ok {
+		return r
+	}
+	return s.registry
+}
+
+// checkRegistry returns an error if a package reference names a registry other
+// than the one serving its account. Packages are routed by account, so reading
+// them from another registry would silently serve a different package.
+func (s *Server) checkRegistry(ref marketplace.Reference) error {
+	if ref.Registry == "" {
+		return nil
+	}
+	if ref.Account == local.Account {
+		return fmt.Errorf("package %s names registry %s, but local packages are not published to a registry", ref, ref.Registry)
+	}
+	u, err := url.Parse(s.packageRegistry(ref.Account).BaseURL)
+	if err == nil && strings.EqualFold(u.Host, ref.Registry) {
+		return nil
+	}
+	return fmt.Errorf("package %s names registry %s, which is not configured for account %q: omit the registry, or configure it with %s", ref, ref.Registry, ref.Account, EnvRegistryCatalogs)
+}
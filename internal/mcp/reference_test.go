@@ -0,0 +1,112 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/local"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+)
+
+func TestPackageRef(t *testing.T) {
+	cases := map[string]struct {
+		args    resourceArgs
+		want    marketplace.Reference
+		wantErr bool
+	}{
+		"SplitArguments": {
+			args: resourceArgs{Account: "upbound", RepositoryName: "provider-aws-s3", Version: "v1.20.0"},
+			want: marketplace.Reference{Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"Package": {
+			args: resourceArgs{referenceArg: referenceArg{Package: "xpkg.upbound.io/upbound/provider-aws-s3:v1.20.0"}},
+			want: marketplace.Reference{Registry: "xpkg.upbound.io", Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"PackageWithVersion": {
+			args: resourceArgs{referenceArg: referenceArg{Package: "upbound/provider-aws-s3"}, Version: "v1.20.0"},
+			want: marketplace.Reference{Account: "upbound", Repository: "provider-aws-s3", Version: "v1.20.0"},
+		},
+		"Conflict": {
+			args:    resourceArgs{referenceArg: referenceArg{Package: "upbound/provider-aws-s3:v1.20.0"}, Account: "crossplane-contrib"},
+			wantErr: true,
+		},
+		"MissingRepository": {
+			args:    resourceArgs{Account: "upbound"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.args.ref()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("packageRef() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("packageRef() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("packageRef() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckRegistry(t *testing.T) {
+	s := newFakeServer()
+	WithRegistryCatalog("registry.example.com:5000", registry.NewClient(registry.WithBaseURL("https://registry.example.com:5000")), "acme")(s)
+
+	cases := map[string]struct {
+		ref     marketplace.Reference
+		wantErr bool
+	}{
+		"NoRegistry": {
+			ref: marketplace.Reference{Account: "upbound", Repository: "provider-aws-s3"},
+		},
+		"DefaultRegistry": {
+			ref: marketplace.Reference{Registry: "xpkg.upbound.io", Account: "upbound", Repository: "provider-aws-s3"},
+		},
+		"ConfiguredRegistry": {
+			ref: marketplace.Reference{Registry: "registry.example.com:5000", Account: "acme", Repository: "provider-internal"},
+		},
+		"UnknownRegistry": {
+			ref:     marketplace.Reference{Registry: "ghcr.io", Account: "upbound", Repository: "provider-aws-s3"},
+			wantErr: true,
+		},
+		"RegistryOfAnotherAccount": {
+			ref:     marketplace.Reference{Registry: "registry.example.com:5000", Account: "upbound", Repository: "provider-aws-s3"},
+			wantErr: true,
+		},
+		"Local": {
+			ref:     marketplace.Reference{Registry: "xpkg.upbound.io", Account: local.Account, Repository: "provider-dev"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := s.checkRegistry(tc.ref)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("checkRegistry() error = %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
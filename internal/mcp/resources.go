@@ -0,0 +1,317 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+const (
+	// packageScheme is the URI scheme of marketplace resources.
+	packageScheme = "marketplace://"
+
+	// packageURITemplate is the URI template of package resources.
+	packageURITemplate = packageScheme + "{account}/{repository}"
+
+	// versionURITemplate is the prefix of the URI templates of resources
+	// within a package version.
+	versionURITemplate = packageURITemplate + "/{version}"
+
+	// maxRecentPackages bounds the recently accessed packages listed as
+	// resources.
+	maxRecentPackages = 20
+
+	// maxAssetSize bounds the assets read from asset URLs.
+	maxAssetSize = 10 << 20
+
+	mimeJSON     = "application/json"
+	mimeMarkdown = "text/markdown"
+)
+
+// A versionResource is a resource within a package version, such as its readme
+// or the schema of one of its kinds.
+type versionResource struct {
+	// section names the resource, e.g. readme or crds, and args are the
+	// remaining URI path segments.
+	section string
+	args    int
+
+	template    string
+	name        string
+	description string
+	mimeType    string
+	read        func(s *Server, ctx context.Context, u resourceURI) (string, error)
+}
+
+// versionResources are the resources served within each package version.
+var versionResources = []versionResource{
+	{
+		section:     "readme",
+		template:    versionURITemplate + "/readme",
+		name:        "Package README",
+		description: "The README of a package version. Use latest as the version for the latest version.",
+		mimeType:    mimeMarkdown,
+		read:        (*Server).readReadme,
+	},
+	{
+		section:     "crds",
+		args:        2,
+		template:    versionURITemplate + "/crds/{group}/{kind}",
+		name:        "Kind Definition",
+		description: "The CRD or XRD that defines a kind in a package version, including its OpenAPI schema.",
+		mimeType:    mimeJSON,
+		read:        (*Server).readDefinition,
+	},
+	{
+		section:     "compositions",
+		args:        1,
+		template:    versionURITemplate + "/compositions/{name}",
+		name:        "Composition",
+		description: "A composition in a package version.",
+		mimeType:    mimeJSON,
+		read:        (*Server).readComposition,
+	},
+	{
+		section:     "examples",
+		args:        2,
+		template:    versionURITemplate + "/examples/{group}/{kind}",
+		name:        "Kind Examples",
+		description: "Example manifests of a kind in a package version.",
+		mimeType:    mimeJSON,
+		read:        (*Server).readExamples,
+	},
+}
+
+// resourceURI is a parsed marketplace resource URI.
+type resourceURI struct {
+	raw        string
+	account    string
+	repository string
+	version    string
+	resource   *versionResource
+	args       []string
+}
+
+// parseResourceURI parses a resource URI within a package version.
+func parseResourceURI(uri string) (resourceURI, error) {
+	u := resourceURI{raw: uri}
+	rest, ok := strings.CutPrefix(uri, packageScheme)
+	if !ok {
+		return u, fmt.Errorf("resource URI %q does not start with %s", uri, packageScheme)
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 4 || slices.Contains(parts, "") {
+		return u, fmt.Errorf("resource URI %q must be of the form %s/<resource>", uri, versionURITemplate)
+	}
+	u.account, u.repository, u.version = parts[0], parts[1], parts[2]
+	for i := range versionResources {
+		r := &versionResources[i]
+		if r.section == parts[3] && r.args == len(parts)-4 {
+			u.resource, u.args = r, parts[4:]
+			return u, nil
+		}
+	}
+	return u, fmt.Errorf("unknown package resource %q", uri)
+}
+
+// parsePackageURI returns the account and repository of a package resource
+// URI.
+func parsePackageURI(uri string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(uri, packageScheme)
+	if !ok {
+		return "", "", false
+	}
+	account, repository, ok := strings.Cut(rest, "/")
+	if !ok || account == "" || repository == "" || strings.Contains(repository, "/") {
+		return "", "", false
+	}
+	return account, repository, true
+}
+
+// registerResources registers the resource templates packages and their
+// contents are read through.
+func (s *Server) registerResources() {
+	s.mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(
+		packageURITemplate,
+		"Package",
+		mcp.WithTemplateDescription("Metadata of the latest version of a package."),
+		mcp.WithTemplateMIMEType(mimeJSON),
+	), s.handleReadPackage)
+
+	for _, r := range versionResources {
+		s.mcpServer.AddResourceTemplate(mcp.NewResourceTemplate(
+			r.template,
+			r.name,
+			mcp.WithTemplateDescription(r.description),
+			mcp.WithTemplateMIMEType(r.mimeType),
+		), s.handleReadVersionResource)
+	}
+}
+
+// handleReadPackage reads the metadata of the latest version of a package.
+func (s *Server) handleReadPackage(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	account, repository, ok := parsePackageURI(req.Params.URI)
+	if !ok {
+		return nil, fmt.Errorf("invalid package URI, expected %s", packageURITemplate)
+	}
+	md, err := s.catalog.GetPackageMetadata(ctx, account, repository, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package metadata: %w", err)
+	}
+	b, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal package metadata: %w", err)
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: mimeJSON, Text: string(b)}}, nil
+}
+
+// handleReadVersionResource reads a resource within a package version.
+func (s *Server) handleReadVersionResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	u, err := parseResourceURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+	if u.version == "latest" {
+		md, err := s.catalog.GetPackageMetadata(ctx, u.account, u.repository, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest version: %w", err)
+		}
+		u.version = md.LatestVersion
+	}
+	text, err := u.resource.read(s, ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	s.rememberPackage(u.account, u.repository, u.version)
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: u.raw, MIMEType: u.resource.mimeType, Text: text}}, nil
+}
+
+// readReadme reads the readme of a package version, downloading it if the
+// catalog only returns its URL.
+func (s *Server) readReadme(ctx context.Context, u resourceURI) (string, error) {
+	a, err := s.catalog.GetPackageAssets(ctx, u.account, u.repository, u.version, "readme")
+	if err != nil {
+		return "", fmt.Errorf("failed to get readme: %w", err)
+	}
+	if a.Content != "" || a.URL == "" {
+		return a.Content, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create readme request: %w", err)
+	}
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download readme: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Read only.
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download readme: %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to download readme: %w", err)
+	}
+	return string(b), nil
+}
+
+// readDefinition reads the CRD or XRD that defines a kind.
+func (s *Server) readDefinition(ctx context.Context, u resourceURI) (string, error) {
+	raw, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, u.account, u.repository, u.version, u.args[0], u.args[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to get definition: %w", err)
+	}
+	return raw, nil
+}
+
+// readComposition reads a composition by name, looking up the kind it
+// composes in the package's resources.
+func (s *Server) readComposition(ctx context.Context, u resourceURI) (string, error) {
+	res, err := s.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, u.account, u.repository, u.version)
+	if err != nil {
+		return "", fmt.Errorf("failed to get package resources: %w", err)
+	}
+	i := slices.IndexFunc(res.Compositions, func(c marketplace.CompositionMeta) bool { return c.Name == u.args[0] })
+	if i < 0 {
+		return "", fmt.Errorf("composition %s not found in %s/%s:%s", u.args[0], u.account, u.repository, u.version)
+	}
+	c := res.Compositions[i]
+	group, _, _ := strings.Cut(c.XrdAPIVersion, "/")
+	raw, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, u.account, u.repository, u.version, group, c.XrdKind, c.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get composition: %w", err)
+	}
+	return raw, nil
+}
+
+// readExamples reads the examples of a kind.
+func (s *Server) readExamples(ctx context.Context, u resourceURI) (string, error) {
+	exs, err := s.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, u.account, u.repository, u.version, u.args[0], u.args[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to get examples: %w", err)
+	}
+	b, err := json.MarshalIndent(exs, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal examples: %w", err)
+	}
+	return string(b), nil
+}
+
+// recentPackages are the package versions most recently accessed, oldest
+// first, by the URI of their readme resource.
+type recentPackages struct {
+	mu   sync.Mutex
+	uris []string
+}
+
+// rememberPackage lists the readme of a package version as a resource, so
+// that clients can browse recently accessed packages. Only the most recently
+// accessed maxRecentPackages are listed.
+func (s *Server) rememberPackage(account, repository, version string) {
+	if account == "" || repository == "" || version == "" || version == "latest" {
+		return
+	}
+	uri := fmt.Sprintf("%s%s/%s/%s/readme", packageScheme, account, repository, version)
+
+	s.recent.mu.Lock()
+	defer s.recent.mu.Unlock()
+	if i := slices.Index(s.recent.uris, uri); i >= 0 {
+		s.recent.uris = append(slices.Delete(s.recent.uris, i, i+1), uri)
+		return
+	}
+	ref := marketplace.Reference{Account: account, Repository: repository, Version: version}
+	s.mcpServer.AddResource(mcp.NewResource(uri, ref.String()+" README",
+		mcp.WithResourceDescription("The README of "+ref.String()),
+		mcp.WithMIMEType(mimeMarkdown),
+	), s.handleReadVersionResource)
+	s.recent.uris = append(s.recent.uris, uri)
+	if len(s.recent.uris) > maxRecentPackages {
+		s.mcpServer.RemoveResource(s.recent.uris[0])
+		s.recent.uris = s.recent.uris[1:]
+	}
+}
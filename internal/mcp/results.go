@@ -0,0 +1,227 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/args"
+	"github.com/upbound/marketplace-mcp-server/internal/budget"
+	"github.com/upbound/marketplace-mcp-server/internal/fields"
+	"github.com/upbound/marketplace-mcp-server/internal/index"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+)
+
+// KindMatches is the structured result of the find_kind tool.
+type KindMatches struct {
+	Query   string        `json:"query"`
+	Matches []index.Match `json:"matches"`
+
+	// Building is true while the kind index is being built, in which case
+	// the matches may be incomplete.
+	Building bool `json:"building,omitempty"`
+
+	// Failed lists the packages, as account/repository, that the kind index
+	// could not read. Their kinds may be missing from the matches.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// FieldHits is the structured result of the search_fields tool.
+type FieldHits struct {
+	Query string       `json:"query"`
+	Hits  []fields.Hit `json:"hits"`
+}
+
+// Definition is the structured result of the tools that return a Kubernetes
+// object, such as a CRD or a composition.
+type Definition map[string]any
+
+// AuthStatus is the structured result of the reload_auth tool.
+type AuthStatus struct {
+	// ServerURL is the marketplace URL loaded from the UP CLI profile, if
+	// it could be loaded.
+	ServerURL string `json:"serverUrl,omitempty"`
+	Loaded    bool   `json:"loaded"`
+
+	// Warning describes what could not be reloaded along with the token.
+	Warning string `json:"warning,omitempty"`
+}
+
+// outputFormatArg is the argument every tool accepts to select the format
+// its result is rendered in.
+const outputFormatArg = "output_format"
+
+// outputFormatProperty is the schema of the output_format argument.
+var outputFormatProperty = map[string]any{
+	"type":        "string",
+	"description": "Format to render the result in: markdown or text for people, json or yaml for programs. The structured content is the same in every format.",
+	"enum":        render.Formats,
+	"default":     string(render.DefaultFormat),
+}
+
+// budgetArgs are the arguments every tool accepts to fit its result into a
+// size budget.
+type budgetArgs struct {
+	MaxTokens int    `arg:"max_tokens" desc:"Maximum size of the result in tokens, estimated at 4 bytes per token. Larger results are truncated at a section, list item or nesting boundary and end with a cursor for the next part." min:"64"`
+	MaxBytes  int    `arg:"max_bytes" desc:"Maximum size of the result in bytes. When max_tokens is also set the smaller budget applies." min:"256"`
+	Cursor    string `arg:"cursor" desc:"Cursor from a truncated result, to fetch its next part. Call the tool again with the same arguments and the cursor; the budget defaults to the one the cursor was issued with."`
+}
+
+// PagedResult is the structured result of a tool call whose result was
+// truncated to fit its size budget. Structured content is only ever returned
+// whole, so a truncated result holds a part of the rendered result instead.
+type PagedResult struct {
+	Page ResultPage `json:"page" jsonschema:"required"`
+}
+
+// ResultPage is a part of a rendered tool result.
+type ResultPage struct {
+	// Text is the part of the result, followed by a truncation marker unless
+	// it is the last part.
+	Text string `json:"text" jsonschema:"required"`
+
+	// Start and End are the byte offsets of the part in the rendered result,
+	// and Total is the size of the rendered result.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Total int `json:"total"`
+
+	// Cursor fetches the next part, or is empty if this is the last part.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// outputSchema returns the output schema of a tool whose structured results
+// are of type T, or a PagedResult when they do not fit the tool's budget.
+func outputSchema[T any]() json.RawMessage {
+	b, err := json.Marshal(map[string]any{
+		"type":  "object",
+		"anyOf": []any{objectSchema[T](), objectSchema[PagedResult]()},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("cannot generate output schema for %T: %v", *new(T), err))
+	}
+	return b
+}
+
+// objectSchema returns the JSON schema of type T. Properties are only
+// required when tagged `jsonschema:"required"`, since results omit empty
+// fields, and nested arrays and objects may be null, since nil slices and
+// maps encode as null.
+func objectSchema[T any]() map[string]any {
+	r := jsonschema.Reflector{
+		DoNotReference:             true,
+		Anonymous:                  true,
+		AllowAdditionalProperties:  true,
+		RequiredFromJSONSchemaTags: true,
+	}
+	var zero T
+	schema := r.Reflect(zero)
+	schema.Version = ""
+
+	var out map[string]any
+	b, err := json.Marshal(schema)
+	if err == nil {
+		err = json.Unmarshal(b, &out)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("cannot generate output schema for %T: %v", zero, err))
+	}
+	for _, p := range properties(out) {
+		nullable(p)
+	}
+	out["type"] = "object"
+	return out
+}
+
+// nullable allows a schema, and the arrays and objects nested in it, to be
+// null.
+func nullable(schema map[string]any) {
+	if t, ok := schema["type"].(string); ok && (t == "array" || t == "object") {
+		schema["type"] = []any{t, "null"}
+	}
+	for _, p := range properties(schema) {
+		nullable(p)
+	}
+	for _, k := range []string{"items", "additionalProperties"} {
+		if sub, ok := schema[k].(map[string]any); ok {
+			nullable(sub)
+		}
+	}
+}
+
+// properties returns the property schemas of an object schema.
+func properties(schema map[string]any) []map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	out := make([]map[string]any, 0, len(props))
+	for _, p := range props {
+		if p, ok := p.(map[string]any); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// structuredResult returns a tool result holding v as structured content,
+// along with v rendered in the output format the tool was called with. JSON
+// and YAML encode v, while markdown and text render doc through the server's
+// templates.
+func (s *Server) structuredResult(req mcp.CallToolRequest, v any, doc *render.Document) (*mcp.CallToolResult, error) {
+	f, err := render.ParseFormat(req.GetString(outputFormatArg, ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	text, err := s.templates.Render(req.Params.Name, f, v, doc)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to render result: %v", err)), err
+	}
+
+	var b budgetArgs
+	if err := args.Decode(req.GetArguments(), &b); err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	kind := budget.Document
+	if f == render.JSON || f == render.YAML {
+		kind = budget.Data
+	}
+	page, err := budget.Paginate(req.Params.Name, text, kind, budget.Bytes(b.MaxTokens, b.MaxBytes), b.Cursor)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), err
+	}
+	if page.Truncated() {
+		return mcp.NewToolResultStructured(PagedResult{Page: ResultPage{
+			Text:   page.Text,
+			Start:  page.Start,
+			End:    page.End,
+			Total:  page.Total,
+			Cursor: page.Cursor,
+		}}, page.Text), nil
+	}
+	return mcp.NewToolResultStructured(v, text), nil
+}
+
+// definitionResult returns a tool result holding a raw Kubernetes object.
+func (s *Server) definitionResult(req mcp.CallToolRequest, raw string) (*mcp.CallToolResult, error) {
+	var def Definition
+	if err := json.Unmarshal([]byte(raw), &def); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to decode response: %v", err)), err
+	}
+	return s.structuredResult(req, def, definitionDocument(def))
+}
@@ -0,0 +1,388 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+)
+
+// validate checks that v, as decoded from JSON, matches the types and
+// required properties of a JSON schema.
+func validate(schema map[string]any, v any, path string) error {
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []error
+		for _, sub := range anyOf {
+			err := validate(sub.(map[string]any), v, path) //nolint:forcetypeassert // Subschemas are objects.
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, s := range t {
+			types = append(types, s.(string)) //nolint:forcetypeassert // Schema types are strings.
+		}
+	}
+	if len(types) > 0 && !slices.Contains(types, jsonType(v)) && (jsonType(v) != "integer" || !slices.Contains(types, "number")) {
+		return fmt.Errorf("%s: got %s, want %v", path, jsonType(v), types)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, r := range required {
+			if _, ok := v[r.(string)]; !ok { //nolint:forcetypeassert // Required properties are strings.
+				return fmt.Errorf("%s: missing required property %s", path, r)
+			}
+		}
+		for k, pv := range v {
+			ps, ok := props[k].(map[string]any)
+			if !ok {
+				ps, _ = schema["additionalProperties"].(map[string]any)
+			}
+			if ps == nil {
+				continue
+			}
+			if err := validate(ps, pv, path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, iv := range v {
+			if items == nil {
+				break
+			}
+			if err := validate(items, iv, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// roundTrip returns v as decoded from its JSON encoding.
+func roundTrip(t *testing.T, v any) map[string]any {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestOutputSchema(t *testing.T) {
+	type result struct {
+		Name  string            `json:"name" jsonschema:"required"`
+		Tags  []string          `json:"tags"`
+		Attrs map[string]string `json:"attrs,omitempty"`
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(outputSchema[result](), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	if schema["type"] != "object" {
+		t.Errorf("type = %v, want object", schema["type"])
+	}
+	anyOf, _ := schema["anyOf"].([]any)
+	if len(anyOf) != 2 {
+		t.Fatalf("anyOf = %v, want the result and a page of it", schema["anyOf"])
+	}
+	if r := anyOf[0].(map[string]any)["required"]; fmt.Sprint(r) != "[name]" { //nolint:forcetypeassert // Subschemas are objects.
+		t.Errorf("required = %v, want [name]", r)
+	}
+	if err := validate(schema, map[string]any{"name": "a", "tags": nil}, "result"); err != nil {
+		t.Errorf("null array rejected: %v", err)
+	}
+	if err := validate(schema, map[string]any{"tags": []any{"a"}}, "result"); err == nil {
+		t.Error("missing required property accepted")
+	}
+	if err := validate(schema, map[string]any{"name": "a", "tags": "a"}, "result"); err == nil {
+		t.Error("string accepted as an array")
+	}
+	if err := validate(schema, map[string]any{"page": map[string]any{"text": "a", "total": 2.0, "cursor": "c"}}, "result"); err != nil {
+		t.Errorf("page rejected: %v", err)
+	}
+}
+
+func TestToolsDeclareOutputSchemas(t *testing.T) {
+	s := NewServer(marketplace.NewClient(), WithSync([]string{"upbound"}), WithSnapshotDir(t.TempDir()))
+	tools := s.mcpServer.ListTools()
+	if len(tools) == 0 {
+		t.Fatal("no tools registered")
+	}
+	for name, tool := range tools {
+		schema := roundTrip(t, tool.Tool)["outputSchema"]
+		if m, ok := schema.(map[string]any); !ok || m["type"] != "object" {
+			t.Errorf("tool %s output schema = %v, want an object schema", name, schema)
+		}
+	}
+}
+
+func TestStructuredResults(t *testing.T) {
+	cases := map[string]struct {
+		tool string
+		args map[string]any
+	}{
+		"PackageMetadata": {
+			tool: "get_package_metadata",
+			args: map[string]any{"package": "upbound/provider-aws-s3"},
+		},
+		"Repositories": {
+			tool: "get_repositories",
+			args: map[string]any{"account": "upbound"},
+		},
+		"PackageResources": {
+			tool: "get_package_version_resources",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0"},
+		},
+		"Definition": {
+			tool: "get_package_version_groupkind_resources",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0", "resource_group": "s3.aws.upbound.io", "resource_kind": "Bucket"},
+		},
+		"Examples": {
+			tool: "get_package_version_examples",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0", "resource_group": "s3.aws.upbound.io", "resource_kind": "Bucket"},
+		},
+		"KindContext": {
+			tool: "get_kind_context",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0", "resource_group": "s3.aws.upbound.io", "resource_kind": "Bucket"},
+		},
+		"SyncStatus": {
+			tool: "get_sync_status",
+		},
+	}
+	s := NewServer(marketplace.NewClient(),
+		WithCatalog(catalog.Backend{Name: "fake", Catalog: fakeCatalog{}, Accounts: []string{"upbound"}}),
+		WithSync([]string{"upbound"}),
+	)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tool := s.mcpServer.GetTool(tc.tool)
+			req := mcp.CallToolRequest{}
+			req.Params.Name = tc.tool
+			req.Params.Arguments = tc.args
+			result, err := tool.Handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("%s error = %v", tc.tool, err)
+			}
+			if result.StructuredContent == nil {
+				t.Fatalf("%s returned no structured content", tc.tool)
+			}
+			if len(result.Content) != 1 {
+				t.Errorf("%s returned %d content blocks, want a text summary", tc.tool, len(result.Content))
+			}
+			schema, _ := roundTrip(t, tool.Tool)["outputSchema"].(map[string]any)
+			if err := validate(schema, roundTrip(t, result.StructuredContent), tc.tool); err != nil {
+				t.Errorf("structured content does not match the output schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	cases := map[string]struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		"Default": {
+			want: "# Package upbound/provider-aws-s3\n\n- **Latest version:** v1.21.0\n\n## Versions\n\n- v1.2.0\n- v1.21.0\n- v1.20.0\n",
+		},
+		"Text": {
+			format: "text",
+			want:   "Package upbound/provider-aws-s3\n===============================\n\nLatest version: v1.21.0\n\nVersions\n--------\n- v1.2.0\n- v1.21.0\n- v1.20.0\n",
+		},
+		"JSON": {
+			format: "json",
+			want:   "{\n  \"account\": \"upbound\",\n  \"repository\": \"provider-aws-s3\",\n  \"name\": \"\",\n  \"public\": false,\n  \"createdAt\": \"0001-01-01T00:00:00Z\",\n  \"updatedAt\": \"0001-01-01T00:00:00Z\",\n  \"versions\": [\n    \"v1.2.0\",\n    \"v1.21.0\",\n    \"v1.20.0\"\n  ],\n  \"latestVersion\": \"v1.21.0\"\n}",
+		},
+		"YAML": {
+			format: "yaml",
+			want:   "account: upbound\ncreatedAt: \"0001-01-01T00:00:00Z\"\nlatestVersion: v1.21.0\nname: \"\"\npublic: false\nrepository: provider-aws-s3\nupdatedAt: \"0001-01-01T00:00:00Z\"\nversions:\n  - v1.2.0\n  - v1.21.0\n  - v1.20.0\n",
+		},
+		"Unsupported": {
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	s := newFakeServer()
+	tool := s.mcpServer.GetTool("get_package_metadata")
+	if _, ok := tool.Tool.InputSchema.Properties[outputFormatArg]; !ok {
+		t.Fatalf("get_package_metadata does not accept %s", outputFormatArg)
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "get_package_metadata"
+			req.Params.Arguments = map[string]any{"package": "upbound/provider-aws-s3", outputFormatArg: tc.format}
+			result, err := tool.Handler(context.Background(), req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("get_package_metadata error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				if !result.IsError {
+					t.Error("get_package_metadata did not return an error result")
+				}
+				return
+			}
+			text, ok := result.Content[0].(mcp.TextContent)
+			if !ok {
+				t.Fatalf("content = %T, want text", result.Content[0])
+			}
+			if text.Text != tc.want {
+				t.Errorf("get_package_metadata text =\n%s\nwant\n%s", text.Text, tc.want)
+			}
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	s := newFakeServer()
+	tool := s.mcpServer.GetTool("get_package_metadata")
+	call := func(arguments map[string]any) (*mcp.CallToolResult, error) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "get_package_metadata"
+		req.Params.Arguments = arguments
+		return tool.Handler(context.Background(), req)
+	}
+
+	full, err := call(map[string]any{"package": "upbound/provider-aws-s3", outputFormatArg: "json"})
+	if err != nil {
+		t.Fatalf("get_package_metadata error = %v", err)
+	}
+	want := full.Content[0].(mcp.TextContent).Text //nolint:forcetypeassert // Results are text.
+
+	first, err := call(map[string]any{"package": "upbound/provider-aws-s3", outputFormatArg: "json", "max_bytes": 256})
+	if err != nil {
+		t.Fatalf("get_package_metadata error = %v", err)
+	}
+	schema, _ := roundTrip(t, tool.Tool)["outputSchema"].(map[string]any)
+	page, ok := first.StructuredContent.(PagedResult)
+	if !ok {
+		t.Fatalf("truncated result structured content = %T, want a page", first.StructuredContent)
+	}
+	if err := validate(schema, roundTrip(t, page), "result"); err != nil {
+		t.Errorf("page does not match the output schema: %v", err)
+	}
+	text := first.Content[0].(mcp.TextContent).Text //nolint:forcetypeassert // Results are text.
+	i := strings.LastIndex(text, "[truncated:")
+	if i < 0 {
+		t.Fatalf("result %q has no truncation marker", text)
+	}
+	cursor := text[strings.Index(text[i:], "\"")+i+1 : strings.LastIndex(text, "\"")]
+	if page.Page.Text != text || page.Page.Cursor != cursor {
+		t.Errorf("page = %+v, want the text and cursor of the result", page.Page)
+	}
+
+	rest, err := call(map[string]any{"package": "upbound/provider-aws-s3", outputFormatArg: "json", "cursor": cursor})
+	if err != nil {
+		t.Fatalf("get_package_metadata with cursor error = %v", err)
+	}
+	got := strings.TrimRight(text[:i], "\n") + "\n" + rest.Content[0].(mcp.TextContent).Text //nolint:forcetypeassert // Results are text.
+	if got != want {
+		t.Errorf("pages joined =\n%s\nwant\n%s", got, want)
+	}
+
+	if result, err := call(map[string]any{"package": "upbound/provider-aws-s3", "max_bytes": 10}); err == nil || !result.IsError {
+		t.Error("get_package_metadata accepted a budget below the minimum")
+	}
+}
+
+func TestSnapshotDiffDocument(t *testing.T) {
+	r := &snapshot.DiffReport{
+		From:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		NewPackages: []snapshot.PackageChange{{Account: "upbound", Repository: "provider-aws-s3", Type: "provider", Version: "v1.0.0"}},
+		Popularity: []snapshot.PopularityChange{
+			{Account: "upbound", Repository: "provider-aws-s3", Downloads: 10, DownloadsDelta: 10},
+			{Account: "upbound", Repository: "provider-gcp", Downloads: 5, DownloadsDelta: -1},
+		},
+	}
+	doc := snapshotDiffDocument(r, 1)
+
+	var tables int
+	for _, b := range doc.Blocks() {
+		if b.Kind == render.BlockTable {
+			tables++
+		}
+	}
+	if tables != 2 {
+		t.Errorf("document has %d tables, want 2", tables)
+	}
+	text := doc.Text()
+	for _, want := range []string{"Marketplace changes 2025-01-01 to 2025-02-01", "New packages (1)", "Removed packages (0)", "1 more packages changed."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, markdown := range []string{"#", "|", "**"} {
+		if strings.Contains(text, markdown) {
+			t.Errorf("text contains markdown %q:\n%s", markdown, text)
+		}
+	}
+}
@@ -18,24 +18,163 @@ package mcp
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"maps"
+	"path/filepath"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/upbound/marketplace-mcp-server/internal/args"
 	"github.com/upbound/marketplace-mcp-server/internal/auth"
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/contextpack"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+	"github.com/upbound/marketplace-mcp-server/internal/fields"
+	"github.com/upbound/marketplace-mcp-server/internal/index"
+	"github.com/upbound/marketplace-mcp-server/internal/local"
 	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/recommend"
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+	"github.com/upbound/marketplace-mcp-server/internal/render"
+	"github.com/upbound/marketplace-mcp-server/internal/snapshot"
+	"github.com/upbound/marketplace-mcp-server/internal/syncer"
+	"github.com/upbound/marketplace-mcp-server/internal/upgrade"
+	"github.com/upbound/marketplace-mcp-server/internal/verify"
+	"github.com/upbound/marketplace-mcp-server/internal/watch"
 )
 
+// MarketplaceCatalog is the name of the Upbound Marketplace catalog backend.
+const MarketplaceCatalog = "marketplace"
+
 // Server represents the MCP server.
 type Server struct {
-	mcpServer   *server.MCPServer
-	client      *marketplace.Client
-	authManager *auth.Manager
+	mcpServer     *server.MCPServer
+	client        *marketplace.Client
+	authManager   *auth.Manager
+	localPackages *local.Store
+	backends      []catalog.Backend
+	catalog       *catalog.Federated
+	registry      *registry.Client
+	registries    map[string]*registry.Client
+	verifier      *verify.Verifier
+	cacheDir      string
+	snapshotDir   string
+	templates     *render.Templates
+	toolFilter    ToolFilter
+
+	// tools are the defined tools, registered while their toolset is
+	// available.
+	toolsMu sync.Mutex
+	tools   map[string]server.ServerTool
+
+	// indexMu makes updates that span the kind and field indexes atomic.
+	// Each index is safe for concurrent use, so it is only held to put
+	// what has already been fetched.
+	indexMu sync.Mutex
+	kinds   *index.Index
+	fields  *fields.Index
+
+	// kindsBuilding is true while the kind index is built in the
+	// background.
+	kindsMu       sync.Mutex
+	kindsBuilding bool
+
+	syncAccounts []string
+	syncOpts     []syncer.Option
+	sync         *syncer.Worker
+
+	watchEnabled bool
+	watchOpts    []watch.Option
+	watcher      *watch.Watcher
+	sessions     *sessionIDManager
+
+	// recent are the recently accessed packages listed as resources.
+	recent recentPackages
+}
+
+// Option configures the Server.
+type Option func(*Server)
+
+// WithLocalPackages serves the packages found at the supplied paths under the
+// local account. Paths may be .xpkg files, package directories, or
+// directories containing either.
+func WithLocalPackages(paths ...string) Option {
+	return func(s *Server) {
+		s.localPackages = local.NewStore(paths...)
+	}
+}
+
+// WithCatalog adds a catalog backend alongside the Upbound Marketplace.
+func WithCatalog(b catalog.Backend) Option {
+	return func(s *Server) {
+		s.backends = append(s.backends, b)
+	}
+}
+
+// WithRegistryCatalog adds a catalog backend that reads packages in the
+// supplied accounts directly from an OCI registry. Packages in these accounts
+// are also verified against this registry.
+func WithRegistryCatalog(name string, c *registry.Client, accounts ...string) Option {
+	return func(s *Server) {
+		WithCatalog(catalog.Backend{
+			Name:     name,
+			Catalog:  catalog.NewPackageCatalog(c),
+			Accounts: accounts,
+		})(s)
+		for _, a := range accounts {
+			s.registries[a] = c
+		}
+	}
+}
+
+// WithSnapshot adds a read-only catalog backend that serves the packages in a
+// snapshot. The snapshot's accounts are served from it rather than from the
+// marketplace.
+func WithSnapshot(name string, snap *snapshot.Snapshot) Option {
+	return WithCatalog(catalog.Backend{
+		Name:     name,
+		Catalog:  snap,
+		Accounts: snap.Accounts(),
+	})
+}
+
+// WithSnapshotDir makes the snapshots in the supplied directory available to
+// compare with the diff_snapshots tool.
+func WithSnapshotDir(dir string) Option {
+	return func(s *Server) {
+		s.snapshotDir = dir
+	}
+}
+
+// WithCacheDir persists indexes in the supplied directory. Without it indexes
+// are kept in memory.
+func WithCacheDir(dir string) Option {
+	return func(s *Server) {
+		s.cacheDir = dir
+	}
+}
+
+// WithVerificationKeys sets the public keys trusted when verifying package
+// signatures and attestations.
+func WithVerificationKeys(keys ...verify.Key) Option {
+	return func(s *Server) {
+		s.verifier = verify.NewVerifier(keys...)
+	}
+}
+
+// WithTemplates renders the markdown and text output of tools with the
+// supplied templates rather than the built-in ones.
+func WithTemplates(t *render.Templates) Option {
+	return func(s *Server) {
+		s.templates = t
+	}
 }
 
 // NewServer creates a new MCP server using mcp-go framework.
-func NewServer(client *marketplace.Client) *Server {
+func NewServer(client *marketplace.Client, opts ...Option) *Server {
 	// Initialize auth manager
 	authManager := auth.NewManager()
 
@@ -56,24 +195,101 @@ func NewServer(client *marketplace.Client) *Server {
 	}
 
 	s := &Server{
-		client:      client,
-		authManager: authManager,
+		client:        client,
+		authManager:   authManager,
+		localPackages: local.NewStore(),
+		registry:      registry.NewClient(),
+		registries:    map[string]*registry.Client{},
+		verifier:      verify.NewVerifier(),
+		templates:     render.DefaultTemplates(),
+		tools:         map[string]server.ServerTool{},
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	// The marketplace serves every account not claimed by another backend.
+	backends := []catalog.Backend{{Name: MarketplaceCatalog, Catalog: client}}
+	if len(s.localPackages.Paths()) > 0 {
+		backends = append(backends, catalog.Backend{
+			Name:     local.Account,
+			Catalog:  catalog.NewPackageCatalog(s.localPackages),
+			Accounts: []string{local.Account},
+		})
+	}
+	s.catalog = catalog.NewFederated(append(backends, s.backends...)...)
+
+	s.kinds = openIndex(s.cacheDir, "kinds.json")
+	s.fields = openFieldIndex(s.cacheDir, "fields.json")
+	if len(s.syncAccounts) > 0 {
+		s.sync = s.newSyncWorker()
 	}
 
 	// Create MCP server with server info
+	completer := newCompleter(s)
+	serverOpts := []server.ServerOption{
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completer),
+		server.WithResourceCompletionProvider(completer),
+		server.WithToolCapabilities(true),
+	}
+	if s.watchEnabled {
+		serverOpts = append(serverOpts, server.WithResourceCapabilities(true, false))
+	}
 	mcpServer := server.NewMCPServer(
 		"marketplace-mcp-server",
 		"1.0.0",
+		serverOpts...,
 	)
 
 	s.mcpServer = mcpServer
 
-	// Register tools
+	// Register tools, resources and prompts
 	s.registerTools()
+	s.RefreshTools()
+	s.registerResources()
+	s.registerPrompts()
+
+	// Package resources are subscribable when watching
+	if s.watchEnabled {
+		s.watcher = s.newWatcher()
+	}
+	s.sessions = newSessionIDManager(s)
 
 	return s
 }
 
+// openIndex opens the kind index persisted in the cache directory, falling back
+// to an in-memory index if it cannot be read.
+func openIndex(dir, name string) *index.Index {
+	path := ""
+	if dir != "" {
+		path = filepath.Join(dir, name)
+	}
+	idx, err := index.Open(path)
+	if err != nil {
+		log.Printf("Warning: Rebuilding kind index: %v", err)
+		idx, _ = index.Open("")
+	}
+	return idx
+}
+
+// openFieldIndex opens the field index persisted in the cache directory,
+// falling back to an in-memory index if it cannot be read.
+func openFieldIndex(dir, name string) *fields.Index {
+	path := ""
+	if dir != "" {
+		path = filepath.Join(dir, name)
+	}
+	idx, err := fields.Open(path)
+	if err != nil {
+		log.Printf("Warning: Rebuilding field index: %v", err)
+		idx, _ = fields.Open("")
+	}
+	return idx
+}
+
 // Start starts the MCP server using stdio transport.
 func (s *Server) Start(_ context.Context) error {
 	return server.ServeStdio(s.mcpServer)
@@ -84,288 +300,143 @@ func (s *Server) GetMCPServer() *server.MCPServer {
 	return s.mcpServer
 }
 
-// registerTools registers all available tools.
+// Catalog returns the catalog the server reads packages from, which federates
+// the marketplace and every configured backend.
+func (s *Server) Catalog() catalog.Catalog {
+	return s.catalog
+}
+
+// addTool defines a tool unless the tool filter disables it, annotating it
+// and adding the output_format and budget arguments every tool accepts. Calls
+// with an unsupported output format or an invalid budget fail before the
+// handler runs. Defined tools are registered by RefreshTools while their
+// toolset is available.
+func (s *Server) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	spec, ok := toolSpecs[tool.Name]
+	if !ok {
+		panic(fmt.Sprintf("tool %s has no toolset", tool.Name))
+	}
+	if !s.toolFilter.Allows(tool.Name) {
+		return
+	}
+	tool.Annotations = spec.annotations
+
+	props := maps.Clone(tool.InputSchema.Properties)
+	if props == nil {
+		props = map[string]any{}
+	}
+	props[outputFormatArg] = outputFormatProperty
+	maps.Copy(props, args.Schema[budgetArgs]().Properties)
+	tool.InputSchema.Properties = props
+
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+	s.tools[tool.Name] = server.ServerTool{Tool: tool, Handler: func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := render.ParseFormat(req.GetString(outputFormatArg, "")); err != nil {
+			return mcp.NewToolResultError(err.Error()), err
+		}
+		if err := args.Decode(req.GetArguments(), &budgetArgs{}); err != nil {
+			return mcp.NewToolResultError(err.Error()), err
+		}
+		return handler(ctx, req)
+	}}
+}
+
+// registerTools defines every tool. RefreshTools registers those that are
+// available.
 func (s *Server) registerTools() {
 	// Search packages tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "search_packages",
-		Description: "Search for packages in the Upbound Marketplace",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"query": map[string]any{
-					"type":        "string",
-					"description": "Search query for packages",
-				},
-				"family": map[string]any{
-					"type":        "string",
-					"description": "Family repository key to filter by",
-				},
-				"package_type": map[string]any{
-					"type":        "string",
-					"description": "Type of package (provider, configuration, function)",
-				},
-				"account_name": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name to filter by",
-				},
-				"tier": map[string]any{
-					"type":        "string",
-					"description": "Package tier (official, community, etc.)",
-				},
-				"public": map[string]any{
-					"type":        "boolean",
-					"description": "Filter by public/private packages",
-				},
-				"size": map[string]any{
-					"type":        "integer",
-					"description": "Number of results to return (max 500)",
-					"default":     20,
-				},
-				"page": map[string]any{
-					"type":        "integer",
-					"description": "Page number (0-indexed)",
-					"default":     0,
-				},
-				"use_v1": map[string]any{
-					"type":        "boolean",
-					"description": "Use v1 API instead of v2",
-					"default":     false,
-				},
-			},
-		},
-	}, s.handleSearchPackages)
+	s.addTool(defineTool[searchPackagesArgs, marketplace.SearchResponse]("search_packages",
+		"Search for packages in the Upbound Marketplace and any other configured catalogs"),
+		bind(s.handleSearchPackages))
 
 	// Get package metadata tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_metadata",
-		Description: "Get detailed metadata for a specific package",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name",
-				},
-				"repository": map[string]any{
-					"type":        "string",
-					"description": "Repository name",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "Package version (optional, gets latest if not specified)",
-				},
-				"use_v1": map[string]any{
-					"type":        "boolean",
-					"description": "Use v1 API instead of v2",
-					"default":     false,
-				},
-			},
-			Required: []string{"account", "repository"},
-		},
-	}, s.handleGetPackageMetadata)
+	s.addTool(defineTool[getPackageMetadataArgs, marketplace.PackageMetadata]("get_package_metadata",
+		"Get detailed metadata for a specific package"),
+		bind(s.handleGetPackageMetadata))
 
 	// Get package assets tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_assets",
-		Description: "Get assets (documentation, icons, release notes, etc.) for a specific package version",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name",
-				},
-				"repository": map[string]any{
-					"type":        "string",
-					"description": "Repository name",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "Package version or 'latest'",
-				},
-				"asset_type": map[string]any{
-					"type":        "string",
-					"description": "Type of asset to retrieve",
-					"enum":        []string{"docs", "icon", "readme", "releaseNotes", "sbom"},
-				},
-			},
-			Required: []string{"account", "repository", "version", "asset_type"},
-		},
-	}, s.handleGetPackageAssets)
+	s.addTool(defineTool[getPackageAssetsArgs, marketplace.AssetResponse]("get_package_assets",
+		"Get assets (documentation, icons, release notes, etc.) for a specific package version"),
+		bind(s.handleGetPackageAssets))
 
 	// Get repositories tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_repositories",
-		Description: "Get repositories for an account",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name",
-				},
-				"filter": map[string]any{
-					"type":        "string",
-					"description": "AIP-160 formatted filter (v2 only)",
-				},
-				"size": map[string]any{
-					"type":        "integer",
-					"description": "Number of results to return (max 100)",
-					"default":     20,
-				},
-				"page": map[string]any{
-					"type":        "integer",
-					"description": "Page number (0-indexed)",
-					"default":     0,
-				},
-				"use_v1": map[string]any{
-					"type":        "boolean",
-					"description": "Use v1 API instead of v2",
-					"default":     false,
-				},
-			},
-			Required: []string{"account"},
-		},
-	}, s.handleGetRepositories)
+	s.addTool(defineTool[getRepositoriesArgs, marketplace.RepositoryResponse]("get_repositories",
+		"Get repositories for an account"),
+		bind(s.handleGetRepositories))
 
 	// Get Package Version Resources tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_version_resources",
-		Description: "Get package version resources for a supplied repository name.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name. For example upbound.",
-				},
-				"repository_name": map[string]any{
-					"type":        "string",
-					"description": "The name of the repository. For example provider-aws-s3.",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "The version of the package. For example v1.23.1.",
-				},
-			},
-			Required: []string{"account", "repository_name", "version"},
-		},
-	}, s.handleGetPackagesAccountRepositoryVersionResources)
+	s.addTool(defineTool[resourceArgs, marketplace.PackageResources]("get_package_version_resources",
+		"Get package version resources for a supplied repository name."),
+		bind(s.handleGetPackagesAccountRepositoryVersionResources))
 
 	// Get Package Version Compositions Resources for Group & Kind tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_version_composition_resources",
-		Description: "Get package version composition resources for a supplied group, kind and version and composition.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name. For example upbound.",
-				},
-				"repository_name": map[string]any{
-					"type":        "string",
-					"description": "The name of the repository. For example provider-aws-s3.",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "The version of the package. For example v1.23.1.",
-				},
-				"resource_group": map[string]any{
-					"type":        "string",
-					"description": "The group of the resource. For example s3.aws.upbound.io.",
-				},
-				"resource_kind": map[string]any{
-					"type":        "string",
-					"description": "The kind of the resource. For example Bucket.",
-				},
-				"composition_name": map[string]any{
-					"type":        "string",
-					"description": "The name of the composition.",
-				},
-			},
-			Required: []string{"account", "repository_name", "version", "resource_group", "resource_kind", "composition_name"},
-		},
-	}, s.handleGetPackagesAccountRepositoryVersionResourcesGroupKindComposition)
+	s.addTool(defineTool[getCompositionArgs, Definition]("get_package_version_composition_resources",
+		"Get package version composition resources for a supplied group, kind and version and composition."),
+		bind(s.handleGetPackagesAccountRepositoryVersionResourcesGroupKindComposition))
 
 	// Get Package Version Resources for Group & Kind tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_version_groupkind_resources",
-		Description: "Get package version resources for a supplied group, kind and version.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name. For example upbound.",
-				},
-				"repository_name": map[string]any{
-					"type":        "string",
-					"description": "The name of the repository. For example provider-aws-s3.",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "The version of the package. For example v1.23.1.",
-				},
-				"resource_group": map[string]any{
-					"type":        "string",
-					"description": "The group of the resource. For example s3.aws.upbound.io.",
-				},
-				"resource_kind": map[string]any{
-					"type":        "string",
-					"description": "The kind of the resource. For example Bucket.",
-				},
-			},
-			Required: []string{"account", "repository_name", "version", "resource_group", "resource_kind"},
-		},
-	}, s.handleGetPackagesAccountRepositoryVersionResourcesGroupKind)
+	s.addTool(defineTool[getGroupKindArgs, Definition]("get_package_version_groupkind_resources",
+		"Get package version resources for a supplied group, kind and version."),
+		bind(s.handleGetPackagesAccountRepositoryVersionResourcesGroupKind))
 
 	// Get specific package examples for account / repo / version / group and kind.
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_package_version_examples",
-		Description: "Get package version examples for a supplied group, kind and version.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"account": map[string]any{
-					"type":        "string",
-					"description": "Account/organization name. For example upbound.",
-				},
-				"repository_name": map[string]any{
-					"type":        "string",
-					"description": "The name of the repository. For example provider-aws-s3.",
-				},
-				"version": map[string]any{
-					"type":        "string",
-					"description": "The version of the package. For example v1.23.1.",
-				},
-				"resource_group": map[string]any{
-					"type":        "string",
-					"description": "The group of the resource. For example s3.aws.upbound.io.",
-				},
-				"resource_kind": map[string]any{
-					"type":        "string",
-					"description": "The kind of the resource. For example Bucket.",
-				},
-			},
-			Required: []string{"account", "repository_name", "version", "resource_group", "resource_kind"},
-		},
-	}, s.handleGetPackagesAccountRepositoryVersionResourcesGroupKindExamples)
+	s.addTool(defineTool[getGroupKindArgs, marketplace.Examples]("get_package_version_examples",
+		"Get package version examples for a supplied group, kind and version."),
+		bind(s.handleGetPackagesAccountRepositoryVersionResourcesGroupKindExamples))
+
+	// Get kind context tool
+	s.addTool(defineTool[getKindContextArgs, contextpack.Pack]("get_kind_context",
+		"Get everything needed to author a resource of one kind in a single call: the fields of its spec.forProvider with the required ones marked, the best example, the package docs section about the kind, how it references a ProviderConfig and the keys of its connection secret. The result is trimmed to fit max_tokens or max_bytes, or about 4000 tokens by default."),
+		bind(s.handleGetKindContext))
+
+	// Diff package versions tool
+	s.addTool(defineTool[diffPackageVersionsArgs, diff.Report]("diff_package_versions",
+		"Compare the kinds and schemas of two versions of a package, reporting added and removed kinds, added, removed and changed fields, newly required fields, removed enum values, fields newly restricted to an enum and storage version changes, each classified as breaking or non-breaking"),
+		bind(s.handleDiffPackageVersions))
+
+	// Plan upgrade tool
+	s.addTool(defineTool[planUpgradeArgs, upgrade.Report]("plan_upgrade",
+		"Plan a package upgrade: consolidates the release notes of every version between the current and target versions into notable changes, breaking changes and deprecations, and lists the breaking schema changes between the two versions"),
+		bind(s.handlePlanUpgrade))
+
+	// Recommend providers tool
+	s.addTool(defineTool[recommendProvidersArgs, recommend.Result]("recommend_providers",
+		"Recommend the smallest set of provider family packages, with pinned versions, that serve a set of managed resource kinds given as apiVersion/kind pairs or raw manifests"),
+		bind(s.handleRecommendProviders))
+
+	// Find kind tool
+	s.addTool(defineTool[findKindArgs, KindMatches]("find_kind",
+		"Find the packages and versions that define a kind, by kind name, plural or short name, with fuzzy matching. Uses a local index of every package's kinds, which is built in the background on first use; until it is complete, results are partial and marked as building"),
+		bind(s.handleFindKind))
+
+	// Search fields tool
+	s.addTool(defineTool[searchFieldsArgs, FieldHits]("search_fields",
+		"Search the field paths and descriptions of CRD and XRD schemas by free text, returning ranked field paths with their kind, package and a description snippet. Searches the packages already in the local field index; give a package to index it first and search only its fields"),
+		bind(s.handleSearchFields))
+
+	// Get private repositories tool, available while authenticated
+	s.addTool(defineTool[getPrivateRepositoriesArgs, marketplace.RepositoryResponse]("get_private_repositories",
+		"Get the private repositories of an account that the authenticated user can read"),
+		bind(s.handleGetPrivateRepositories))
+
+	// Diff snapshots tool, available when a snapshot directory is configured
+	s.addTool(defineTool[diffSnapshotsArgs, snapshot.DiffReport]("diff_snapshots",
+		"Compare two catalog snapshots to report what changed in the marketplace between them: new, removed and deprecated packages, new versions, tier changes and download and star changes"),
+		bind(s.handleDiffSnapshots))
+
+	// Sync status tool, available when a background sync worker is configured
+	s.addTool(defineTool[struct{}, syncer.Status]("get_sync_status",
+		"Get the status of the background worker that keeps the kind and field indexes warm: the accounts it syncs, its progress through the current sync, when the last sync completed, when the next starts and the last error"),
+		bind(s.handleGetSyncStatus))
+
+	// Verify package tool
+	s.addTool(defineTool[verifyPackageArgs, verify.Result]("verify_package",
+		"Verify the cosign signatures and SLSA provenance attestations of a package version against the configured trusted public keys, and check that its registry digest matches the digest the marketplace reports"),
+		bind(s.handleVerifyPackage))
 
 	// Reload auth tool
-	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "reload_auth",
-		Description: "Reload authentication and server configuration from UP CLI configuration (useful if you switched profiles)",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				"random_string": map[string]any{
-					"type":        "string",
-					"description": "Dummy parameter for no-parameter tools",
-				},
-			},
-			Required: []string{"random_string"},
-		},
-	}, s.handleReloadAuth)
+	s.addTool(defineTool[reloadAuthArgs, AuthStatus]("reload_auth",
+		"Reload authentication and server configuration from UP CLI configuration (useful if you switched profiles)"),
+		bind(s.handleReloadAuth))
 }
@@ -17,11 +17,114 @@
 package mcp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/local"
 	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
 )
 
+// fakeCatalog serves one provider with a single kind.
+type fakeCatalog struct {
+	catalog.Catalog
+}
+
+func (fakeCatalog) GetPackageMetadata(_ context.Context, account, repo, _ string, _ bool) (*marketplace.PackageMetadata, error) {
+	return &marketplace.PackageMetadata{Account: account, Repository: repo, LatestVersion: "v1.21.0", Versions: []string{"v1.2.0", "v1.21.0", "v1.20.0"}}, nil
+}
+
+func (fakeCatalog) GetRepositories(_ context.Context, account string, _ marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	return &marketplace.RepositoryResponse{Repositories: []marketplace.Repository{
+		{Account: account, Name: "provider-aws-s3"},
+		{Account: account, Name: "provider-aws-ec2"},
+		{Account: account, Name: "provider-gcp-storage"},
+	}}, nil
+}
+
+func (fakeCatalog) SearchPackages(_ context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	resp := &marketplace.SearchResponse{}
+	for _, p := range []marketplace.Package{
+		{Account: "upbound", Repository: "provider-aws-s3", Type: "provider"},
+		{Account: "upbound", Repository: "configuration-aws-s3", Type: "configuration"},
+		{Account: "upbound", Repository: "provider-gcp-storage", Type: "provider"},
+	} {
+		matches := params.PackageType == "" || p.Type == params.PackageType
+		for _, word := range strings.Fields(params.Query) {
+			matches = matches && strings.Contains(p.Repository, word)
+		}
+		if matches {
+			resp.Packages = append(resp.Packages, p)
+		}
+	}
+	resp.Total = len(resp.Packages)
+	return resp, nil
+}
+
+func (fakeCatalog) GetPackageAssets(_ context.Context, _, _, _, _ string) (*marketplace.AssetResponse, error) {
+	return &marketplace.AssetResponse{Content: "# Bucket\n\nManages an S3 bucket."}, nil
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, account, repo, _ string) (*marketplace.PackageResources, error) {
+	return &marketplace.PackageResources{
+		PackageMeta: marketplace.PackageMeta{Account: account, Repository: repo},
+		CRDs:        []marketplace.CRDMeta{{Group: "s3.aws.upbound.io", Kind: "Bucket", StorageVersion: "v1beta1"}},
+	}, nil
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, _, _, _, group, kind string) (string, error) {
+	return fmt.Sprintf(`{"spec":{"group":%q,"names":{"kind":%q},"versions":[{"name":"v1beta1","schema":{"openAPIV3Schema":{"properties":{"spec":{"properties":{"forProvider":{"properties":{"region":{"type":"string"}}}}}}}}}]}}`, group, kind), nil
+}
+
+func (fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(_ context.Context, _, _, _, _, _ string) (*marketplace.Examples, error) {
+	return &marketplace.Examples{Examples: []string{"kind: Bucket"}}, nil
+}
+
+// newFakeServer returns a server that reads the upbound account from a fake
+// catalog.
+func newFakeServer() *Server {
+	return NewServer(marketplace.NewClient(), WithCatalog(catalog.Backend{Name: "fake", Catalog: fakeCatalog{}, Accounts: []string{"upbound"}}))
+}
+
+// call sends a JSON-RPC request to the server and decodes its result.
+func call(t *testing.T, s *Server, method string, params any, result any) {
+	t.Helper()
+	req, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s.mcpServer.HandleMessage(context.Background(), req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("%s failed: %s", method, resp.Error.Message)
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestNewServer(t *testing.T) {
 	client := marketplace.NewClient()
 	server := NewServer(client)
@@ -42,3 +145,704 @@ func TestNewServer(t *testing.T) {
 		t.Error("MCP server should not be nil")
 	}
 }
+
+func TestParsePackageURI(t *testing.T) {
+	cases := map[string]struct {
+		uri     string
+		account string
+		repo    string
+		ok      bool
+	}{
+		"Package":       {uri: "marketplace://upbound/provider-aws-s3", account: "upbound", repo: "provider-aws-s3", ok: true},
+		"OtherScheme":   {uri: "https://upbound/provider-aws-s3"},
+		"NoRepository":  {uri: "marketplace://upbound/"},
+		"TooManyLevels": {uri: "marketplace://upbound/provider-aws-s3/v1.0.0"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			account, repo, ok := parsePackageURI(tc.uri)
+			if account != tc.account || repo != tc.repo || ok != tc.ok {
+				t.Errorf("parsePackageURI(%q) = %q, %q, %t, want %q, %q, %t", tc.uri, account, repo, ok, tc.account, tc.repo, tc.ok)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHandler(t *testing.T) {
+	s := NewServer(marketplace.NewClient(), WithWatch())
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := s.SubscriptionHandler(next)
+	session := s.SessionIDManager().Generate()
+
+	cases := map[string]struct {
+		body    string
+		session string
+		status  int
+		code    int
+	}{
+		"PassesOtherMethods": {
+			body:   `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+			status: http.StatusTeapot,
+		},
+		"RequiresSession": {
+			body:   `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"marketplace://upbound/provider-aws-s3"}}`,
+			status: http.StatusOK,
+			code:   -32600,
+		},
+		"RejectsUnknownSession": {
+			body:    `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"marketplace://upbound/provider-aws-s3"}}`,
+			session: "mcp-session-forged",
+			status:  http.StatusOK,
+			code:    -32600,
+		},
+		"RejectsInvalidURI": {
+			body:    `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"marketplace://upbound"}}`,
+			session: session,
+			status:  http.StatusOK,
+			code:    -32602,
+		},
+		"Unsubscribes": {
+			body:    `{"jsonrpc":"2.0","id":1,"method":"resources/unsubscribe","params":{"uri":"marketplace://upbound/provider-aws-s3"}}`,
+			session: session,
+			status:  http.StatusOK,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tc.body))
+			if tc.session != "" {
+				req.Header.Set(headerSessionID, tc.session)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Error *struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+			}
+			code := 0
+			if resp.Error != nil {
+				code = resp.Error.Code
+			}
+			if code != tc.code {
+				t.Errorf("error code = %d, want %d", code, tc.code)
+			}
+		})
+	}
+}
+
+func TestSessionIDManager(t *testing.T) {
+	s := NewServer(marketplace.NewClient(), WithWatch())
+	m := s.sessions
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	valid := func(session string) bool {
+		terminated, err := m.Validate(session)
+		return err == nil && !terminated
+	}
+	if !valid("") {
+		t.Error("Validate() rejected a request without a session")
+	}
+	if valid("mcp-session-forged") {
+		t.Error("Validate() accepted a session it did not issue")
+	}
+
+	active, idle := m.Generate(), m.Generate()
+	if !valid(active) || !valid(idle) {
+		t.Fatal("Validate() rejected an issued session")
+	}
+	now = now.Add(sessionIdleTimeout / 2)
+	valid(active)
+	now = now.Add(sessionIdleTimeout/2 + time.Second)
+	if valid(idle) {
+		t.Error("Validate() accepted an idle session")
+	}
+	if !valid(active) {
+		t.Error("Validate() rejected an active session")
+	}
+	if _, err := m.Terminate(active); err != nil || valid(active) {
+		t.Errorf("Validate() accepted a terminated session, Terminate() error = %v", err)
+	}
+
+	first := m.Generate()
+	for range maxSessions {
+		now = now.Add(time.Millisecond)
+		m.Generate()
+	}
+	if valid(first) {
+		t.Error("Validate() accepted the least recently active session beyond the limit")
+	}
+	if len(m.sessions) != maxSessions {
+		t.Errorf("tracking %d sessions, want %d", len(m.sessions), maxSessions)
+	}
+}
+
+func TestParseResourceURI(t *testing.T) {
+	cases := map[string]struct {
+		uri     string
+		section string
+		args    []string
+		wantErr bool
+	}{
+		"Readme":       {uri: "marketplace://upbound/provider-aws-s3/v1.20.0/readme", section: "readme"},
+		"CRD":          {uri: "marketplace://upbound/provider-aws-s3/v1.20.0/crds/s3.aws.upbound.io/Bucket", section: "crds", args: []string{"s3.aws.upbound.io", "Bucket"}},
+		"Composition":  {uri: "marketplace://upbound/configuration-aws-network/latest/compositions/xnetworks.aws.platform.upbound.io", section: "compositions", args: []string{"xnetworks.aws.platform.upbound.io"}},
+		"Examples":     {uri: "marketplace://upbound/provider-aws-s3/v1.20.0/examples/s3.aws.upbound.io/Bucket", section: "examples", args: []string{"s3.aws.upbound.io", "Bucket"}},
+		"MissingKind":  {uri: "marketplace://upbound/provider-aws-s3/v1.20.0/crds/s3.aws.upbound.io", wantErr: true},
+		"EmptySegment": {uri: "marketplace://upbound//v1.20.0/readme", wantErr: true},
+		"Unknown":      {uri: "marketplace://upbound/provider-aws-s3/v1.20.0/icon", wantErr: true},
+		"OtherScheme":  {uri: "https://upbound/provider-aws-s3/v1.20.0/readme", wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			u, err := parseResourceURI(tc.uri)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseResourceURI(%q) error = %v, wantErr %t", tc.uri, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if u.resource.section != tc.section || strings.Join(u.args, ",") != strings.Join(tc.args, ",") {
+				t.Errorf("parseResourceURI(%q) = %s %v, want %s %v", tc.uri, u.resource.section, u.args, tc.section, tc.args)
+			}
+		})
+	}
+}
+
+func TestRememberPackage(t *testing.T) {
+	s := NewServer(marketplace.NewClient())
+	for i := range maxRecentPackages + 2 {
+		s.rememberPackage("upbound", fmt.Sprintf("provider-%d", i), "v1.0.0")
+	}
+	// Accessing a listed package again keeps it listed.
+	s.rememberPackage("upbound", "provider-2", "v1.0.0")
+	s.rememberPackage("upbound", "provider-extra", "v1.0.0")
+	s.rememberPackage("upbound", "provider-unversioned", "latest")
+
+	msg := s.mcpServer.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`))
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result struct {
+			Resources []struct {
+				URI string `json:"uri"`
+			} `json:"resources"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	listed := map[string]bool{}
+	for _, r := range resp.Result.Resources {
+		listed[r.URI] = true
+	}
+	if len(listed) != maxRecentPackages {
+		t.Errorf("listed %d resources, want %d", len(listed), maxRecentPackages)
+	}
+	for uri, want := range map[string]bool{
+		"marketplace://upbound/provider-0/v1.0.0/readme":     false,
+		"marketplace://upbound/provider-2/v1.0.0/readme":     true,
+		"marketplace://upbound/provider-3/v1.0.0/readme":     false,
+		"marketplace://upbound/provider-extra/v1.0.0/readme": true,
+	} {
+		if listed[uri] != want {
+			t.Errorf("listed %s = %t, want %t", uri, listed[uri], want)
+		}
+	}
+}
+
+func TestFindProviderForServicePrompt(t *testing.T) {
+	s := newFakeServer()
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "find_provider_for_service"
+	req.Params.Arguments = map[string]string{"service": "s3", "cloud": "aws"}
+	result, err := s.handleFindProviderForService(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleFindProviderForService() error = %v", err)
+	}
+
+	text, ok := result.Messages[0].Content.(mcp.TextContent)
+	if !ok {
+		t.Fatalf("message = %T, want text", result.Messages[0].Content)
+	}
+	if !strings.Contains(text.Text, "upbound/provider-aws-s3") {
+		t.Errorf("prompt does not list the matching provider:\n%s", text.Text)
+	}
+	for _, other := range []string{"upbound/configuration-aws-s3", "upbound/provider-gcp-storage"} {
+		if strings.Contains(text.Text, other) {
+			t.Errorf("prompt lists %s, which does not match both the query and the provider type", other)
+		}
+	}
+}
+
+func TestExplainManagedResourcePrompt(t *testing.T) {
+	s := newFakeServer()
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "explain_managed_resource"
+	req.Params.Arguments = map[string]string{"package": "upbound/provider-aws-s3", "kind": "bucket"}
+	result, err := s.handleExplainManagedResource(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleExplainManagedResource() error = %v", err)
+	}
+
+	if len(result.Messages) != 3 {
+		t.Fatalf("got %d messages, want the instructions, CRD and examples", len(result.Messages))
+	}
+	text, ok := result.Messages[0].Content.(mcp.TextContent)
+	if !ok || !strings.Contains(text.Text, "Bucket.s3.aws.upbound.io") || !strings.Contains(text.Text, "upbound/provider-aws-s3:v1.21.0") {
+		t.Errorf("instructions = %v, want them to name the kind and the latest version", result.Messages[0].Content)
+	}
+	crd, ok := result.Messages[1].Content.(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("second message = %T, want an embedded resource", result.Messages[1].Content)
+	}
+	res, ok := crd.Resource.(mcp.TextResourceContents)
+	if !ok || res.URI != "marketplace://upbound/provider-aws-s3/v1.21.0/crds/s3.aws.upbound.io/Bucket" || !strings.Contains(res.Text, "s3.aws.upbound.io") {
+		t.Errorf("embedded CRD = %+v", crd.Resource)
+	}
+}
+
+func TestPromptCompletion(t *testing.T) {
+	cases := map[string]struct {
+		prompt  string
+		arg     string
+		value   string
+		context map[string]string
+		want    []string
+	}{
+		"Cloud": {
+			prompt: "find_provider_for_service",
+			arg:    "cloud",
+			value:  "a",
+			want:   []string{"aws", "azure"},
+		},
+		"Repositories": {
+			prompt: "explain_managed_resource",
+			arg:    "package",
+			value:  "upbound/provider-aws",
+			want:   []string{"upbound/provider-aws-s3", "upbound/provider-aws-ec2"},
+		},
+		"Accounts": {
+			prompt: "explain_managed_resource",
+			arg:    "package",
+			value:  "upb",
+			want:   []string{"upbound/"},
+		},
+		"Kinds": {
+			prompt:  "explain_managed_resource",
+			arg:     "kind",
+			value:   "b",
+			context: map[string]string{"package": "upbound/provider-aws-s3"},
+			want:    []string{"Bucket"},
+		},
+		"Versions": {
+			prompt:  "review_package_upgrade",
+			arg:     "target_version",
+			value:   "v1.2",
+			context: map[string]string{"package": "upbound/provider-aws-s3"},
+			want:    []string{"v1.21.0", "v1.20.0", "v1.2.0"},
+		},
+	}
+	s := newFakeServer()
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var result mcp.CompleteResult
+			call(t, s, "completion/complete", map[string]any{
+				"ref":      map[string]string{"type": "ref/prompt", "name": tc.prompt},
+				"argument": map[string]string{"name": tc.arg, "value": tc.value},
+				"context":  map[string]any{"arguments": tc.context},
+			}, &result)
+			if strings.Join(result.Completion.Values, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("completion = %v, want %v", result.Completion.Values, tc.want)
+			}
+		})
+	}
+}
+
+func TestResourceCompletion(t *testing.T) {
+	crds := "marketplace://{account}/{repository}/{version}/crds/{group}/{kind}"
+	pkg := map[string]string{"account": "upbound", "repository": "provider-aws-s3", "version": "v1.21.0"}
+	cases := map[string]struct {
+		arg     string
+		value   string
+		context map[string]string
+		want    []string
+	}{
+		"Accounts": {
+			arg:   "account",
+			value: "cross",
+			want:  []string{"crossplane-contrib"},
+		},
+		"Repositories": {
+			arg:     "repository",
+			value:   "provider-g",
+			context: map[string]string{"account": "upbound"},
+			want:    []string{"provider-gcp-storage"},
+		},
+		"NoAccount": {
+			arg:   "repository",
+			value: "provider",
+			want:  []string{},
+		},
+		"Versions": {
+			arg:     "version",
+			value:   "v1.2",
+			context: map[string]string{"account": "upbound", "repository": "provider-aws-s3"},
+			want:    []string{"v1.21.0", "v1.20.0", "v1.2.0"},
+		},
+		"Groups": {
+			arg:     "group",
+			value:   "s3",
+			context: pkg,
+			want:    []string{"s3.aws.upbound.io"},
+		},
+		"KindsInGroup": {
+			arg:     "kind",
+			context: map[string]string{"account": "upbound", "repository": "provider-aws-s3", "group": "s3.aws.upbound.io"},
+			want:    []string{"Bucket"},
+		},
+		"KindsInOtherGroup": {
+			arg:     "kind",
+			context: map[string]string{"account": "upbound", "repository": "provider-aws-s3", "group": "ec2.aws.upbound.io"},
+			want:    []string{},
+		},
+	}
+	s := newFakeServer()
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var result mcp.CompleteResult
+			call(t, s, "completion/complete", map[string]any{
+				"ref":      map[string]string{"type": "ref/resource", "uri": crds},
+				"argument": map[string]string{"name": tc.arg, "value": tc.value},
+				"context":  map[string]any{"arguments": tc.context},
+			}, &result)
+			if strings.Join(result.Completion.Values, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("completion = %v, want %v", result.Completion.Values, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompletionCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := completionCache{ttl: time.Minute, now: func() time.Time { return now }}
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"a"}, nil
+	}
+	fail := func() ([]string, error) {
+		loads++
+		return nil, errors.New("unavailable")
+	}
+
+	c.get("key", load)
+	c.get("key", load)
+	if loads != 1 {
+		t.Errorf("loads within the TTL = %d, want 1", loads)
+	}
+	now = now.Add(2 * time.Minute)
+	c.get("key", load)
+	if loads != 2 {
+		t.Errorf("loads after the TTL = %d, want 2", loads)
+	}
+	c.get("other", fail)
+	if got := c.get("other", load); len(got) != 1 || loads != 4 {
+		t.Errorf("get() after a failed load = %v with %d loads, want a fresh load", got, loads)
+	}
+
+	for i := range maxCompletionEntries + 10 {
+		c.get(fmt.Sprint(i), load)
+		now = now.Add(time.Millisecond)
+	}
+	if len(c.entries) != maxCompletionEntries {
+		t.Errorf("cache holds %d entries, want at most %d", len(c.entries), maxCompletionEntries)
+	}
+	if _, ok := c.entries["0"]; ok {
+		t.Error("cache kept the entry closest to expiring beyond its bound")
+	}
+	now = now.Add(2 * time.Minute)
+	c.get("last", load)
+	if len(c.entries) != 1 {
+		t.Errorf("cache holds %d entries after they expired, want 1", len(c.entries))
+	}
+}
+
+// pagedCatalog serves an account with more repositories than fit on a page.
+type pagedCatalog struct {
+	fakeCatalog
+}
+
+func (pagedCatalog) GetRepositories(_ context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	const total = 250
+	resp := &marketplace.RepositoryResponse{Count: total}
+	for i := params.Page * params.Size; i < min(total, (params.Page+1)*params.Size); i++ {
+		resp.Repositories = append(resp.Repositories, marketplace.Repository{Account: account, Name: fmt.Sprintf("provider-%03d", i)})
+	}
+	return resp, nil
+}
+
+func TestCompleteRepositoriesBeyondFirstPage(t *testing.T) {
+	s := NewServer(marketplace.NewClient(), WithCatalog(catalog.Backend{Name: "fake", Catalog: pagedCatalog{}, Accounts: []string{"upbound"}}))
+	got := completion(newCompleter(s).packages(context.Background(), "upbound/provider-2"), "upbound/provider-2")
+	if got.Total != 50 || got.Values[0] != "upbound/provider-200" {
+		t.Errorf("completion = %d values starting %v, want the 50 repositories on the last page", got.Total, got.Values[:min(len(got.Values), 1)])
+	}
+}
+
+func TestArgumentValidation(t *testing.T) {
+	cases := map[string]struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		"MissingRequired": {
+			tool: "get_package_version_groupkind_resources",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0", "resource_group": "s3.aws.upbound.io"},
+			want: "invalid argument resource_kind: is required",
+		},
+		"NotInEnum": {
+			tool: "get_package_assets",
+			args: map[string]any{"package": "upbound/provider-aws-s3:v1.21.0", "asset_type": "manual"},
+			want: "invalid argument asset_type: must be one of docs, icon, readme, releaseNotes, sbom",
+		},
+		"AboveMaximum": {
+			tool: "get_repositories",
+			args: map[string]any{"account": "upbound", "size": 101},
+			want: "invalid argument size: must be at most 100",
+		},
+		"WrongType": {
+			tool: "recommend_providers",
+			args: map[string]any{"resources": []any{map[string]any{"apiVersion": "s3.aws.upbound.io/v1beta1", "kind": 1}}},
+			want: "invalid argument resources[0].kind: must be a string",
+		},
+	}
+	s := newFakeServer()
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = tc.tool
+			req.Params.Arguments = tc.args
+			result, err := s.mcpServer.GetTool(tc.tool).Handler(context.Background(), req)
+			if err == nil || !result.IsError {
+				t.Fatalf("%s error = %v, want an error result", tc.tool, err)
+			}
+			if text, ok := result.Content[0].(mcp.TextContent); !ok || text.Text != tc.want {
+				t.Errorf("%s result = %v, want %q", tc.tool, result.Content[0], tc.want)
+			}
+		})
+	}
+}
+
+func TestFindKind(t *testing.T) {
+	mp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mp.Close()
+	client := marketplace.NewClient()
+	client.SetBaseURL(mp.URL)
+	s := NewServer(client, WithCatalog(catalog.Backend{Name: "fake", Catalog: fakeCatalog{}, Accounts: []string{"upbound"}}))
+
+	// A package indexed by the background sync does not make the index
+	// complete.
+	s.kinds.Put("upbound", "provider-aws-ec2", "v1.21.0", &marketplace.PackageResources{
+		CRDs: []marketplace.CRDMeta{{Group: "ec2.aws.upbound.io", Kind: "Instance"}},
+	})
+
+	tool := s.mcpServer.GetTool("find_kind")
+	find := func(query string) KindMatches {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "find_kind"
+		req.Params.Arguments = map[string]any{"query": query}
+		result, err := tool.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("find_kind error = %v", err)
+		}
+		m, ok := result.StructuredContent.(KindMatches)
+		if !ok {
+			t.Fatalf("find_kind structured content = %T, want KindMatches", result.StructuredContent)
+		}
+		return m
+	}
+
+	if got := find("instance"); !got.Building || len(got.Matches) != 1 {
+		t.Errorf("find_kind during the build = %+v, want the partial index marked as building", got)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		s.kindsMu.Lock()
+		building := s.kindsBuilding
+		s.kindsMu.Unlock()
+		if !building {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("kind index build did not finish")
+		}
+	}
+	if !s.kinds.Complete() {
+		t.Fatal("kind index is not complete after the build")
+	}
+	got := find("bucket")
+	if got.Building || len(got.Matches) != 1 || len(got.Matches[0].Packages) != 3 {
+		t.Errorf("find_kind after the build = %+v, want Bucket in every fake package", got)
+	}
+}
+
+// lockCheckingCatalog reports reads made while the server holds indexMu.
+type lockCheckingCatalog struct {
+	fakeCatalog
+	s      *Server
+	locked bool
+}
+
+func (c *lockCheckingCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, account, repo, version, group, kind string) (string, error) {
+	if !c.s.indexMu.TryLock() {
+		c.locked = true
+	} else {
+		c.s.indexMu.Unlock()
+	}
+	return c.fakeCatalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repo, version, group, kind)
+}
+
+func TestServerIndexerFetchesUnlocked(t *testing.T) {
+	s := newFakeServer()
+	c := &lockCheckingCatalog{s: s}
+	i := &serverIndexer{s: s}
+	if err := i.Index(context.Background(), c, "upbound", "provider-aws-s3", "v1.21.0"); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	if c.locked {
+		t.Error("Index() fetched definitions while holding indexMu")
+	}
+	if !i.Indexed("upbound", "provider-aws-s3", "v1.21.0") {
+		t.Error("Indexed() = false after Index()")
+	}
+}
+
+func TestReloadAuth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("UP_CONFIG_PATH", path)
+	write := func(config string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"upbound":{"default":"default","profiles":{"default":{"session":"token","domain":"https://upbound.example.com"}}}}`)
+
+	client := marketplace.NewClient()
+	s := NewServer(client)
+	tool := s.mcpServer.GetTool("reload_auth")
+	reload := func() *mcp.CallToolResult {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "reload_auth"
+		result, err := tool.Handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("reload_auth error = %v", err)
+		}
+		return result
+	}
+
+	cases := []struct {
+		name      string
+		config    string
+		wantToken string
+		wantError bool
+	}{
+		{name: "Loaded", config: `{"upbound":{"default":"default","profiles":{"default":{"session":"rotated","domain":"https://upbound.example.com"}}}}`, wantToken: "rotated"},
+		{name: "UnreadableKeepsToken", config: `{"upbound":`, wantToken: "rotated", wantError: true},
+		{name: "LoggedOutClearsToken", config: `{"upbound":{"default":"default","profiles":{"default":{}}}}`, wantError: true},
+	}
+	for _, tc := range cases {
+		write(tc.config)
+		result := reload()
+		if result.IsError != tc.wantError {
+			t.Errorf("%s: reload_auth IsError = %t, want %t", tc.name, result.IsError, tc.wantError)
+		}
+		if client.Token != tc.wantToken {
+			t.Errorf("%s: token = %q, want %q", tc.name, client.Token, tc.wantToken)
+		}
+	}
+
+	write(`{"upbound":{"default":"default","profiles":{"default":{"session":"token","domain":"https://%zz"}}}}`)
+	status, ok := reload().StructuredContent.(AuthStatus)
+	if !ok || !status.Loaded || !strings.Contains(status.Warning, "server URL not reloaded") {
+		t.Errorf("reload_auth with an invalid domain = %+v, want the token loaded with a warning", status)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("stale")
+	if reload(); client.Token != "" {
+		t.Errorf("token = %q after the UP CLI config was removed, want none", client.Token)
+	}
+}
+
+func TestGetPrivateRepositories(t *testing.T) {
+	s := NewServer(marketplace.NewClient(),
+		WithCatalog(catalog.Backend{Name: "fake", Catalog: fakeCatalog{}, Accounts: []string{"upbound"}}),
+		WithCatalog(catalog.Backend{Name: "registry", Catalog: catalog.NewPackageCatalog(local.NewStore()), Accounts: []string{"acme"}}),
+	)
+
+	cases := map[string]struct {
+		args    getPrivateRepositoriesArgs
+		want    int
+		wantErr error
+	}{
+		"Account": {
+			args: getPrivateRepositoriesArgs{Account: "upbound"},
+			want: 3,
+		},
+		"Package": {
+			args: getPrivateRepositoriesArgs{referenceArg: referenceArg{Package: "upbound/provider-aws-s3"}},
+			want: 3,
+		},
+		"NoAccount": {
+			wantErr: errors.New("account parameter is required"),
+		},
+		"UnfilteredCatalog": {
+			args:    getPrivateRepositoriesArgs{Account: "acme"},
+			wantErr: catalog.ErrFilterUnsupported,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "get_private_repositories"
+			result, err := s.handleGetPrivateRepositories(context.Background(), req, tc.args)
+			if tc.wantErr != nil {
+				if err == nil || (!errors.Is(err, tc.wantErr) && err.Error() != tc.wantErr.Error()) || !result.IsError {
+					t.Errorf("get_private_repositories error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get_private_repositories error = %v", err)
+			}
+			repos, ok := result.StructuredContent.(*marketplace.RepositoryResponse)
+			if !ok {
+				t.Fatalf("structured content = %T, want *RepositoryResponse", result.StructuredContent)
+			}
+			if len(repos.Repositories) != tc.want {
+				t.Errorf("get_private_repositories returned %d repositories, want %d", len(repos.Repositories), tc.want)
+			}
+		})
+	}
+}
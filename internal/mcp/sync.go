@@ -0,0 +1,99 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/fields"
+	"github.com/upbound/marketplace-mcp-server/internal/syncer"
+)
+
+// WithSync configures a background worker that keeps the kind and field
+// indexes warm by periodically syncing the packages of the supplied accounts.
+// The worker only runs once RunSync is called.
+func WithSync(accounts []string, opts ...syncer.Option) Option {
+	return func(s *Server) {
+		s.syncAccounts = accounts
+		s.syncOpts = opts
+	}
+}
+
+// RunSync runs the background sync worker until the context is cancelled. It
+// returns immediately if no worker is configured.
+func (s *Server) RunSync(ctx context.Context) {
+	if s.sync != nil {
+		s.sync.Run(ctx)
+	}
+}
+
+// SyncStatus returns the status of the background sync worker, and false if
+// no worker is configured.
+func (s *Server) SyncStatus() (syncer.Status, bool) {
+	if s.sync == nil {
+		return syncer.Status{}, false
+	}
+	return s.sync.Status(), true
+}
+
+// newSyncWorker returns a worker that syncs into the server's indexes,
+// persisting its progress alongside them.
+func (s *Server) newSyncWorker() *syncer.Worker {
+	opts := s.syncOpts
+	if s.cacheDir != "" {
+		opts = append([]syncer.Option{syncer.WithProgressFile(filepath.Join(s.cacheDir, "sync.json"))}, opts...)
+	}
+	return syncer.NewWorker(s.catalog, &serverIndexer{s: s}, s.syncAccounts, opts...)
+}
+
+// serverIndexer indexes package versions into the server's kind and field
+// indexes.
+type serverIndexer struct {
+	s *Server
+}
+
+func (i *serverIndexer) Indexed(account, repository, version string) bool {
+	kv, _ := i.s.kinds.Version(account, repository)
+	fv, _ := i.s.fields.Version(account, repository)
+	return kv == version && fv == version
+}
+
+func (i *serverIndexer) Index(ctx context.Context, c catalog.Catalog, account, repository, version string) error {
+	res, err := c.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repository, version)
+	if err != nil {
+		return fmt.Errorf("failed to get resources: %w", err)
+	}
+	fs, err := fields.Fetch(ctx, c, account, repository, version, res)
+	if err != nil {
+		return err
+	}
+
+	// Both indexes move to the version together, once it has been fetched.
+	i.s.indexMu.Lock()
+	defer i.s.indexMu.Unlock()
+	i.s.kinds.Put(account, repository, version, res)
+	i.s.fields.Put(account, repository, version, fs)
+	return nil
+}
+
+func (i *serverIndexer) Save() error {
+	return errors.Join(i.s.kinds.Save(), i.s.fields.Save())
+}
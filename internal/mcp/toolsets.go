@@ -0,0 +1,309 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Toolsets group the tools that can be enabled or disabled together.
+const (
+	// ToolsetDiscovery finds packages, kinds and fields.
+	ToolsetDiscovery = "discovery"
+
+	// ToolsetSchema reads the kinds, compositions and examples a package
+	// version defines.
+	ToolsetSchema = "schema"
+
+	// ToolsetUpgrade compares package versions and plans upgrades.
+	ToolsetUpgrade = "upgrade"
+
+	// ToolsetVerification verifies package signatures and provenance.
+	ToolsetVerification = "verification"
+
+	// ToolsetPrivate reads the private packages of the authenticated user.
+	// It is only available while the server is authenticated.
+	ToolsetPrivate = "private"
+
+	// ToolsetSnapshots compares catalog snapshots. It is only available when
+	// a snapshot directory is configured.
+	ToolsetSnapshots = "snapshots"
+
+	// ToolsetSync reports on the background sync worker. It is only
+	// available when the worker is configured.
+	ToolsetSync = "sync"
+
+	// ToolsetOperations manages the server itself.
+	ToolsetOperations = "operations"
+)
+
+// toolsetConditions are the server states toolsets require. A toolset without
+// a condition is always available.
+var toolsetConditions = map[string]func(s *Server) bool{
+	ToolsetPrivate:   (*Server).authenticated,
+	ToolsetSnapshots: func(s *Server) bool { return s.snapshotDir != "" },
+	ToolsetSync:      func(s *Server) bool { return s.sync != nil },
+}
+
+// Names that select tools by their annotations rather than their toolset.
+const (
+	// AllTools selects every tool.
+	AllTools = "all"
+
+	// ReadOnlyTools selects the tools that do not modify their environment.
+	ReadOnlyTools = "read-only"
+)
+
+// Annotations shared by tools.
+var (
+	// catalogRead annotates a tool that reads catalogs or registries.
+	catalogRead = mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(true),
+		DestructiveHint: mcp.ToBoolPtr(false),
+		IdempotentHint:  mcp.ToBoolPtr(true),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	}
+
+	// localRead annotates a tool that only reads the server's own state.
+	localRead = mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(true),
+		DestructiveHint: mcp.ToBoolPtr(false),
+		IdempotentHint:  mcp.ToBoolPtr(true),
+		OpenWorldHint:   mcp.ToBoolPtr(false),
+	}
+
+	// localUpdate annotates a tool that updates the server's own state,
+	// without destroying anything.
+	localUpdate = mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(false),
+		DestructiveHint: mcp.ToBoolPtr(false),
+		IdempotentHint:  mcp.ToBoolPtr(true),
+		OpenWorldHint:   mcp.ToBoolPtr(false),
+	}
+)
+
+// toolSpec is the toolset and annotations of a tool.
+type toolSpec struct {
+	toolset     string
+	annotations mcp.ToolAnnotation
+}
+
+// toolSpecs are the toolsets and annotations of every tool.
+var toolSpecs = map[string]toolSpec{
+	"search_packages":      {ToolsetDiscovery, catalogRead},
+	"get_package_metadata": {ToolsetDiscovery, catalogRead},
+	"get_package_assets":   {ToolsetDiscovery, catalogRead},
+	"get_repositories":     {ToolsetDiscovery, catalogRead},
+	"recommend_providers":  {ToolsetDiscovery, catalogRead},
+	"find_kind":            {ToolsetDiscovery, catalogRead},
+	"search_fields":        {ToolsetDiscovery, catalogRead},
+
+	"get_package_version_resources":             {ToolsetSchema, catalogRead},
+	"get_package_version_composition_resources": {ToolsetSchema, catalogRead},
+	"get_package_version_groupkind_resources":   {ToolsetSchema, catalogRead},
+	"get_package_version_examples":              {ToolsetSchema, catalogRead},
+	"get_kind_context":                          {ToolsetSchema, catalogRead},
+
+	"diff_package_versions": {ToolsetUpgrade, catalogRead},
+	"plan_upgrade":          {ToolsetUpgrade, catalogRead},
+
+	"verify_package": {ToolsetVerification, catalogRead},
+
+	"get_private_repositories": {ToolsetPrivate, catalogRead},
+
+	"diff_snapshots": {ToolsetSnapshots, localRead},
+
+	"get_sync_status": {ToolsetSync, localRead},
+
+	"reload_auth": {ToolsetOperations, localUpdate},
+}
+
+// ToolFilter selects the tools a server registers. Each entry names a tool,
+// a toolset, AllTools or ReadOnlyTools.
+type ToolFilter struct {
+	// Enabled tools are registered. When empty, every tool is enabled.
+	Enabled []string `yaml:"enabled,omitempty"`
+
+	// Disabled tools are not registered, even if they are enabled.
+	Disabled []string `yaml:"disabled,omitempty"`
+}
+
+// Validate returns an error if the filter names an unknown tool or toolset.
+func (f ToolFilter) Validate() error {
+	for _, n := range slices.Concat(f.Enabled, f.Disabled) {
+		if !knownToolName(n) {
+			return fmt.Errorf("unknown tool or toolset %q, expected a tool name, one of the toolsets %s, %s or %s", n, strings.Join(toolsetNames(), ", "), AllTools, ReadOnlyTools)
+		}
+	}
+	return nil
+}
+
+// Allows returns true if the filter enables a tool.
+func (f ToolFilter) Allows(tool string) bool {
+	enabled := len(f.Enabled) == 0 || slices.ContainsFunc(f.Enabled, func(n string) bool { return selects(n, tool) })
+	disabled := slices.ContainsFunc(f.Disabled, func(n string) bool { return selects(n, tool) })
+	return enabled && !disabled
+}
+
+// selects returns true if a tool or toolset name selects a tool.
+func selects(name, tool string) bool {
+	spec := toolSpecs[tool]
+	switch name {
+	case AllTools:
+		return true
+	case ReadOnlyTools:
+		return spec.annotations.ReadOnlyHint != nil && *spec.annotations.ReadOnlyHint
+	case tool, spec.toolset:
+		return true
+	}
+	return false
+}
+
+// knownToolName returns true if name is a tool, a toolset, AllTools or
+// ReadOnlyTools.
+func knownToolName(name string) bool {
+	if _, ok := toolSpecs[name]; ok {
+		return true
+	}
+	return name == AllTools || name == ReadOnlyTools || slices.Contains(toolsetNames(), name)
+}
+
+// toolsetNames returns the names of the toolsets, sorted.
+func toolsetNames() []string {
+	var out []string
+	for _, spec := range toolSpecs {
+		if !slices.Contains(out, spec.toolset) {
+			out = append(out, spec.toolset)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LoadToolFilter reads a tool filter from a YAML file with enabled and
+// disabled lists.
+func LoadToolFilter(path string) (ToolFilter, error) {
+	var f ToolFilter
+	b, err := os.ReadFile(path) //nolint:gosec // The path is configured by the operator.
+	if err != nil {
+		return f, fmt.Errorf("failed to read tool config: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return f, fmt.Errorf("failed to parse tool config %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// authenticated returns true if the server has a marketplace token.
+func (s *Server) authenticated() bool {
+	return s.client.Token != ""
+}
+
+// RefreshTools registers the tools whose toolsets are available in the
+// server's current state, such as whether it is authenticated, and removes
+// the others. Clients are sent notifications/tools/list_changed when the list
+// of tools changes.
+func (s *Server) RefreshTools() {
+	s.toolsMu.Lock()
+	defer s.toolsMu.Unlock()
+
+	var add []server.ServerTool
+	var remove []string
+	for name, t := range s.tools {
+		registered := s.mcpServer.GetTool(name) != nil
+		available := true
+		if cond, ok := toolsetConditions[toolSpecs[name].toolset]; ok {
+			available = cond(s)
+		}
+		switch {
+		case available && !registered:
+			add = append(add, t)
+		case !available && registered:
+			remove = append(remove, name)
+		}
+	}
+	if len(add) > 0 {
+		s.mcpServer.AddTools(add...)
+	}
+	if len(remove) > 0 {
+		s.mcpServer.DeleteTools(remove...)
+	}
+}
+
+// WithToolFilter only registers the tools the supplied filter allows.
+func WithToolFilter(f ToolFilter) Option {
+	return func(s *Server) {
+		s.toolFilter = f
+	}
+}
+
+// ToolFlags are the command line flags that select the tools a server
+// registers. Each flag defaults to the value of its environment variable.
+type ToolFlags struct {
+	Config   string
+	Enabled  string
+	Disabled string
+}
+
+// Register registers the flags with a flag set.
+func (t *ToolFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&t.Config, "tools-config", os.Getenv(EnvToolsConfig), "YAML file with enabled and disabled lists of tools and toolsets")
+	fs.StringVar(&t.Enabled, "enable-tools", os.Getenv(EnvEnabledTools), "Comma separated tools and toolsets to enable; all are enabled when empty")
+	fs.StringVar(&t.Disabled, "disable-tools", os.Getenv(EnvDisabledTools), "Comma separated tools and toolsets to disable")
+}
+
+// Option returns the option that applies the flags. The enabled and disabled
+// lists, when set, replace those of the config file.
+func (t *ToolFlags) Option() (Option, error) {
+	var f ToolFilter
+	if t.Config != "" {
+		var err error
+		if f, err = LoadToolFilter(t.Config); err != nil {
+			return nil, err
+		}
+	}
+	if l := splitList(t.Enabled); len(l) > 0 {
+		f.Enabled = l
+	}
+	if l := splitList(t.Disabled); len(l) > 0 {
+		f.Disabled = l
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return WithToolFilter(f), nil
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, e := range strings.Split(v, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,195 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+func TestToolAnnotations(t *testing.T) {
+	s := NewServer(marketplace.NewClient(), WithSync([]string{"upbound"}), WithSnapshotDir(t.TempDir()))
+	for name, tool := range s.mcpServer.ListTools() {
+		a := tool.Tool.Annotations
+		if a.ReadOnlyHint == nil || a.DestructiveHint == nil || a.IdempotentHint == nil || a.OpenWorldHint == nil {
+			t.Errorf("tool %s annotations = %+v, want every hint set", name, a)
+		}
+	}
+	if len(s.tools) != len(toolSpecs) {
+		t.Errorf("defined %d tools, want all %d tools with a toolset", len(s.tools), len(toolSpecs))
+	}
+}
+
+func TestToolFilter(t *testing.T) {
+	cases := map[string]struct {
+		filter ToolFilter
+		want   []string
+		absent []string
+	}{
+		"Default": {
+			want: []string{"search_packages", "get_kind_context", "reload_auth"},
+		},
+		"Toolset": {
+			filter: ToolFilter{Enabled: []string{ToolsetSchema}},
+			want:   []string{"get_package_version_groupkind_resources", "get_kind_context"},
+			absent: []string{"search_packages", "reload_auth"},
+		},
+		"ReadOnly": {
+			filter: ToolFilter{Enabled: []string{ReadOnlyTools}},
+			want:   []string{"search_packages", "diff_snapshots"},
+			absent: []string{"reload_auth"},
+		},
+		"ToolsetWithoutTool": {
+			filter: ToolFilter{Enabled: []string{ToolsetSchema, "search_packages"}, Disabled: []string{"get_package_version_composition_resources"}},
+			want:   []string{"search_packages", "get_package_version_examples"},
+			absent: []string{"get_package_version_composition_resources", "find_kind"},
+		},
+		"Disabled": {
+			filter: ToolFilter{Disabled: []string{ToolsetOperations, ToolsetSnapshots}},
+			want:   []string{"search_packages"},
+			absent: []string{"reload_auth", "diff_snapshots"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(marketplace.NewClient(), WithSnapshotDir(t.TempDir()), WithToolFilter(tc.filter))
+			tools := s.mcpServer.ListTools()
+			for _, n := range tc.want {
+				if _, ok := tools[n]; !ok {
+					t.Errorf("tool %s not registered", n)
+				}
+			}
+			for _, n := range tc.absent {
+				if _, ok := tools[n]; ok {
+					t.Errorf("tool %s registered", n)
+				}
+			}
+		})
+	}
+}
+
+func TestToolFlags(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(config, []byte("enabled: [schema]\ndisabled: [get_kind_context]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]struct {
+		flags   ToolFlags
+		want    ToolFilter
+		wantErr bool
+	}{
+		"Config": {
+			flags: ToolFlags{Config: config},
+			want:  ToolFilter{Enabled: []string{"schema"}, Disabled: []string{"get_kind_context"}},
+		},
+		"FlagsOverrideConfig": {
+			flags: ToolFlags{Config: config, Enabled: "read-only, reload_auth"},
+			want:  ToolFilter{Enabled: []string{"read-only", "reload_auth"}, Disabled: []string{"get_kind_context"}},
+		},
+		"Unknown": {
+			flags:   ToolFlags{Disabled: "get_everything"},
+			wantErr: true,
+		},
+		"MissingConfig": {
+			flags:   ToolFlags{Config: filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o, err := tc.flags.Option()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Option() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			s := &Server{}
+			o(s)
+			if !slices.Equal(s.toolFilter.Enabled, tc.want.Enabled) || !slices.Equal(s.toolFilter.Disabled, tc.want.Disabled) {
+				t.Errorf("Option() filter = %+v, want %+v", s.toolFilter, tc.want)
+			}
+		})
+	}
+}
+
+// fakeSession is an initialized client session that records notifications.
+type fakeSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (fakeSession) Initialize()       {}
+func (fakeSession) Initialized() bool { return true }
+func (fakeSession) SessionID() string { return "fake" }
+func (f fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return f.notifications
+}
+
+func TestRefreshTools(t *testing.T) {
+	client := marketplace.NewClient()
+	s := NewServer(client)
+	// Start unauthenticated, even if an UP CLI profile was loaded.
+	client.SetToken("")
+	s.RefreshTools()
+	session := fakeSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	registered := func() bool { return s.mcpServer.GetTool("get_private_repositories") != nil }
+	notified := func() bool {
+		select {
+		case n := <-session.notifications:
+			return n.Method == mcp.MethodNotificationToolsListChanged
+		default:
+			return false
+		}
+	}
+
+	if registered() {
+		t.Fatal("get_private_repositories registered without authentication")
+	}
+
+	client.SetToken("token")
+	s.RefreshTools()
+	if !registered() {
+		t.Error("get_private_repositories not registered after authenticating")
+	}
+	if !notified() {
+		t.Error("no tools/list_changed notification after authenticating")
+	}
+
+	s.RefreshTools()
+	if notified() {
+		t.Error("tools/list_changed notification without a change")
+	}
+
+	client.SetToken("")
+	s.RefreshTools()
+	if registered() {
+		t.Error("get_private_repositories still registered after losing authentication")
+	}
+	if !notified() {
+		t.Error("no tools/list_changed notification after losing authentication")
+	}
+}
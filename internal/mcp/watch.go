@@ -0,0 +1,250 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/upbound/marketplace-mcp-server/internal/watch"
+)
+
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+
+	// headerSessionID is the header streamable HTTP clients identify their
+	// session with.
+	headerSessionID = "Mcp-Session-Id"
+
+	// maxRequestSize bounds the JSON-RPC requests SubscriptionHandler reads.
+	maxRequestSize = 4 << 20
+
+	// maxSessions bounds the sessions tracked at once. Issuing a session
+	// beyond it ends the least recently active one.
+	maxSessions = 1000
+
+	// sessionIdleTimeout is how long a session may go without a request
+	// before it expires, losing its subscriptions.
+	sessionIdleTimeout = time.Hour
+)
+
+// WithWatch makes packages resources that clients may subscribe to, and
+// notifies subscribers when a new version of a package is released. Packages
+// are only polled once RunWatch is called.
+func WithWatch(opts ...watch.Option) Option {
+	return func(s *Server) {
+		s.watchEnabled = true
+		s.watchOpts = opts
+	}
+}
+
+// Watching returns true if clients may subscribe to packages.
+func (s *Server) Watching() bool {
+	return s.watcher != nil
+}
+
+// RunWatch polls subscribed packages until the context is cancelled. It
+// returns immediately if watching is not configured.
+func (s *Server) RunWatch(ctx context.Context) {
+	if s.watcher != nil {
+		s.watcher.Run(ctx)
+	}
+}
+
+// newWatcher returns a watcher that reads packages from the server's catalog
+// and notifies subscribers through the MCP server.
+func (s *Server) newWatcher() *watch.Watcher {
+	return watch.NewWatcher(s.catalog, func(session, uri string) error {
+		return s.mcpServer.SendNotificationToSpecificClient(session, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}, s.watchOpts...)
+}
+
+// SubscriptionHandler handles resources/subscribe and resources/unsubscribe
+// requests, which the MCP server does not route, and passes every other
+// request to next. Subscriptions belong to the session named by the
+// Mcp-Session-Id header. It returns next unchanged if watching is not
+// configured.
+func (s *Server) SubscriptionHandler(next http.Handler) http.Handler {
+	if s.watcher == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req struct {
+			ID     mcp.RequestId `json:"id"`
+			Method string        `json:"method"`
+			Params struct {
+				URI string `json:"uri"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(body, &req) != nil || (req.Method != methodSubscribe && req.Method != methodUnsubscribe) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeJSONRPC(w, s.handleSubscription(r.Context(), r.Header.Get(headerSessionID), req.ID, req.Method, req.Params.URI))
+	})
+}
+
+// handleSubscription subscribes or unsubscribes a session, returning the
+// JSON-RPC response to send.
+func (s *Server) handleSubscription(ctx context.Context, session string, id mcp.RequestId, method, uri string) any {
+	if session == "" {
+		return mcp.NewJSONRPCError(id, mcp.INVALID_REQUEST, "resource subscriptions require an "+headerSessionID+" header", nil)
+	}
+	if !s.sessions.touch(session) {
+		return mcp.NewJSONRPCError(id, mcp.INVALID_REQUEST, "unknown or expired session: initialize a new session to subscribe", nil)
+	}
+	account, repository, ok := parsePackageURI(uri)
+	if !ok {
+		return mcp.NewJSONRPCError(id, mcp.INVALID_PARAMS, "invalid package URI, expected "+packageURITemplate, nil)
+	}
+	if method == methodUnsubscribe {
+		s.watcher.Unsubscribe(session, uri)
+		return mcp.NewJSONRPCResponse(id, mcp.Result{})
+	}
+	if err := s.watcher.Subscribe(ctx, session, uri, account, repository); err != nil {
+		return mcp.NewJSONRPCError(id, mcp.INVALID_PARAMS, err.Error(), nil)
+	}
+	return mcp.NewJSONRPCResponse(id, mcp.Result{})
+}
+
+func writeJSONRPC(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: Failed to write JSON-RPC response: %v", err)
+	}
+}
+
+// SessionIDManager returns a session ID manager for the streamable HTTP
+// server. It issues session IDs so that clients can subscribe to packages,
+// but like a stateless server accepts requests without one. Requests naming a
+// session it did not issue, or one that expired after sessionIdleTimeout, are
+// rejected. Terminating or expiring a session removes its subscriptions.
+func (s *Server) SessionIDManager() server.SessionIdManager {
+	return s.sessions
+}
+
+// sessionIDManager tracks the sessions it issued and when each was last
+// active. It is safe for concurrent use.
+type sessionIDManager struct {
+	s   *Server
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]time.Time
+}
+
+func newSessionIDManager(s *Server) *sessionIDManager {
+	return &sessionIDManager{s: s, now: time.Now, sessions: map[string]time.Time{}}
+}
+
+func (m *sessionIDManager) Generate() string {
+	session := "mcp-session-" + uuid.NewString()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ended := m.expire()
+	if len(m.sessions) >= maxSessions {
+		oldest := ""
+		for id, seen := range m.sessions {
+			if oldest == "" || seen.Before(m.sessions[oldest]) {
+				oldest = id
+			}
+		}
+		delete(m.sessions, oldest)
+		ended = append(ended, oldest)
+	}
+	m.sessions[session] = m.now()
+	m.end(ended...)
+	return session
+}
+
+func (m *sessionIDManager) Validate(session string) (bool, error) {
+	if session == "" {
+		return false, nil
+	}
+	if !m.touch(session) {
+		return false, fmt.Errorf("unknown or expired session %q", session)
+	}
+	return false, nil
+}
+
+func (m *sessionIDManager) Terminate(session string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, session)
+	m.end(session)
+	return false, nil
+}
+
+// touch records activity in a session, returning false if the session is
+// unknown or expired.
+func (m *sessionIDManager) touch(session string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.end(m.expire()...)
+	if _, ok := m.sessions[session]; !ok {
+		return false
+	}
+	m.sessions[session] = m.now()
+	return true
+}
+
+// expire forgets the sessions that have been idle for longer than
+// sessionIdleTimeout, returning them. The caller must hold the lock.
+func (m *sessionIDManager) expire() []string {
+	var expired []string
+	for id, seen := range m.sessions {
+		if m.now().Sub(seen) > sessionIdleTimeout {
+			delete(m.sessions, id)
+			expired = append(expired, id)
+		}
+	}
+	return expired
+}
+
+// end removes the subscriptions of sessions that are no longer tracked.
+func (m *sessionIDManager) end(sessions ...string) {
+	if m.s.watcher == nil {
+		return
+	}
+	for _, id := range sessions {
+		m.s.watcher.RemoveSession(id)
+	}
+}
@@ -0,0 +1,74 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GroupKind identifies a kind of resource.
+type GroupKind struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+}
+
+// String returns the group kind as Kind.group.
+func (gk GroupKind) String() string {
+	return gk.Kind + "." + gk.Group
+}
+
+// ParseGroupKind returns the group kind of an apiVersion and kind.
+func ParseGroupKind(apiVersion, kind string) (GroupKind, error) {
+	group, _, ok := strings.Cut(apiVersion, "/")
+	if !ok || group == "" || kind == "" {
+		return GroupKind{}, fmt.Errorf("%q %q is not a managed resource kind: expected an apiVersion of the form <group>/<version> and a kind", apiVersion, kind)
+	}
+	return GroupKind{Group: group, Kind: kind}, nil
+}
+
+// ParseManifests returns the group kinds of the resources in a YAML stream.
+// Documents without an apiVersion and kind are ignored.
+func ParseManifests(manifests string) ([]GroupKind, error) {
+	dec := yaml.NewDecoder(strings.NewReader(manifests))
+	var out []GroupKind
+	for {
+		var doc struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+		}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifests: %w", err)
+		}
+		if doc.APIVersion == "" || doc.Kind == "" {
+			continue
+		}
+		gk, err := ParseGroupKind(doc.APIVersion, doc.Kind)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, gk)
+	}
+}
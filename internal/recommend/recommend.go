@@ -0,0 +1,203 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// maxCandidates bounds the search results inspected for each group.
+const maxCandidates = 5
+
+// Provider is a recommended provider package.
+type Provider struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	Version    string `json:"version"`
+
+	// Family is the repository key of the provider's family, if it belongs
+	// to one. Family members install the family's configuration provider as
+	// a dependency.
+	Family string `json:"family,omitempty"`
+
+	// CRDCount is the number of CRDs the provider installs.
+	CRDCount int `json:"crdCount"`
+
+	// Kinds are the requested kinds the provider serves.
+	Kinds []string `json:"kinds"`
+}
+
+// Result is a set of recommended providers.
+type Result struct {
+	Providers []Provider `json:"providers"`
+
+	// Unresolved lists the requested kinds no provider was found for.
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// recommender searches a catalog for providers, remembering the resources of
+// every package it inspects and the family that serves each API domain.
+type recommender struct {
+	catalog   catalog.Catalog
+	resources map[string]*marketplace.PackageResources
+	families  map[string]string
+}
+
+// candidate is a provider that serves at least one requested group.
+type candidate struct {
+	Provider
+	resources *marketplace.PackageResources
+}
+
+// Recommend returns the smallest set of providers found that serve every
+// supplied kind. Groups are assigned to providers already chosen where
+// possible, and otherwise to the first provider family member found for them.
+// A provider outside a family is only chosen if no family member serves the
+// group.
+func Recommend(ctx context.Context, c catalog.Catalog, kinds []GroupKind) (*Result, error) {
+	r := &recommender{
+		catalog:   c,
+		resources: map[string]*marketplace.PackageResources{},
+		families:  map[string]string{},
+	}
+
+	byGroup := map[string][]string{}
+	for _, gk := range kinds {
+		if !slices.Contains(byGroup[gk.Group], gk.Kind) {
+			byGroup[gk.Group] = append(byGroup[gk.Group], gk.Kind)
+		}
+	}
+	groups := make([]string, 0, len(byGroup))
+	for g := range byGroup {
+		groups = append(groups, g)
+	}
+	slices.Sort(groups)
+
+	res := &Result{Providers: []Provider{}}
+	var chosen []*candidate
+	for _, g := range groups {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		idx := slices.IndexFunc(chosen, func(c *candidate) bool { return serves(c.resources, g) })
+		if idx < 0 {
+			c := r.search(ctx, g)
+			if c == nil {
+				for _, k := range byGroup[g] {
+					res.Unresolved = append(res.Unresolved, GroupKind{Group: g, Kind: k}.String())
+				}
+				continue
+			}
+			chosen = append(chosen, c)
+			idx = len(chosen) - 1
+		}
+		for _, k := range byGroup[g] {
+			chosen[idx].Kinds = append(chosen[idx].Kinds, GroupKind{Group: g, Kind: k}.String())
+		}
+	}
+
+	for _, c := range chosen {
+		slices.Sort(c.Kinds)
+		res.Providers = append(res.Providers, c.Provider)
+	}
+	return res, nil
+}
+
+// search finds a provider that serves a group. Groups are conventionally named
+// <service>.<provider domain>, for example s3.aws.upbound.io, and providers
+// <provider>-<service>, for example provider-aws-s3.
+func (r *recommender) search(ctx context.Context, group string) *candidate {
+	service, domain, _ := strings.Cut(group, ".")
+	provider, _, _ := strings.Cut(domain, ".")
+
+	queries := []marketplace.SearchParams{
+		{Query: fmt.Sprintf("provider-%s-%s", provider, service), Family: r.families[domain]},
+		{Query: service},
+	}
+	var fallback *candidate
+	for _, q := range queries {
+		q.PackageType = "provider"
+		q.Size = 20
+		resp, err := r.catalog.SearchPackages(ctx, q)
+		if err != nil {
+			continue
+		}
+		for _, p := range resp.Packages[:min(len(resp.Packages), maxCandidates)] {
+			c := r.inspect(ctx, p)
+			if c == nil || !serves(c.resources, group) {
+				continue
+			}
+			if c.Family != "" {
+				r.families[domain] = c.Family
+				return c
+			}
+			if fallback == nil {
+				fallback = c
+			}
+		}
+	}
+	return fallback
+}
+
+// inspect returns a package as a candidate, or nil if its resources cannot be
+// read.
+func (r *recommender) inspect(ctx context.Context, p marketplace.Package) *candidate {
+	version := p.Version
+	if version == "" {
+		md, err := r.catalog.GetPackageMetadata(ctx, p.Account, p.Repository, "", false)
+		if err != nil || md.LatestVersion == "" {
+			return nil
+		}
+		version = md.LatestVersion
+	}
+
+	key := p.Account + "/" + p.Repository + ":" + version
+	res, ok := r.resources[key]
+	if !ok {
+		var err error
+		if res, err = r.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, p.Account, p.Repository, version); err != nil {
+			return nil
+		}
+		r.resources[key] = res
+	}
+
+	c := &candidate{
+		Provider: Provider{
+			Account:    p.Account,
+			Repository: p.Repository,
+			Version:    version,
+			CRDCount:   len(res.CRDs),
+			Kinds:      []string{},
+		},
+		resources: res,
+	}
+	if res.FamilyRepoKey != nil {
+		c.Family = *res.FamilyRepoKey
+	}
+	return c
+}
+
+// serves returns true if a package installs a CRD in the group.
+func serves(res *marketplace.PackageResources, group string) bool {
+	return slices.ContainsFunc(res.CRDs, func(c marketplace.CRDMeta) bool { return c.Group == group })
+}
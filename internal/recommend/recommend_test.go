@@ -0,0 +1,136 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package recommend
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// fakeCatalog serves search results by query and resources by repository.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	search    map[string][]string
+	resources map[string]*marketplace.PackageResources
+}
+
+func (f *fakeCatalog) SearchPackages(_ context.Context, p marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	resp := &marketplace.SearchResponse{}
+	for _, repo := range f.search[p.Query] {
+		resp.Packages = append(resp.Packages, marketplace.Package{Account: "upbound", Repository: repo, Version: "v1.20.0"})
+	}
+	return resp, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, repo, _ string) (*marketplace.PackageResources, error) {
+	return f.resources[repo], nil
+}
+
+func resources(family string, groups ...string) *marketplace.PackageResources {
+	r := &marketplace.PackageResources{}
+	if family != "" {
+		r.FamilyRepoKey = &family
+	}
+	for _, g := range groups {
+		r.CRDs = append(r.CRDs, marketplace.CRDMeta{Group: g, Kind: "Any"})
+	}
+	return r
+}
+
+func TestRecommend(t *testing.T) {
+	c := &fakeCatalog{
+		search: map[string][]string{
+			"provider-aws-s3":  {"provider-aws", "provider-aws-s3"},
+			"provider-aws-ec2": {"provider-aws"},
+			"ec2":              {"provider-aws", "provider-aws-ec2"},
+		},
+		resources: map[string]*marketplace.PackageResources{
+			"provider-aws":     resources("", "s3.aws.upbound.io", "ec2.aws.upbound.io"),
+			"provider-aws-s3":  resources("upbound/provider-family-aws", "s3.aws.upbound.io"),
+			"provider-aws-ec2": resources("upbound/provider-family-aws", "ec2.aws.upbound.io", "ec2.aws.upbound.io"),
+		},
+	}
+
+	kinds, err := ParseManifests(`apiVersion: s3.aws.upbound.io/v1beta1
+kind: Bucket
+---
+apiVersion: s3.aws.upbound.io/v1beta1
+kind: BucketPolicy
+---
+apiVersion: ec2.aws.upbound.io/v1beta1
+kind: Instance
+---
+apiVersion: sql.gcp.upbound.io/v1beta1
+kind: DatabaseInstance
+`)
+	if err != nil {
+		t.Fatalf("ParseManifests() error = %v", err)
+	}
+
+	res, err := Recommend(context.Background(), c, kinds)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+
+	var got []string
+	for _, p := range res.Providers {
+		got = append(got, p.Repository)
+		if p.Family != "upbound/provider-family-aws" || p.Version != "v1.20.0" {
+			t.Errorf("provider %s has family %q version %q", p.Repository, p.Family, p.Version)
+		}
+	}
+	if want := []string{"provider-aws-ec2", "provider-aws-s3"}; !slices.Equal(got, want) {
+		t.Errorf("Recommend() providers = %v, want %v", got, want)
+	}
+	if res.Providers[0].CRDCount != 2 {
+		t.Errorf("CRDCount = %d, want 2", res.Providers[0].CRDCount)
+	}
+	if want := []string{"Bucket.s3.aws.upbound.io", "BucketPolicy.s3.aws.upbound.io"}; !slices.Equal(res.Providers[1].Kinds, want) {
+		t.Errorf("Kinds = %v, want %v", res.Providers[1].Kinds, want)
+	}
+	if want := []string{"DatabaseInstance.sql.gcp.upbound.io"}; !slices.Equal(res.Unresolved, want) {
+		t.Errorf("Unresolved = %v, want %v", res.Unresolved, want)
+	}
+}
+
+func TestParseGroupKind(t *testing.T) {
+	cases := map[string]struct {
+		apiVersion, kind string
+		want             GroupKind
+		wantErr          bool
+	}{
+		"Valid":     {apiVersion: "s3.aws.upbound.io/v1beta1", kind: "Bucket", want: GroupKind{Group: "s3.aws.upbound.io", Kind: "Bucket"}},
+		"CoreGroup": {apiVersion: "v1", kind: "ConfigMap", wantErr: true},
+		"NoKind":    {apiVersion: "s3.aws.upbound.io/v1beta1", wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseGroupKind(tc.apiVersion, tc.kind)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseGroupKind() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseGroupKind() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,628 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+	"github.com/upbound/marketplace-mcp-server/internal/xpkg"
+)
+
+const (
+	// DefaultRegistry is the registry packages are pulled from when no other
+	// registry is configured.
+	DefaultRegistry = "https://xpkg.upbound.io"
+
+	userAgent = "marketplace-mcp-server/1.0"
+
+	// maxCachedPackages bounds the number of parsed packages kept in memory.
+	maxCachedPackages = 32
+
+	// catalogScope is the token scope required to list repositories.
+	catalogScope = "registry:catalog:*"
+
+	// maxArtifactSize bounds the size of a signature or attestation layer.
+	maxArtifactSize = 4 << 20
+
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ErrNotFound is returned when the registry does not have the requested
+// content.
+var ErrNotFound = errors.New("not found")
+
+// manifestAccept is the Accept header sent when fetching manifests.
+var manifestAccept = strings.Join([]string{
+	mediaTypeOCIManifest,
+	mediaTypeOCIIndex,
+	mediaTypeDockerManifest,
+	mediaTypeDockerList,
+}, ", ")
+
+// Client reads packages from an OCI registry using the distribution API.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Username   string
+	Password   string
+
+	log logging.Logger
+
+	mu       sync.Mutex
+	tokens   map[string]string
+	packages map[string]*xpkg.Package
+}
+
+// Option enables overriding the underlying Client.
+type Option func(*Client)
+
+// WithLogger overrides the default logger for the Client.
+func WithLogger(log logging.Logger) Option {
+	return func(c *Client) {
+		c.log = log
+	}
+}
+
+// WithBaseURL overrides the registry the Client reads from.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithCredentials sets the username and password used when the registry
+// requests authentication.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) {
+		c.Username = username
+		c.Password = password
+	}
+}
+
+// NewClient creates a new registry client.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		BaseURL: DefaultRegistry,
+		HTTPClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		log:      logging.NewNopLogger(),
+		tokens:   map[string]string{},
+		packages: map[string]*xpkg.Package{},
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// descriptor describes content stored in the registry.
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *platform         `json:"platform,omitempty"`
+}
+
+// platform of an image in an index.
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// manifest is an image manifest or an image index.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+// Layer is an artifact layer read in full.
+type Layer struct {
+	MediaType   string
+	Annotations map[string]string
+	Content     []byte
+}
+
+// GetPackageResources pulls a package from the registry and summarises its
+// resources in the same form as the marketplace API. The reference may be a
+// tag or a digest.
+func (c *Client) GetPackageResources(ctx context.Context, account, repository, reference string) (*marketplace.PackageResources, error) {
+	pkg, err := c.Pull(ctx, account+"/"+repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Resources(account, repository), nil
+}
+
+// Pull fetches the package image at the supplied tag or digest and parses the
+// package stream it contains.
+func (c *Client) Pull(ctx context.Context, repository, reference string) (*xpkg.Package, error) {
+	m, digest, err := c.getManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	cached, ok := c.packages[repository+"@"+digest]
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	if len(m.Manifests) > 0 {
+		// Multi-platform packages carry the same package stream for every
+		// platform, so any image will do.
+		d := pickImage(m.Manifests)
+		if m, _, err = c.getManifest(ctx, repository, d.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range packageLayers(m.Layers) {
+		pkg, err := c.parseLayer(ctx, repository, l)
+		if errors.Is(err, xpkg.ErrNoPackageStream) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkg.Digest = digest
+		c.cache(repository+"@"+digest, pkg)
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("image %s@%s is not a Crossplane package: no layer contains %s", repository, digest, xpkg.StreamFile)
+}
+
+// cache stores a parsed package, evicting everything once the cache is full.
+// Packages are immutable by digest, so correctness never depends on it.
+func (c *Client) cache(key string, pkg *xpkg.Package) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.packages) >= maxCachedPackages {
+		clear(c.packages)
+	}
+	c.packages[key] = pkg
+}
+
+// LoadPackage pulls the supplied package version. An empty version pulls the
+// latest tag.
+func (c *Client) LoadPackage(ctx context.Context, account, repository, version string) (*xpkg.Package, error) {
+	if version == "" {
+		version = "latest"
+	}
+	return c.Pull(ctx, account+"/"+repository, version)
+}
+
+// ListPackages lists the repositories in the registry catalog that belong to
+// the supplied account, or every repository if account is empty. Many
+// registries do not expose their catalog, in which case an error is returned.
+func (c *Client) ListPackages(ctx context.Context, account string) ([]marketplace.Package, error) {
+	var cat struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := c.getJSON(ctx, catalogScope, c.BaseURL+"/v2/_catalog", &cat); err != nil {
+		return nil, fmt.Errorf("failed to list registry catalog: %w", err)
+	}
+	out := make([]marketplace.Package, 0, len(cat.Repositories))
+	for _, r := range cat.Repositories {
+		acct, repo, ok := strings.Cut(r, "/")
+		if !ok || (account != "" && acct != account) {
+			continue
+		}
+		out = append(out, marketplace.Package{Account: acct, Repository: repo, Name: repo})
+	}
+	return out, nil
+}
+
+// PackageVersions lists the semantic version tags of a package, oldest first.
+func (c *Client) PackageVersions(ctx context.Context, account, repository string) ([]string, error) {
+	repo := account + "/" + repository
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.getJSON(ctx, repo, fmt.Sprintf("%s/v2/%s/tags/list", c.BaseURL, repo), &tags); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	out := make([]string, 0, len(tags.Tags))
+	for _, t := range tags.Tags {
+		if semver.IsValid(t) {
+			out = append(out, t)
+		}
+	}
+	semver.Sort(out)
+	return out, nil
+}
+
+// Resolve returns the digest of the manifest a tag or digest refers to.
+func (c *Client) Resolve(ctx context.Context, repository, reference string) (string, error) {
+	_, digest, err := c.getManifest(ctx, repository, reference)
+	return digest, err
+}
+
+// Signatures returns the layers of the cosign signature image attached to the
+// supplied manifest digest. It returns no layers if the image is not signed.
+func (c *Client) Signatures(ctx context.Context, repository, digest string) ([]Layer, error) {
+	return c.artifacts(ctx, repository, digest, "sig")
+}
+
+// Attestations returns the layers of the cosign attestation image attached to
+// the supplied manifest digest. It returns no layers if there are none.
+func (c *Client) Attestations(ctx context.Context, repository, digest string) ([]Layer, error) {
+	return c.artifacts(ctx, repository, digest, "att")
+}
+
+// artifacts reads the layers of the image cosign attaches to a digest, which
+// is tagged sha256-<hex>.<suffix> in the same repository.
+func (c *Client) artifacts(ctx context.Context, repository, digest, suffix string) ([]Layer, error) {
+	tag := strings.Replace(digest, ":", "-", 1) + "." + suffix
+	m, _, err := c.getManifest(ctx, repository, tag)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Layer, 0, len(m.Layers))
+	for _, l := range m.Layers {
+		if l.Size > maxArtifactSize {
+			return nil, fmt.Errorf("layer %s of %s is too large: %d bytes", l.Digest, tag, l.Size)
+		}
+		content, err := c.readBlob(ctx, repository, l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Layer{MediaType: l.MediaType, Annotations: l.Annotations, Content: content})
+	}
+	return out, nil
+}
+
+// readBlob reads a small blob in full, verifying its digest.
+func (c *Client) readBlob(ctx context.Context, repository, digest string) ([]byte, error) {
+	body, err := c.getBlob(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+	}()
+	b, err := io.ReadAll(io.LimitReader(body, maxArtifactSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	if len(b) > maxArtifactSize {
+		return nil, fmt.Errorf("blob %s is larger than %d bytes", digest, maxArtifactSize)
+	}
+	return b, nil
+}
+
+// getJSON fetches and decodes a JSON document from the registry.
+func (c *Client) getJSON(ctx context.Context, scope, u string, into any) error {
+	resp, err := c.do(ctx, scope, u, "application/json")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+	}()
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// packageLayers orders layers so that the one annotated as holding the
+// package stream is tried first. Unannotated packages fall back to searching
+// every layer, most recent first.
+func packageLayers(layers []descriptor) []descriptor {
+	for _, l := range layers {
+		if l.Annotations[xpkg.AnnotationKey] == xpkg.PackageAnnotation {
+			return []descriptor{l}
+		}
+	}
+	out := make([]descriptor, 0, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		out = append(out, layers[i])
+	}
+	return out
+}
+
+// pickImage chooses an image from an index, preferring linux/amd64.
+func pickImage(manifests []descriptor) descriptor {
+	for _, d := range manifests {
+		if d.Platform != nil && d.Platform.OS == "linux" && d.Platform.Architecture == "amd64" {
+			return d
+		}
+	}
+	return manifests[0]
+}
+
+// parseLayer downloads a layer and parses the package stream in it.
+func (c *Client) parseLayer(ctx context.Context, repository string, l descriptor) (*xpkg.Package, error) {
+	body, err := c.getBlob(ctx, repository, l.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+	}()
+
+	pkg, err := xpkg.ParseLayer(body)
+	if err != nil {
+		return nil, err
+	}
+	// Read the rest of the layer so its digest is verified.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// getManifest fetches a manifest and returns it along with its digest.
+func (c *Client) getManifest(ctx context.Context, repository, reference string) (*manifest, string, error) {
+	u := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, repository, reference)
+	resp, err := c.do(ctx, repository, u, manifestAccept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	digest := "sha256:" + sha256Hex(body)
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", fmt.Errorf("manifest digest %s does not match requested digest %s", digest, reference)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &m, digest, nil
+}
+
+// getBlob returns a reader for a blob that verifies its digest once the blob
+// has been read in full.
+func (c *Client) getBlob(ctx context.Context, repository, digest string) (io.ReadCloser, error) {
+	want, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm: %s", digest)
+	}
+	u := fmt.Sprintf("%s/v2/%s/blobs/%s", c.BaseURL, repository, digest)
+	resp, err := c.do(ctx, repository, u, "")
+	if err != nil {
+		return nil, err
+	}
+	return &verifier{rc: resp.Body, h: sha256.New(), want: want}, nil
+}
+
+// do performs a GET request against the registry, negotiating a bearer token
+// if the registry asks for one.
+func (c *Client) do(ctx context.Context, repository, u, accept string) (*http.Response, error) {
+	resp, err := c.get(ctx, repository, u, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if err := resp.Body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+		if err := c.authenticate(ctx, repository, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = c.get(ctx, repository, u, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("authentication required for %s", repository)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("registry request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+func (c *Client) get(ctx context.Context, repository, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	c.mu.Lock()
+	token := c.tokens[repository]
+	c.mu.Unlock()
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// authenticate exchanges credentials for a bearer token scoped to pull the
+// supplied repository, as described by a WWW-Authenticate challenge.
+func (c *Client) authenticate(ctx context.Context, repository, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return fmt.Errorf("authentication required for %s", repository)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("failed to parse token realm: %w", err)
+	}
+	q := u.Query()
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	scope := params["scope"]
+	switch {
+	case scope != "":
+	case strings.Contains(repository, ":"):
+		scope = repository
+	default:
+		scope = fmt.Sprintf("repository:%s:pull", repository)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute token request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log.Info("failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tr struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"` //nolint:tagliatelle // This is marshalling an external API.
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return fmt.Errorf("failed to decode token response: %w", err)
+	}
+	token := tr.Token
+	if token == "" {
+		token = tr.AccessToken
+	}
+	if token == "" {
+		return fmt.Errorf("token response for %s did not contain a token", repository)
+	}
+
+	c.mu.Lock()
+	c.tokens[repository] = token
+	c.mu.Unlock()
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://r.io/token",service="r.io",scope="repository:a/b:pull"`.
+func parseChallenge(h string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, val string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if strings.HasPrefix(rest, `"`) {
+			val, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			val, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = val
+		}
+	}
+	return scheme, params
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifier checks the digest of a blob as it is read.
+type verifier struct {
+	rc   io.ReadCloser
+	h    hash.Hash
+	want string
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.rc.Read(p)
+	v.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if got := hex.EncodeToString(v.h.Sum(nil)); got != v.want {
+			return n, fmt.Errorf("blob digest sha256:%s does not match expected sha256:%s", got, v.want)
+		}
+	}
+	return n, err
+}
+
+func (v *verifier) Close() error {
+	return v.rc.Close()
+}
@@ -0,0 +1,239 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+)
+
+const packageStream = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-test
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.s3.test.io
+spec:
+  group: s3.test.io
+  names:
+    kind: Bucket
+  scope: Cluster
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+  - name: v1beta2
+    served: true
+    storage: true
+`
+
+// fakeRegistry is an in-process stand-in for an OCI registry that requires
+// bearer token authentication.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}}
+}
+
+func (f *fakeRegistry) addBlob(b []byte) string {
+	d := "sha256:" + sha256Hex(b)
+	f.blobs[d] = b
+	return d
+}
+
+func (f *fakeRegistry) addManifest(tag string, m manifest) string {
+	b, _ := json.Marshal(m)
+	d := "sha256:" + sha256Hex(b)
+	f.manifests[d] = b
+	if tag != "" {
+		f.manifests[tag] = b
+	}
+	return d
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "secret"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+r.Host+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(r.URL.Path, "/")
+	ref := parts[len(parts)-1]
+	var b []byte
+	switch parts[len(parts)-2] {
+	case "tags":
+		tags := []string{}
+		for t := range f.manifests {
+			if !strings.HasPrefix(t, "sha256:") {
+				tags = append(tags, t)
+			}
+		}
+		b, _ = json.Marshal(map[string][]string{"tags": tags})
+	case "manifests":
+		b = f.manifests[ref]
+	case "blobs":
+		b = f.blobs[ref]
+	}
+	if b == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write(b)
+}
+
+func layer(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetPackageResources(t *testing.T) {
+	reg := newFakeRegistry()
+	binary := reg.addBlob(layer(t, map[string]string{"usr/bin/provider": "binary"}))
+	base := reg.addBlob(layer(t, map[string]string{"package.yaml": packageStream}))
+	config := reg.addBlob([]byte("{}"))
+
+	annotated := reg.addManifest("v1.0.0", manifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    descriptor{Digest: config},
+		Layers: []descriptor{
+			{Digest: binary},
+			{Digest: base, Annotations: map[string]string{"io.crossplane.xpkg": "base"}},
+		},
+	})
+	unannotated := reg.addManifest("", manifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    descriptor{Digest: config},
+		Layers:    []descriptor{{Digest: base}, {Digest: binary}},
+	})
+	reg.addManifest("v1.1.0", manifest{
+		MediaType: mediaTypeOCIIndex,
+		Manifests: []descriptor{{Digest: unannotated, Platform: &platform{OS: "linux", Architecture: "amd64"}}},
+	})
+
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	cases := map[string]struct {
+		reference  string
+		wantDigest string
+	}{
+		"AnnotatedTag":   {reference: "v1.0.0", wantDigest: annotated},
+		"Digest":         {reference: annotated, wantDigest: annotated},
+		"UnannotatedIdx": {reference: "v1.1.0"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(WithBaseURL(srv.URL))
+			res, err := c.GetPackageResources(context.Background(), "test", "provider-test", tc.reference)
+			if err != nil {
+				t.Fatalf("GetPackageResources() error = %v", err)
+			}
+			if res.Name != "provider-test" || res.PackageType != "provider" {
+				t.Errorf("unexpected package meta: %+v", res.PackageMeta)
+			}
+			if tc.wantDigest != "" && res.PkgDigest != tc.wantDigest {
+				t.Errorf("PkgDigest = %s, want %s", res.PkgDigest, tc.wantDigest)
+			}
+			if len(res.CRDs) != 1 {
+				t.Fatalf("expected 1 CRD, got %d", len(res.CRDs))
+			}
+			crd := res.CRDs[0]
+			if crd.Group != "s3.test.io" || crd.Kind != "Bucket" || crd.StorageVersion != "v1beta2" || len(crd.Versions) != 2 {
+				t.Errorf("unexpected CRD meta: %+v", crd)
+			}
+		})
+	}
+}
+
+func TestPullNotAPackage(t *testing.T) {
+	reg := newFakeRegistry()
+	binary := reg.addBlob(layer(t, map[string]string{"usr/bin/app": "binary"}))
+	reg.addManifest("latest", manifest{MediaType: mediaTypeOCIManifest, Layers: []descriptor{{Digest: binary}}})
+
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	if _, err := c.Pull(context.Background(), "test/app", "latest"); err == nil {
+		t.Error("expected an error pulling an image without a package stream")
+	}
+}
+
+func TestPackageCatalogLatestVersion(t *testing.T) {
+	reg := newFakeRegistry()
+	base := reg.addBlob(layer(t, map[string]string{"package.yaml": packageStream}))
+	config := reg.addBlob([]byte("{}"))
+	m := manifest{MediaType: mediaTypeOCIManifest, Config: descriptor{Digest: config}, Layers: []descriptor{{Digest: base}}}
+	reg.addManifest("v1.0.0", m)
+	reg.addManifest("v1.10.0", m)
+	reg.addManifest("v1.9.0", m)
+
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	c := catalog.NewPackageCatalog(NewClient(WithBaseURL(srv.URL)))
+	md, err := c.GetPackageMetadata(context.Background(), "test", "provider-test", "", false)
+	if err != nil {
+		t.Fatalf("GetPackageMetadata() error = %v", err)
+	}
+	if md.Version != "v1.10.0" || md.LatestVersion != "v1.10.0" {
+		t.Errorf("Version = %q, LatestVersion = %q, want v1.10.0", md.Version, md.LatestVersion)
+	}
+	if len(md.Versions) != 3 {
+		t.Errorf("Versions = %v, want 3 versions", md.Versions)
+	}
+	if _, err := c.GetV1PackagesAccountRepositoryVersionResources(context.Background(), "test", "provider-test", ""); err != nil {
+		t.Errorf("GetV1PackagesAccountRepositoryVersionResources() error = %v", err)
+	}
+}
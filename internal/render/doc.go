@@ -0,0 +1,25 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package render renders tool results as markdown, plain text, JSON or YAML.
+
+JSON and YAML encode a result as is, while markdown and plain text render a
+Document that describes it for people. Documents are built from titled
+sections of fields, lists, tables, paragraphs and code blocks, so that every
+result is laid out the same way in both formats.
+*/
+package render
@@ -0,0 +1,186 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A BlockKind is a kind of document block.
+type BlockKind string
+
+// Kinds of document blocks.
+const (
+	BlockSection   BlockKind = "section"
+	BlockFields    BlockKind = "fields"
+	BlockParagraph BlockKind = "paragraph"
+	BlockList      BlockKind = "list"
+	BlockTable     BlockKind = "table"
+	BlockCode      BlockKind = "code"
+)
+
+// A Block is part of a document. Which of its fields are set depends on its
+// kind.
+type Block struct {
+	Kind BlockKind
+
+	// Title is the title of a section.
+	Title string
+
+	// Fields are the named values of a fields block.
+	Fields []Field
+
+	// Text is the text of a paragraph or code block.
+	Text string
+
+	// Lang is the language of a code block, such as yaml.
+	Lang string
+
+	// Items are the items of a list.
+	Items []string
+
+	// Header and Rows are the cells of a table.
+	Header []string
+	Rows   [][]string
+}
+
+// A Field is a named value.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// A Document describes a result for people. Its methods append blocks to
+// it and return it, so that a document can be built in one expression.
+type Document struct {
+	title  string
+	blocks []Block
+}
+
+// NewDocument returns an empty document with the supplied title, which may
+// be empty.
+func NewDocument(title string) *Document {
+	return &Document{title: title}
+}
+
+// Field appends a named value. Consecutive fields are rendered together, and
+// zero values are omitted.
+func (d *Document) Field(name string, value any) *Document {
+	v := formatValue(value)
+	if v == "" {
+		return d
+	}
+	f := Field{Name: name, Value: v}
+	if n := len(d.blocks); n > 0 && d.blocks[n-1].Kind == BlockFields {
+		d.blocks[n-1].Fields = append(d.blocks[n-1].Fields, f)
+		return d
+	}
+	d.blocks = append(d.blocks, Block{Kind: BlockFields, Fields: []Field{f}})
+	return d
+}
+
+// Section starts a titled section. Sections with nothing in them are
+// omitted.
+func (d *Document) Section(title string) *Document {
+	d.blocks = append(d.blocks, Block{Kind: BlockSection, Title: title})
+	return d
+}
+
+// Paragraph appends text, which is rendered as is.
+func (d *Document) Paragraph(text string) *Document {
+	if text = strings.TrimSpace(text); text != "" {
+		d.blocks = append(d.blocks, Block{Kind: BlockParagraph, Text: text})
+	}
+	return d
+}
+
+// List appends a bulleted list. An empty list is omitted.
+func (d *Document) List(items ...string) *Document {
+	if len(items) > 0 {
+		d.blocks = append(d.blocks, Block{Kind: BlockList, Items: items})
+	}
+	return d
+}
+
+// Table appends a table. A table without rows is omitted.
+func (d *Document) Table(header []string, rows [][]string) *Document {
+	if len(rows) > 0 {
+		d.blocks = append(d.blocks, Block{Kind: BlockTable, Header: header, Rows: rows})
+	}
+	return d
+}
+
+// Code appends a code block in the supplied language, such as yaml.
+func (d *Document) Code(lang, code string) *Document {
+	if code = strings.TrimRight(code, "\n"); code != "" {
+		d.blocks = append(d.blocks, Block{Kind: BlockCode, Lang: lang, Text: code})
+	}
+	return d
+}
+
+// Title returns the title of the document.
+func (d *Document) Title() string {
+	return d.title
+}
+
+// Blocks returns the blocks of the document, without empty sections.
+func (d *Document) Blocks() []Block {
+	out := make([]Block, 0, len(d.blocks))
+	for i, b := range d.blocks {
+		if b.Kind == BlockSection && (i == len(d.blocks)-1 || d.blocks[i+1].Kind == BlockSection) {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// Markdown renders the document as markdown with the default templates.
+func (d *Document) Markdown() string {
+	out, _ := DefaultTemplates().Render("", Markdown, nil, d)
+	return out
+}
+
+// Text renders the document as plain text with the default templates.
+func (d *Document) Text() string {
+	out, _ := DefaultTemplates().Render("", Text, nil, d)
+	return out
+}
+
+// formatValue formats a field value, returning an empty string for zero
+// values.
+func formatValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsZero() || ((rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.Len() == 0) {
+		return ""
+	}
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case []string:
+		return strings.Join(v, ", ")
+	case bool:
+		return "yes"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,95 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A Format is a way of rendering a result.
+type Format string
+
+// Supported formats.
+const (
+	Markdown Format = "markdown"
+	Text     Format = "text"
+	JSON     Format = "json"
+	YAML     Format = "yaml"
+)
+
+// DefaultFormat is the format results are rendered in unless another is
+// requested.
+const DefaultFormat = Markdown
+
+// Formats are the supported formats.
+var Formats = []Format{Markdown, Text, JSON, YAML}
+
+// ParseFormat parses the name of a format. An empty name is the default
+// format.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return DefaultFormat, nil
+	}
+	f := Format(strings.ToLower(s))
+	if !slices.Contains(Formats, f) {
+		return "", fmt.Errorf("unsupported output format %q, expected one of markdown, text, json or yaml", s)
+	}
+	return f, nil
+}
+
+// Render renders a result with the default templates. JSON and YAML encode
+// v, while markdown and text render doc.
+func Render(f Format, v any, doc *Document) (string, error) {
+	return DefaultTemplates().Render("", f, v, doc)
+}
+
+// encodeJSON encodes v as indented JSON.
+func encodeJSON(v any) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return string(b), nil
+}
+
+// encodeYAML encodes v as YAML, using the field names of its JSON encoding.
+func encodeYAML(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	var obj any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(obj); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,105 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package render
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		"Default":     {in: "", want: Markdown},
+		"Text":        {in: "text", want: Text},
+		"CaseFolded":  {in: "YAML", want: YAML},
+		"Unsupported": {in: "xml", wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseFormat(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %t", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	type result struct {
+		Name     string   `json:"name"`
+		Versions []string `json:"versions,omitempty"`
+	}
+	v := result{Name: "provider-aws-s3", Versions: []string{"v1.20.0", "v1.21.0"}}
+	doc := NewDocument("Package provider-aws-s3").
+		Field("Name", v.Name).
+		Field("License", "").
+		Field("Public", true).
+		Field("Created", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)).
+		Section("Versions").List(v.Versions...).
+		Section("Empty").
+		Section("Kinds").Table([]string{"Kind", "Group"}, [][]string{{"Bucket", "s3.aws.upbound.io"}, {"BucketPolicy", "s3.aws|upbound.io"}}).
+		Section("Example").Code("yaml", "kind: Bucket\n").
+		Section("Trailing")
+
+	cases := map[string]struct {
+		f    Format
+		want string
+	}{
+		"Markdown": {
+			f: Markdown,
+			want: "# Package provider-aws-s3\n\n" +
+				"- **Name:** provider-aws-s3\n- **Public:** yes\n- **Created:** 2025-01-02 03:04:05\n\n" +
+				"## Versions\n\n- v1.20.0\n- v1.21.0\n\n" +
+				"## Kinds\n\n| Kind | Group |\n| --- | --- |\n| Bucket | s3.aws.upbound.io |\n| BucketPolicy | s3.aws\\|upbound.io |\n\n" +
+				"## Example\n\n```yaml\nkind: Bucket\n```\n",
+		},
+		"Text": {
+			f: Text,
+			want: "Package provider-aws-s3\n=======================\n\n" +
+				"Name: provider-aws-s3\nPublic: yes\nCreated: 2025-01-02 03:04:05\n\n" +
+				"Versions\n--------\n- v1.20.0\n- v1.21.0\n\n" +
+				"Kinds\n-----\nKind          Group\n------------  -----------------\nBucket        s3.aws.upbound.io\nBucketPolicy  s3.aws|upbound.io\n\n" +
+				"Example\n-------\nkind: Bucket\n",
+		},
+		"JSON": {
+			f:    JSON,
+			want: "{\n  \"name\": \"provider-aws-s3\",\n  \"versions\": [\n    \"v1.20.0\",\n    \"v1.21.0\"\n  ]\n}",
+		},
+		"YAML": {
+			f:    YAML,
+			want: "name: provider-aws-s3\nversions:\n  - v1.20.0\n  - v1.21.0\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Render(tc.f, v, doc)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
+
+	"golang.org/x/mod/semver"
+)
+
+// DocumentTemplate is the name of the templates that render any document,
+// suffixed by the extension of a format.
+const DocumentTemplate = "document"
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// A View is what a template renders.
+type View struct {
+	// Tool is the name of the tool whose result is rendered, if any.
+	Tool string
+
+	// Format is the format being rendered, either markdown or text.
+	Format Format
+
+	// Title and Blocks are the document describing the result.
+	Title  string
+	Blocks []Block
+
+	// Result is the result the document describes, with the fields of its
+	// Go type.
+	Result any
+}
+
+// Templates render documents as markdown and text. A template named
+// <tool>.md or <tool>.txt renders the results of that tool, while the
+// document.md and document.txt templates render any other document.
+type Templates struct {
+	tmpl *template.Template
+}
+
+// DefaultTemplates returns the built-in templates.
+var DefaultTemplates = sync.OnceValue(func() *Templates {
+	t, err := parse(template.New("").Funcs(funcs()), embedded, "templates")
+	if err != nil {
+		panic(fmt.Sprintf("cannot parse built-in templates: %v", err))
+	}
+	return &Templates{tmpl: t}
+})
+
+// LoadTemplates returns the built-in templates, overridden and extended by
+// the *.tmpl files in the supplied directory. A file is named after the
+// template it defines; for example get_package_metadata.md.tmpl renders the
+// results of the get_package_metadata tool as markdown. Templates may include
+// the built-in ones, for example with {{ template "document.md" . }}.
+func LoadTemplates(dir string) (*Templates, error) {
+	t, err := DefaultTemplates().tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone built-in templates: %w", err)
+	}
+	if t, err = parse(t, os.DirFS(dir), "."); err != nil {
+		return nil, err
+	}
+	return &Templates{tmpl: t}, nil
+}
+
+// parse parses the *.tmpl files in a directory of fsys into t.
+func parse(t *template.Template, fsys fs.FS, dir string) (*template.Template, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".tmpl" {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", e.Name(), err)
+		}
+		if _, err := t.New(strings.TrimSuffix(e.Name(), ".tmpl")).Parse(string(b)); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", e.Name(), err)
+		}
+	}
+	return t, nil
+}
+
+// Render renders the result of a tool. JSON and YAML encode v, while
+// markdown and text render doc with the tool's template, or with the
+// document template if the tool has none.
+func (t *Templates) Render(tool string, f Format, v any, doc *Document) (string, error) {
+	ext := "md"
+	switch f {
+	case JSON:
+		return encodeJSON(v)
+	case YAML:
+		return encodeYAML(v)
+	case Text:
+		ext = "txt"
+	}
+
+	tmpl := t.tmpl.Lookup(tool + "." + ext)
+	if tool == "" || tmpl == nil {
+		tmpl = t.tmpl.Lookup(DocumentTemplate + "." + ext)
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("no template renders %s", f)
+	}
+
+	var out bytes.Buffer
+	view := View{Tool: tool, Format: f, Title: doc.Title(), Blocks: doc.Blocks(), Result: v}
+	if err := tmpl.Execute(&out, view); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimRight(out.String(), "\n") + "\n", nil
+}
+
+// funcs returns the functions available to templates.
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"truncate":    truncate,
+		"toYaml":      toYAML,
+		"toJson":      toJSON,
+		"indent":      indent,
+		"repeat":      func(s string, n int) string { return strings.Repeat(s, n) },
+		"join":        func(sep string, ss []string) string { return strings.Join(ss, sep) },
+		"lower":       strings.ToLower,
+		"upper":       strings.ToUpper,
+		"trim":        strings.TrimSpace,
+		"markdownRow": markdownRow,
+		"columns":     columns,
+
+		"semver":             semver.Canonical,
+		"semverMajor":        semver.Major,
+		"semverMajorMinor":   semver.MajorMinor,
+		"semverCompare":      semver.Compare,
+		"semverIsValid":      semver.IsValid,
+		"semverIsPrerelease": func(v string) bool { return semver.Prerelease(v) != "" },
+	}
+}
+
+// truncate shortens s to at most n characters, marking where it was cut.
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	r := []rune(s)
+	return strings.TrimRight(string(r[:n-1]), " ") + "…"
+}
+
+// toYAML encodes v as YAML, without a trailing newline.
+func toYAML(v any) (string, error) {
+	s, err := encodeYAML(v)
+	return strings.TrimSuffix(s, "\n"), err
+}
+
+// toJSON encodes v as indented JSON.
+func toJSON(v any) (string, error) {
+	return encodeJSON(v)
+}
+
+// indent indents every line of s by n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// markdownRow joins the cells of a markdown table row, escaping characters
+// that would break the table.
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(c, "|", `\|`), "\n", " ")
+	}
+	return strings.Join(escaped, " | ")
+}
+
+// columns lays out a plain text table, returning its lines with the header
+// underlined and every column aligned.
+func columns(header []string, rows [][]string) []string {
+	widths := make([]int, len(header))
+	for _, r := range append([][]string{header}, rows...) {
+		for i, c := range r {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(c))
+			}
+		}
+	}
+	line := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for i, c := range cells {
+			if i < len(widths) {
+				c += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c))
+			}
+			padded[i] = c
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ")
+	}
+	dashes := make([]string, len(widths))
+	for i, w := range widths {
+		dashes[i] = strings.Repeat("-", w)
+	}
+	out := []string{line(header), line(dashes)}
+	for _, r := range rows {
+		out = append(out, line(r))
+	}
+	return out
+}
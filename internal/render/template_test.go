@@ -0,0 +1,123 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	type result struct {
+		Repository string
+		Version    string
+		Downloads  int
+	}
+	v := result{Repository: "provider-aws-s3", Version: "v1.21.0-rc.1", Downloads: 1000}
+	doc := NewDocument("Package").Field("Repository", v.Repository).Field("Downloads", v.Downloads)
+
+	cases := map[string]struct {
+		files   map[string]string
+		tool    string
+		f       Format
+		want    string
+		wantErr bool
+	}{
+		"BuiltIn": {
+			tool: "get_package_metadata",
+			f:    Markdown,
+			want: "# Package\n\n- **Repository:** provider-aws-s3\n- **Downloads:** 1000\n",
+		},
+		"ToolTemplate": {
+			files: map[string]string{
+				"get_package_metadata.md.tmpl": `{{ template "document.md" . }}[Wiki](https://wiki.example.com/{{ .Result.Repository }})`,
+			},
+			tool: "get_package_metadata",
+			f:    Markdown,
+			want: "# Package\n\n- **Repository:** provider-aws-s3\n- **Downloads:** 1000\n\n[Wiki](https://wiki.example.com/provider-aws-s3)\n",
+		},
+		"OtherTool": {
+			files: map[string]string{
+				"get_package_metadata.md.tmpl": `custom`,
+			},
+			tool: "search_packages",
+			f:    Markdown,
+			want: "# Package\n\n- **Repository:** provider-aws-s3\n- **Downloads:** 1000\n",
+		},
+		"DocumentTemplate": {
+			files: map[string]string{
+				"document.txt.tmpl": `{{ range .Blocks }}{{ range .Fields }}{{ if ne .Name "Downloads" }}{{ .Name }}={{ .Value }};{{ end }}{{ end }}{{ end }}`,
+			},
+			tool: "get_package_metadata",
+			f:    Text,
+			want: "Repository=provider-aws-s3;\n",
+		},
+		"Helpers": {
+			files: map[string]string{
+				"get_package_metadata.txt.tmpl": `{{ truncate 8 .Result.Repository }} {{ semverMajorMinor .Result.Version }} {{ semverIsPrerelease .Result.Version }} {{ semverCompare .Result.Version "v1.21.0" }}
+{{ toYaml .Result | indent 2 }}`,
+			},
+			tool: "get_package_metadata",
+			f:    Text,
+			want: "provide… v1.21 true -1\n  Downloads: 1000\n  Repository: provider-aws-s3\n  Version: v1.21.0-rc.1\n",
+		},
+		"JSONIsNotTemplated": {
+			files: map[string]string{
+				"get_package_metadata.md.tmpl": `custom`,
+			},
+			tool: "get_package_metadata",
+			f:    JSON,
+			want: "{\n  \"Repository\": \"provider-aws-s3\",\n  \"Version\": \"v1.21.0-rc.1\",\n  \"Downloads\": 1000\n}",
+		},
+		"InvalidTemplate": {
+			files: map[string]string{
+				"get_package_metadata.md.tmpl": `{{ .Result.Repository `,
+			},
+			wantErr: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tmpl, err := LoadTemplates(dir)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadTemplates() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			got, err := tmpl.Render(tc.tool, tc.f, v, doc)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+
+	// Overrides do not leak into the built-in templates.
+	if got := doc.Markdown(); got != "# Package\n\n- **Repository:** provider-aws-s3\n- **Downloads:** 1000\n" {
+		t.Errorf("Markdown() after loading templates = %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+var _ catalog.Catalog = &Snapshot{}
+
+// version returns the exported version of a package a request refers to. An
+// empty version, or latest, refers to the newest exported version.
+func (s *Snapshot) version(account, repository, version string) (string, error) {
+	e, ok := s.Manifest.Entry(account, repository)
+	if !ok || len(e.Versions) == 0 {
+		return "", fmt.Errorf("package %s/%s is not in the snapshot", account, repository)
+	}
+	if version == "" || version == "latest" {
+		return e.Versions[len(e.Versions)-1], nil
+	}
+	if !slices.Contains(e.Versions, version) {
+		return "", fmt.Errorf("version %s of package %s/%s is not in the snapshot, which has %s", version, account, repository, strings.Join(e.Versions, ", "))
+	}
+	return version, nil
+}
+
+// SearchPackages returns the packages whose account, repository, name,
+// description or keywords contain the query.
+func (s *Snapshot) SearchPackages(_ context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	query := strings.ToLower(params.Query)
+	matched := []marketplace.Package{}
+	for _, e := range s.Manifest.Packages {
+		p := e.Package
+		if params.AccountName != "" && p.Account != params.AccountName {
+			continue
+		}
+		if params.PackageType != "" && !strings.EqualFold(p.Type, params.PackageType) {
+			continue
+		}
+		if params.Tier != "" && !strings.EqualFold(p.Tier, params.Tier) {
+			continue
+		}
+		text := strings.ToLower(strings.Join(append([]string{p.Account, p.Repository, p.Name, p.Description}, p.Keywords...), " "))
+		if query != "" && !strings.Contains(text, query) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return &marketplace.SearchResponse{
+		Packages: page(matched, params.Page, params.Size),
+		Total:    len(matched),
+		Page:     params.Page,
+		Size:     params.Size,
+	}, nil
+}
+
+// GetRepositories returns a repository for every package in the account. A
+// filter returns catalog.ErrFilterUnsupported.
+func (s *Snapshot) GetRepositories(_ context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	if params.Filter != "" {
+		return nil, fmt.Errorf("%w by snapshots", catalog.ErrFilterUnsupported)
+	}
+	repos := []marketplace.Repository{}
+	for _, e := range s.Manifest.Packages {
+		p := e.Package
+		if p.Account != account {
+			continue
+		}
+		repos = append(repos, marketplace.Repository{
+			Account:      p.Account,
+			Name:         p.Repository,
+			Description:  p.Description,
+			Type:         p.Type,
+			Public:       p.Public,
+			CreatedAt:    p.CreatedAt,
+			UpdatedAt:    p.UpdatedAt,
+			PackageCount: len(e.Versions),
+		})
+	}
+	return &marketplace.RepositoryResponse{
+		Repositories: page(repos, params.Page, params.Size),
+		Count:        len(repos),
+		Page:         params.Page,
+		Size:         params.Size,
+	}, nil
+}
+
+// GetPackageMetadata returns the metadata of a package version. Its versions
+// are the exported versions.
+func (s *Snapshot) GetPackageMetadata(_ context.Context, account, repo, version string, _ bool) (*marketplace.PackageMetadata, error) {
+	v, err := s.version(account, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	md := &marketplace.PackageMetadata{}
+	if err := s.decode(metadataFile(account, repo, v), md); err != nil {
+		return nil, err
+	}
+	e, _ := s.Manifest.Entry(account, repo)
+	md.Versions = slices.Clone(e.Versions)
+	md.LatestVersion = e.Versions[len(e.Versions)-1]
+	return md, nil
+}
+
+// GetPackageAssets returns an exported asset of a package version.
+func (s *Snapshot) GetPackageAssets(_ context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error) {
+	v, err := s.version(account, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	a := &marketplace.AssetResponse{}
+	if err := s.decode(assetFile(account, repo, v, assetType), a); err != nil {
+		return nil, fmt.Errorf("%s asset of %s/%s:%s is not in the snapshot", assetType, account, repo, v)
+	}
+	return a, nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResources returns the resources in a
+// package version.
+func (s *Snapshot) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, account, repositoryName, version string) (*marketplace.PackageResources, error) {
+	v, err := s.version(account, repositoryName, version)
+	if err != nil {
+		return nil, err
+	}
+	res := &marketplace.PackageResources{}
+	if err := s.decode(resourcesFile(account, repositoryName, v), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKind returns the CRD or
+// XRD that defines a group and kind.
+func (s *Snapshot) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (string, error) {
+	v, err := s.version(account, repositoryName, version)
+	if err != nil {
+		return "", err
+	}
+	b, ok := s.file(definitionFile(account, repositoryName, v, resourceGroup, resourceKind))
+	if !ok {
+		return "", fmt.Errorf("kind %s.%s not found in package %s/%s:%s", resourceKind, resourceGroup, account, repositoryName, v)
+	}
+	return string(b), nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition returns
+// the named composition of an XRD.
+func (s *Snapshot) GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(_ context.Context, account, repositoryName, version, resourceGroup, resourceKind, compositionName string) (string, error) {
+	v, err := s.version(account, repositoryName, version)
+	if err != nil {
+		return "", err
+	}
+	b, ok := s.file(compositionFile(account, repositoryName, v, resourceGroup, resourceKind, compositionName))
+	if !ok {
+		return "", fmt.Errorf("composition %s not found in package %s/%s:%s", compositionName, account, repositoryName, v)
+	}
+	return string(b), nil
+}
+
+// GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples returns the
+// examples of a group and kind. Kinds without exported examples have none.
+func (s *Snapshot) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(_ context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (*marketplace.Examples, error) {
+	v, err := s.version(account, repositoryName, version)
+	if err != nil {
+		return nil, err
+	}
+	ex := &marketplace.Examples{Examples: []string{}}
+	name := examplesFile(account, repositoryName, v, resourceGroup, resourceKind)
+	if _, ok := s.file(name); !ok {
+		return ex, nil
+	}
+	if err := s.decode(name, ex); err != nil {
+		return nil, err
+	}
+	return ex, nil
+}
+
+// page returns the requested page of items. A size of zero returns every
+// item.
+func page[T any](items []T, pg, size int) []T {
+	if size <= 0 {
+		return items
+	}
+	start := pg * size
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+size, len(items))]
+}
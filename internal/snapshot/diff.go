@@ -0,0 +1,255 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package snapshot
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// PackageChange is a package that was added, removed or deprecated.
+type PackageChange struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	Type       string `json:"type,omitempty"`
+	Tier       string `json:"tier,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// VersionChange lists the versions a package gained.
+type VersionChange struct {
+	Account    string   `json:"account"`
+	Repository string   `json:"repository"`
+	Versions   []string `json:"versions"`
+	Latest     string   `json:"latest"`
+}
+
+// TierChange is a package whose tier changed.
+type TierChange struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+// PopularityChange is a package whose downloads or stars changed.
+type PopularityChange struct {
+	Account        string `json:"account"`
+	Repository     string `json:"repository"`
+	Downloads      int    `json:"downloads"`
+	DownloadsDelta int    `json:"downloadsDelta"`
+	Stars          int    `json:"stars"`
+	StarsDelta     int    `json:"starsDelta"`
+}
+
+// DiffReport describes what changed between two snapshots.
+type DiffReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	NewPackages        []PackageChange    `json:"newPackages"`
+	RemovedPackages    []PackageChange    `json:"removedPackages"`
+	DeprecatedPackages []PackageChange    `json:"deprecatedPackages"`
+	NewVersions        []VersionChange    `json:"newVersions"`
+	TierChanges        []TierChange       `json:"tierChanges"`
+	Popularity         []PopularityChange `json:"popularity"`
+}
+
+// Diff compares two snapshot manifests. Packages are deprecated if they became
+// deprecated between the snapshots. Popularity changes are ordered by the
+// change in downloads, largest first.
+func Diff(from, to *Manifest) *DiffReport {
+	r := &DiffReport{
+		From:               from.CreatedAt,
+		To:                 to.CreatedAt,
+		NewPackages:        []PackageChange{},
+		RemovedPackages:    []PackageChange{},
+		DeprecatedPackages: []PackageChange{},
+		NewVersions:        []VersionChange{},
+		TierChanges:        []TierChange{},
+		Popularity:         []PopularityChange{},
+	}
+
+	for _, e := range to.Packages {
+		p := e.Package
+		old, ok := from.Entry(p.Account, p.Repository)
+		if !ok {
+			r.NewPackages = append(r.NewPackages, change(p))
+			continue
+		}
+		o := old.Package
+		if deprecated(p) && !deprecated(o) {
+			r.DeprecatedPackages = append(r.DeprecatedPackages, change(p))
+		}
+		if added := newVersions(*old, e); len(added) > 0 {
+			r.NewVersions = append(r.NewVersions, VersionChange{Account: p.Account, Repository: p.Repository, Versions: added, Latest: added[len(added)-1]})
+		}
+		if o.Tier != p.Tier {
+			r.TierChanges = append(r.TierChanges, TierChange{Account: p.Account, Repository: p.Repository, From: o.Tier, To: p.Tier})
+		}
+		if p.Downloads != o.Downloads || p.Stars != o.Stars {
+			r.Popularity = append(r.Popularity, PopularityChange{
+				Account:        p.Account,
+				Repository:     p.Repository,
+				Downloads:      p.Downloads,
+				DownloadsDelta: p.Downloads - o.Downloads,
+				Stars:          p.Stars,
+				StarsDelta:     p.Stars - o.Stars,
+			})
+		}
+	}
+	for _, e := range from.Packages {
+		if _, ok := to.Entry(e.Package.Account, e.Package.Repository); !ok {
+			r.RemovedPackages = append(r.RemovedPackages, change(e.Package))
+		}
+	}
+
+	sortPackages(r.NewPackages)
+	sortPackages(r.RemovedPackages)
+	sortPackages(r.DeprecatedPackages)
+	slices.SortFunc(r.NewVersions, func(a, b VersionChange) int {
+		return cmp.Compare(a.Account+"/"+a.Repository, b.Account+"/"+b.Repository)
+	})
+	slices.SortFunc(r.TierChanges, func(a, b TierChange) int {
+		return cmp.Compare(a.Account+"/"+a.Repository, b.Account+"/"+b.Repository)
+	})
+	slices.SortFunc(r.Popularity, func(a, b PopularityChange) int {
+		return cmp.Or(cmp.Compare(b.DownloadsDelta, a.DownloadsDelta), cmp.Compare(b.StarsDelta, a.StarsDelta),
+			cmp.Compare(a.Account+"/"+a.Repository, b.Account+"/"+b.Repository))
+	})
+	return r
+}
+
+func change(p marketplace.Package) PackageChange {
+	return PackageChange{Account: p.Account, Repository: p.Repository, Type: p.Type, Tier: p.Tier, Version: p.Version}
+}
+
+func sortPackages(ps []PackageChange) {
+	slices.SortFunc(ps, func(a, b PackageChange) int {
+		return cmp.Compare(a.Account+"/"+a.Repository, b.Account+"/"+b.Repository)
+	})
+}
+
+// newVersions returns the versions of a package in the newer entry that are
+// not in the older one, oldest first. The latest version listed when a
+// snapshot was taken counts as one of its versions even if it was not
+// exported.
+func newVersions(from, to Entry) []string {
+	have := append(slices.Clone(from.Versions), from.Package.Version)
+	var out []string
+	for _, v := range append(slices.Clone(to.Versions), to.Package.Version) {
+		if v != "" && !slices.Contains(have, v) && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	semver.Sort(out)
+	return out
+}
+
+// deprecated reports whether a package is marked deprecated, either by a tag
+// or keyword, by a deprecated metadata field, or by a description that starts
+// by saying so.
+func deprecated(p marketplace.Package) bool {
+	for _, t := range append(slices.Clone(p.Tags), p.Keywords...) {
+		if strings.EqualFold(t, "deprecated") {
+			return true
+		}
+	}
+	if d, ok := p.Metadata["deprecated"].(bool); ok && d {
+		return true
+	}
+	d := strings.ToLower(strings.TrimSpace(p.Description))
+	return strings.HasPrefix(d, "deprecated") || strings.HasPrefix(d, "[deprecated]")
+}
+
+// Markdown renders the report as a markdown document for the mcp-snapshot
+// command. At most topN popularity changes are listed; zero lists them all.
+func (r *DiffReport) Markdown(topN int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Marketplace changes %s to %s\n", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))
+
+	section := func(title string, n int) bool {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", title, n)
+		if n == 0 {
+			b.WriteString("None.\n")
+		}
+		return n > 0
+	}
+	packages := func(ps []PackageChange) {
+		for _, p := range ps {
+			fmt.Fprintf(&b, "- **%s/%s**", p.Account, p.Repository)
+			var details []string
+			for _, d := range []string{p.Type, p.Tier, p.Version} {
+				if d != "" {
+					details = append(details, d)
+				}
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if section("New packages", len(r.NewPackages)) {
+		packages(r.NewPackages)
+	}
+	if section("New versions", len(r.NewVersions)) {
+		for _, v := range r.NewVersions {
+			fmt.Fprintf(&b, "- **%s/%s**: %s\n", v.Account, v.Repository, strings.Join(v.Versions, ", "))
+		}
+	}
+	if section("Deprecated packages", len(r.DeprecatedPackages)) {
+		packages(r.DeprecatedPackages)
+	}
+	if section("Removed packages", len(r.RemovedPackages)) {
+		packages(r.RemovedPackages)
+	}
+	if section("Tier changes", len(r.TierChanges)) {
+		for _, t := range r.TierChanges {
+			fmt.Fprintf(&b, "- **%s/%s**: %s → %s\n", t.Account, t.Repository, orNone(t.From), orNone(t.To))
+		}
+	}
+	pop := r.Popularity
+	if topN > 0 && len(pop) > topN {
+		pop = pop[:topN]
+	}
+	if section("Downloads and stars", len(r.Popularity)) {
+		b.WriteString("| Package | Downloads | Δ | Stars | Δ |\n|---|---:|---:|---:|---:|\n")
+		for _, p := range pop {
+			fmt.Fprintf(&b, "| %s/%s | %d | %+d | %d | %+d |\n", p.Account, p.Repository, p.Downloads, p.DownloadsDelta, p.Stars, p.StarsDelta)
+		}
+		if len(pop) < len(r.Popularity) {
+			fmt.Fprintf(&b, "\n%d more packages changed.\n", len(r.Popularity)-len(pop))
+		}
+	}
+	return b.String()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
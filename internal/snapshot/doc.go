@@ -0,0 +1,49 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+/*
+Package snapshot exports package metadata, resources, schemas, examples and
+docs from a catalog to a single archive, and serves such archives as
+read-only catalogs, for environments that cannot reach the marketplace.
+
+# Format
+
+A snapshot is a gzip compressed tar archive of JSON files. Format version 1
+holds:
+
+	snapshot.json
+		The Manifest: the format version, when the snapshot was taken, and
+		an Entry per package with its search listing and exported versions.
+	packages/<account>/<repository>/<version>/metadata.json
+		The marketplace.PackageMetadata of the version.
+	packages/<account>/<repository>/<version>/resources.json
+		The marketplace.PackageResources of the version.
+	packages/<account>/<repository>/<version>/definitions/<group>/<kind>.json
+		The CRD or XRD of a kind, exactly as the catalog returned it.
+	packages/<account>/<repository>/<version>/examples/<group>/<kind>.json
+		The marketplace.Examples of a kind.
+	packages/<account>/<repository>/<version>/compositions/<group>/<kind>/<name>.json
+		A composition of an XRD, exactly as the catalog returned it.
+	packages/<account>/<repository>/<version>/assets/<type>.json
+		A marketplace.AssetResponse with its content inlined, for the docs,
+		readme and releaseNotes asset types.
+
+Files may appear in any order. Optional files that could not be exported are
+omitted and listed in the manifest's warnings. Readers reject snapshots with a
+format version they do not know; a change that older readers cannot read
+safely must increment FormatVersion.
+*/
+package snapshot
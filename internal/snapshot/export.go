@@ -0,0 +1,307 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// pageSize is the number of search results requested at once.
+const pageSize = 100
+
+// Selection is a package to export.
+type Selection struct {
+	Account    string
+	Repository string
+
+	// Versions to export. No versions exports the latest version.
+	Versions []string
+}
+
+// Exporter exports packages from a catalog to a snapshot.
+type Exporter struct {
+	catalog    catalog.Catalog
+	httpClient *http.Client
+}
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithHTTPClient sets the client used to download assets the catalog returns
+// as URLs rather than inline content.
+func WithHTTPClient(c *http.Client) Option {
+	return func(e *Exporter) {
+		e.httpClient = c
+	}
+}
+
+// NewExporter returns an exporter that reads packages from the supplied
+// catalog.
+func NewExporter(c catalog.Catalog, opts ...Option) *Exporter {
+	e := &Exporter{catalog: c, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
+}
+
+// List selects the latest version of every package in an account.
+func (e *Exporter) List(ctx context.Context, account string) ([]Selection, error) {
+	var out []Selection
+	for pg := 0; ; pg++ {
+		resp, err := e.catalog.SearchPackages(ctx, marketplace.SearchParams{AccountName: account, Size: pageSize, Page: pg})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list packages of %s: %w", account, err)
+		}
+		for _, p := range resp.Packages {
+			if p.Account == account {
+				out = append(out, Selection{Account: p.Account, Repository: p.Repository})
+			}
+		}
+		if len(resp.Packages) < pageSize || (resp.Total > 0 && (pg+1)*pageSize >= resp.Total) {
+			return out, nil
+		}
+	}
+}
+
+// Export writes a snapshot of the selected packages to w and returns its
+// manifest. It fails if the metadata or resources of a selected version
+// cannot be read; other files that cannot be read are listed in the
+// manifest's warnings.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, sel []Selection) (*Manifest, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	x := &export{Exporter: e, tw: tw, m: &Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC(), Packages: []Entry{}}}
+
+	for _, s := range sel {
+		if err := x.pkg(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	if err := x.write(ManifestFile, x.m); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+	return x.m, nil
+}
+
+// export is an export in progress.
+type export struct {
+	*Exporter
+
+	tw *tar.Writer
+	m  *Manifest
+}
+
+// pkg exports the selected versions of a package.
+func (x *export) pkg(ctx context.Context, s Selection) error {
+	latest, err := x.catalog.GetPackageMetadata(ctx, s.Account, s.Repository, "", false)
+	if err != nil {
+		return fmt.Errorf("failed to get metadata of %s/%s: %w", s.Account, s.Repository, err)
+	}
+	versions := s.Versions
+	if len(versions) == 0 {
+		versions = []string{latest.LatestVersion}
+	}
+
+	e, ok := x.m.Entry(s.Account, s.Repository)
+	if !ok {
+		p := listing(latest)
+		p.Account, p.Repository = s.Account, s.Repository
+		x.m.Packages = append(x.m.Packages, Entry{Package: p})
+		e = &x.m.Packages[len(x.m.Packages)-1]
+	}
+	for _, v := range versions {
+		if v == "" {
+			return fmt.Errorf("package %s/%s has no version to export", s.Account, s.Repository)
+		}
+		if slices.Contains(e.Versions, v) {
+			continue
+		}
+		if err := x.version(ctx, s.Account, s.Repository, v); err != nil {
+			return err
+		}
+		e.Versions = append(e.Versions, v)
+	}
+	// Versions that are not semantic versions sort first.
+	semver.Sort(e.Versions)
+	return nil
+}
+
+// version exports a package version.
+func (x *export) version(ctx context.Context, account, repo, version string) error {
+	ref := fmt.Sprintf("%s/%s:%s", account, repo, version)
+	md, err := x.catalog.GetPackageMetadata(ctx, account, repo, version, false)
+	if err != nil {
+		return fmt.Errorf("failed to get metadata of %s: %w", ref, err)
+	}
+	if err := x.write(metadataFile(account, repo, version), md); err != nil {
+		return err
+	}
+	res, err := x.catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repo, version)
+	if err != nil {
+		return fmt.Errorf("failed to get resources of %s: %w", ref, err)
+	}
+	if err := x.write(resourcesFile(account, repo, version), res); err != nil {
+		return err
+	}
+
+	type groupKind struct{ group, kind string }
+	kinds := make([]groupKind, 0, len(res.CRDs)+len(res.XRDs))
+	for _, c := range res.CRDs {
+		kinds = append(kinds, groupKind{c.Group, c.Kind})
+	}
+	for _, d := range res.XRDs {
+		kinds = append(kinds, groupKind{d.Group, d.Kind})
+	}
+	for _, k := range kinds {
+		def, err := x.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repo, version, k.group, k.kind)
+		if err != nil {
+			x.warn("definition of %s.%s in %s: %v", k.kind, k.group, ref, err)
+		} else if err := x.writeRaw(definitionFile(account, repo, version, k.group, k.kind), []byte(def)); err != nil {
+			return err
+		}
+		ex, err := x.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, account, repo, version, k.group, k.kind)
+		switch {
+		case err != nil:
+			x.warn("examples of %s.%s in %s: %v", k.kind, k.group, ref, err)
+		case len(ex.Examples) > 0:
+			if err := x.write(examplesFile(account, repo, version, k.group, k.kind), ex); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range res.Compositions {
+		group, _, _ := strings.Cut(c.XrdAPIVersion, "/")
+		comp, err := x.catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, account, repo, version, group, c.XrdKind, c.Name)
+		if err != nil {
+			x.warn("composition %s in %s: %v", c.Name, ref, err)
+			continue
+		}
+		if err := x.writeRaw(compositionFile(account, repo, version, group, c.XrdKind, c.Name), []byte(comp)); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range AssetTypes {
+		a, err := x.asset(ctx, account, repo, version, t)
+		if err != nil {
+			x.warn("%s asset of %s: %v", t, ref, err)
+			continue
+		}
+		if a.Content == "" {
+			continue
+		}
+		if err := x.write(assetFile(account, repo, version, t), a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asset returns an asset with its content inlined, downloading it if the
+// catalog returns a URL.
+func (x *export) asset(ctx context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error) {
+	a, err := x.catalog.GetPackageAssets(ctx, account, repo, version, assetType)
+	if err != nil || a.Content != "" || a.URL == "" {
+		return a, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := x.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Read only.
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download asset: status %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset: %w", err)
+	}
+	// The URL is typically short lived, and unreachable where snapshots are
+	// used.
+	return &marketplace.AssetResponse{Content: string(b), Type: a.Type}, nil
+}
+
+func (x *export) warn(format string, args ...any) {
+	x.m.Warnings = append(x.m.Warnings, fmt.Sprintf(format, args...))
+}
+
+// write writes the JSON encoding of v to the archive.
+func (x *export) write(name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return x.writeRaw(name, b)
+}
+
+// writeRaw writes a file to the archive.
+func (x *export) writeRaw(name string, b []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(b)), ModTime: x.m.CreatedAt, Typeflag: tar.TypeReg}
+	if err := x.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := x.tw.Write(b); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// listing returns the search listing of a package from its metadata.
+func listing(md *marketplace.PackageMetadata) marketplace.Package {
+	return marketplace.Package{
+		Account:     md.Account,
+		Repository:  md.Repository,
+		Name:        md.Name,
+		Version:     md.LatestVersion,
+		Description: md.Description,
+		Type:        md.Type,
+		Public:      md.Public,
+		Tier:        md.Tier,
+		Stars:       md.Stars,
+		Downloads:   md.Downloads,
+		CreatedAt:   md.CreatedAt,
+		UpdatedAt:   md.UpdatedAt,
+		Tags:        md.Tags,
+		Keywords:    md.Keywords,
+	}
+}
@@ -0,0 +1,235 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// FormatVersion is the version of the snapshot format written by this
+// package, and the only version it reads.
+const FormatVersion = 1
+
+const (
+	// ManifestFile is the name of the manifest in a snapshot.
+	ManifestFile = "snapshot.json"
+
+	// maxFileSize bounds the size of a single file read from a snapshot.
+	maxFileSize = 64 << 20
+)
+
+// AssetTypes are the asset types exported to snapshots.
+var AssetTypes = []string{"docs", "readme", "releaseNotes"}
+
+// Manifest describes the contents of a snapshot.
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Packages      []Entry   `json:"packages"`
+
+	// Warnings lists the optional files that could not be exported.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Entry is a package in a snapshot.
+type Entry struct {
+	// Package is the package's search listing when the snapshot was taken.
+	Package marketplace.Package `json:"package"`
+
+	// Versions are the exported versions, oldest first.
+	Versions []string `json:"versions"`
+}
+
+// Entry returns the entry of a package, if the snapshot has it.
+func (m *Manifest) Entry(account, repository string) (*Entry, bool) {
+	for i := range m.Packages {
+		if p := m.Packages[i].Package; p.Account == account && p.Repository == repository {
+			return &m.Packages[i], true
+		}
+	}
+	return nil, false
+}
+
+// Snapshot is a snapshot read into memory.
+type Snapshot struct {
+	Manifest Manifest
+
+	files map[string][]byte
+}
+
+// Open reads the snapshot at path.
+func Open(path string) (*Snapshot, error) {
+	f, err := os.Open(path) //nolint:gosec // Snapshot paths are configured by the operator.
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+	s, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// OpenManifest reads only the manifest of the snapshot at path.
+func OpenManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path) //nolint:gosec // Snapshot paths are configured by the operator.
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+	files, err := readFiles(f, func(name string) bool { return name == ManifestFile })
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	m, err := manifest(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Read reads a snapshot.
+func Read(r io.Reader) (*Snapshot, error) {
+	files, err := readFiles(r, func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	m, err := manifest(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Manifest: *m, files: files}, nil
+}
+
+// readFiles reads the files of a snapshot archive that match keep.
+func readFiles(r io.Reader, keep func(name string) bool) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !keep(name) {
+			continue
+		}
+		if hdr.Size > maxFileSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", hdr.Name, maxFileSize)
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+		files[name] = b
+	}
+}
+
+// manifest decodes the manifest of a snapshot.
+func manifest(files map[string][]byte) (*Manifest, error) {
+	raw, ok := files[ManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("archive does not contain %s", ManifestFile)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", ManifestFile, err)
+	}
+	if m.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported snapshot format version %d, expected %d", m.FormatVersion, FormatVersion)
+	}
+	return m, nil
+}
+
+// Accounts returns the accounts the snapshot has packages in.
+func (s *Snapshot) Accounts() []string {
+	var out []string
+	for _, e := range s.Manifest.Packages {
+		if !slices.Contains(out, e.Package.Account) {
+			out = append(out, e.Package.Account)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// file returns the contents of a file in the snapshot.
+func (s *Snapshot) file(name string) ([]byte, bool) {
+	b, ok := s.files[name]
+	return b, ok
+}
+
+// decode decodes a JSON file in the snapshot into v.
+func (s *Snapshot) decode(name string, v any) error {
+	b, ok := s.file(name)
+	if !ok {
+		return fmt.Errorf("%s is not in the snapshot", name)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// Paths of the files of a package version.
+
+func versionDir(account, repository, version string) string {
+	return path.Join("packages", account, repository, version)
+}
+
+func metadataFile(account, repository, version string) string {
+	return path.Join(versionDir(account, repository, version), "metadata.json")
+}
+
+func resourcesFile(account, repository, version string) string {
+	return path.Join(versionDir(account, repository, version), "resources.json")
+}
+
+func definitionFile(account, repository, version, group, kind string) string {
+	return path.Join(versionDir(account, repository, version), "definitions", group, kind+".json")
+}
+
+func examplesFile(account, repository, version, group, kind string) string {
+	return path.Join(versionDir(account, repository, version), "examples", group, kind+".json")
+}
+
+func compositionFile(account, repository, version, group, kind, name string) string {
+	return path.Join(versionDir(account, repository, version), "compositions", group, kind, name+".json")
+}
+
+func assetFile(account, repository, version, assetType string) string {
+	return path.Join(versionDir(account, repository, version), "assets", assetType+".json")
+}
@@ -0,0 +1,221 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// fakeCatalog serves versions of one package, with a docs asset served from
+// docsURL.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	docsURL string
+}
+
+func (f *fakeCatalog) SearchPackages(_ context.Context, _ marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	return &marketplace.SearchResponse{Packages: []marketplace.Package{{Account: "upbound", Repository: "configuration-aws-network"}}, Total: 1}, nil
+}
+
+func (f *fakeCatalog) GetPackageMetadata(_ context.Context, account, repo, version string, _ bool) (*marketplace.PackageMetadata, error) {
+	if version == "" {
+		version = "v1.1.0"
+	}
+	return &marketplace.PackageMetadata{
+		Account: account, Repository: repo, Version: version, Type: "configuration", Tier: "official", Downloads: 42,
+		Versions: []string{"v1.0.0", "v1.1.0"}, LatestVersion: "v1.1.0",
+	}, nil
+}
+
+func (f *fakeCatalog) GetPackageAssets(_ context.Context, _, _, _, assetType string) (*marketplace.AssetResponse, error) {
+	switch assetType {
+	case "docs":
+		return &marketplace.AssetResponse{URL: f.docsURL, Type: "docs"}, nil
+	case "readme":
+		return &marketplace.AssetResponse{Content: "# Network", Type: "readme"}, nil
+	default:
+		return nil, errors.New("not found")
+	}
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, account, repo, _ string) (*marketplace.PackageResources, error) {
+	return &marketplace.PackageResources{
+		PackageMeta:  marketplace.PackageMeta{Account: account, Repository: repo},
+		XRDs:         []marketplace.XRDMeta{{Group: "aws.platform.upbound.io", Kind: "XNetwork"}},
+		Compositions: []marketplace.CompositionMeta{{Name: "xnetworks", XrdAPIVersion: "aws.platform.upbound.io/v1alpha1", XrdKind: "XNetwork"}},
+	}, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(_ context.Context, _, _, version, _, kind string) (string, error) {
+	return `{"kind":"` + kind + `","version":"` + version + `"}`, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(_ context.Context, _, _, _, _, _, name string) (string, error) {
+	return `{"metadata":{"name":"` + name + `"}}`, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(_ context.Context, _, _, version, _, _ string) (*marketplace.Examples, error) {
+	if version == "v1.0.0" {
+		return &marketplace.Examples{}, nil
+	}
+	return &marketplace.Examples{Examples: []string{"kind: XNetwork"}}, nil
+}
+
+func TestExportAndRead(t *testing.T) {
+	docs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("full docs"))
+	}))
+	defer docs.Close()
+
+	e := NewExporter(&fakeCatalog{docsURL: docs.URL})
+	sel, err := e.List(context.Background(), "upbound")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	sel = append(sel, Selection{Account: "upbound", Repository: "configuration-aws-network", Versions: []string{"v1.0.0"}})
+
+	var buf bytes.Buffer
+	m, err := e.Export(context.Background(), &buf, sel)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(m.Warnings) != 2 {
+		t.Errorf("Export() warnings = %v, want the missing releaseNotes of both versions", m.Warnings)
+	}
+
+	s, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	ctx := context.Background()
+
+	md, err := s.GetPackageMetadata(ctx, "upbound", "configuration-aws-network", "latest", false)
+	if err != nil {
+		t.Fatalf("GetPackageMetadata() error = %v", err)
+	}
+	if md.Version != "v1.1.0" || !slices.Equal(md.Versions, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("GetPackageMetadata() = %s with versions %v, want v1.1.0 with both versions", md.Version, md.Versions)
+	}
+	if _, err := s.GetPackageMetadata(ctx, "upbound", "configuration-aws-network", "v0.9.0", false); err == nil {
+		t.Error("GetPackageMetadata() of a version not in the snapshot should fail")
+	}
+
+	resp, _ := s.SearchPackages(ctx, marketplace.SearchParams{Query: "network", Tier: "official"})
+	if len(resp.Packages) != 1 || resp.Packages[0].Downloads != 42 {
+		t.Errorf("SearchPackages() = %+v, want the package with its listing", resp.Packages)
+	}
+
+	def, err := s.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, "upbound", "configuration-aws-network", "v1.0.0", "aws.platform.upbound.io", "XNetwork")
+	if err != nil || def != `{"kind":"XNetwork","version":"v1.0.0"}` {
+		t.Errorf("GetV1PackagesAccountRepositoryVersionResourcesGroupKind() = %s, %v", def, err)
+	}
+	comp, err := s.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, "upbound", "configuration-aws-network", "", "aws.platform.upbound.io", "XNetwork", "xnetworks")
+	if err != nil || comp != `{"metadata":{"name":"xnetworks"}}` {
+		t.Errorf("GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition() = %s, %v", comp, err)
+	}
+	for v, want := range map[string]int{"v1.0.0": 0, "v1.1.0": 1} {
+		ex, err := s.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, "upbound", "configuration-aws-network", v, "aws.platform.upbound.io", "XNetwork")
+		if err != nil || len(ex.Examples) != want {
+			t.Errorf("examples of %s = %v, %v, want %d", v, ex, err, want)
+		}
+	}
+
+	a, err := s.GetPackageAssets(ctx, "upbound", "configuration-aws-network", "", "docs")
+	if err != nil || a.Content != "full docs" || a.URL != "" {
+		t.Errorf("GetPackageAssets(docs) = %+v, %v, want the downloaded docs inlined", a, err)
+	}
+	if _, err := s.GetPackageAssets(ctx, "upbound", "configuration-aws-network", "", "releaseNotes"); err == nil {
+		t.Error("GetPackageAssets(releaseNotes) should fail for an asset that was not exported")
+	}
+}
+
+func TestReadRejectsUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	b := []byte(`{"formatVersion": 2}`)
+	_ = tw.WriteHeader(&tar.Header{Name: ManifestFile, Mode: 0o644, Size: int64(len(b)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(b)
+	_ = tw.Close()
+	_ = gz.Close()
+
+	if _, err := Read(&buf); err == nil {
+		t.Error("Read() should reject an unknown format version")
+	}
+}
+
+func entry(repo, version, tier string, downloads int, versions ...string) Entry {
+	return Entry{
+		Package:  marketplace.Package{Account: "upbound", Repository: repo, Version: version, Tier: tier, Downloads: downloads},
+		Versions: versions,
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := &Manifest{Packages: []Entry{
+		entry("provider-aws-s3", "v1.19.0", "official", 100, "v1.19.0"),
+		entry("provider-old", "v0.1.0", "community", 5),
+		entry("provider-gcp", "v1.0.0", "community", 10),
+	}}
+	deprecatedGCP := entry("provider-gcp", "v1.0.0", "official", 10)
+	deprecatedGCP.Package.Keywords = []string{"Deprecated"}
+	to := &Manifest{Packages: []Entry{
+		entry("provider-aws-s3", "v1.21.0", "official", 150, "v1.20.0"),
+		deprecatedGCP,
+		entry("provider-new", "v0.1.0", "community", 0),
+	}}
+
+	r := Diff(from, to)
+	if len(r.NewPackages) != 1 || r.NewPackages[0].Repository != "provider-new" {
+		t.Errorf("NewPackages = %+v, want provider-new", r.NewPackages)
+	}
+	if len(r.RemovedPackages) != 1 || r.RemovedPackages[0].Repository != "provider-old" {
+		t.Errorf("RemovedPackages = %+v, want provider-old", r.RemovedPackages)
+	}
+	if len(r.DeprecatedPackages) != 1 || r.DeprecatedPackages[0].Repository != "provider-gcp" {
+		t.Errorf("DeprecatedPackages = %+v, want provider-gcp", r.DeprecatedPackages)
+	}
+	if len(r.NewVersions) != 1 || !slices.Equal(r.NewVersions[0].Versions, []string{"v1.20.0", "v1.21.0"}) {
+		t.Errorf("NewVersions = %+v, want v1.20.0 and v1.21.0 of provider-aws-s3", r.NewVersions)
+	}
+	if len(r.TierChanges) != 1 || r.TierChanges[0].From != "community" || r.TierChanges[0].To != "official" {
+		t.Errorf("TierChanges = %+v, want provider-gcp from community to official", r.TierChanges)
+	}
+	if len(r.Popularity) != 1 || r.Popularity[0].DownloadsDelta != 50 {
+		t.Errorf("Popularity = %+v, want +50 downloads of provider-aws-s3", r.Popularity)
+	}
+
+	md := r.Markdown(0)
+	for _, want := range []string{"## New packages (1)", "- **upbound/provider-aws-s3**: v1.20.0, v1.21.0", "community → official", "| upbound/provider-aws-s3 | 150 | +50 | 0 | +0 |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() does not contain %q:\n%s", want, md)
+		}
+	}
+}
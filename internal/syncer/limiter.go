@@ -0,0 +1,136 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package syncer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// maxBackoff bounds the extra delay added after consecutive failed requests.
+const maxBackoff = 5 * time.Minute
+
+// limiter spaces requests at least interval apart. After a failed request
+// each subsequent request is delayed by an extra backoff that doubles with
+// every consecutive failure, so that the worker slows down when a catalog
+// rejects it for sending too many requests.
+type limiter struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	next    time.Time
+	backoff time.Duration
+}
+
+// wait blocks until the next request may be sent.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval + l.backoff)
+	l.mu.Unlock()
+
+	t := time.NewTimer(at.Sub(now))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// done records the outcome of a request.
+func (l *limiter) done(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil {
+		l.backoff = 0
+		return
+	}
+	l.backoff = min(max(2*l.backoff, l.interval, time.Second), maxBackoff)
+}
+
+// limitedCatalog rate limits the requests sent to a catalog.
+type limitedCatalog struct {
+	catalog.Catalog
+
+	l *limiter
+}
+
+func limit[T any](ctx context.Context, l *limiter, fn func() (T, error)) (T, error) {
+	if err := l.wait(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := fn()
+	l.done(err)
+	return v, err
+}
+
+func (c *limitedCatalog) SearchPackages(ctx context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	return limit(ctx, c.l, func() (*marketplace.SearchResponse, error) { return c.Catalog.SearchPackages(ctx, params) })
+}
+
+func (c *limitedCatalog) GetRepositories(ctx context.Context, account string, params marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	return limit(ctx, c.l, func() (*marketplace.RepositoryResponse, error) {
+		return c.Catalog.GetRepositories(ctx, account, params)
+	})
+}
+
+func (c *limitedCatalog) GetPackageMetadata(ctx context.Context, account, repo, version string, useV1 bool) (*marketplace.PackageMetadata, error) {
+	return limit(ctx, c.l, func() (*marketplace.PackageMetadata, error) {
+		return c.Catalog.GetPackageMetadata(ctx, account, repo, version, useV1)
+	})
+}
+
+func (c *limitedCatalog) GetPackageAssets(ctx context.Context, account, repo, version, assetType string) (*marketplace.AssetResponse, error) {
+	return limit(ctx, c.l, func() (*marketplace.AssetResponse, error) {
+		return c.Catalog.GetPackageAssets(ctx, account, repo, version, assetType)
+	})
+}
+
+func (c *limitedCatalog) GetV1PackagesAccountRepositoryVersionResources(ctx context.Context, account, repositoryName, version string) (*marketplace.PackageResources, error) {
+	return limit(ctx, c.l, func() (*marketplace.PackageResources, error) {
+		return c.Catalog.GetV1PackagesAccountRepositoryVersionResources(ctx, account, repositoryName, version)
+	})
+}
+
+func (c *limitedCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (string, error) {
+	return limit(ctx, c.l, func() (string, error) {
+		return c.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKind(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+	})
+}
+
+func (c *limitedCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind, compositionName string) (string, error) {
+	return limit(ctx, c.l, func() (string, error) {
+		return c.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindComposition(ctx, account, repositoryName, version, resourceGroup, resourceKind, compositionName)
+	})
+}
+
+func (c *limitedCatalog) GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx context.Context, account, repositoryName, version, resourceGroup, resourceKind string) (*marketplace.Examples, error) {
+	return limit(ctx, c.l, func() (*marketplace.Examples, error) {
+		return c.Catalog.GetV1PackagesAccountRepositoryVersionResourcesGroupKindExamples(ctx, account, repositoryName, version, resourceGroup, resourceKind)
+	})
+}
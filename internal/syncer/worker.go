@@ -0,0 +1,404 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/cache"
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// FormatVersion is the version of the on-disk progress format. Progress
+// written in another format is discarded.
+const FormatVersion = 1
+
+const (
+	// DefaultInterval is the time between the end of one sync and the start
+	// of the next.
+	DefaultInterval = 6 * time.Hour
+
+	// DefaultRate is the default number of catalog requests per second.
+	DefaultRate = 2.0
+
+	// checkpointEvery is the number of packages synced between saves of
+	// progress and indexes.
+	checkpointEvery = 20
+
+	pageSize = 100
+)
+
+// Worker states.
+const (
+	StateIdle    = "idle"
+	StateSyncing = "syncing"
+	StateStopped = "stopped"
+)
+
+// An Indexer indexes package versions.
+type Indexer interface {
+	// Indexed reports whether a package version is already indexed.
+	Indexed(account, repository, version string) bool
+
+	// Index indexes a package version, reading it through the supplied
+	// catalog.
+	Index(ctx context.Context, c catalog.Catalog, account, repository, version string) error
+
+	// Save persists the index.
+	Save() error
+}
+
+// progress is the on-disk form of a worker's progress through a sync.
+type progress struct {
+	FormatVersion   int       `json:"formatVersion"`
+	Cycle           int       `json:"cycle"`
+	StartedAt       time.Time `json:"startedAt,omitzero"`
+	LastCompletedAt time.Time `json:"lastCompletedAt,omitzero"`
+
+	// Cursors maps an account to the last repository synced in the current
+	// cycle. Accounts without a cursor are synced from the start.
+	Cursors map[string]string `json:"cursors"`
+
+	// Done lists the accounts fully synced in the current cycle.
+	Done []string `json:"done,omitempty"`
+}
+
+// Status reports the state of a worker.
+type Status struct {
+	State           string    `json:"state"`
+	Accounts        []string  `json:"accounts"`
+	Cycle           int       `json:"cycle"`
+	StartedAt       time.Time `json:"startedAt,omitzero"`
+	LastCompletedAt time.Time `json:"lastCompletedAt,omitzero"`
+	NextRunAt       time.Time `json:"nextRunAt,omitzero"`
+
+	// Account is the account being synced.
+	Account string `json:"account,omitempty"`
+
+	// Checked, Indexed and Failed count the packages of the current or last
+	// cycle.
+	Checked int `json:"checked"`
+	Indexed int `json:"indexed"`
+	Failed  int `json:"failed"`
+
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitzero"`
+}
+
+// Worker periodically syncs the packages of a set of accounts into an
+// Indexer.
+type Worker struct {
+	catalog  catalog.Catalog
+	indexer  Indexer
+	accounts []string
+	interval time.Duration
+	path     string
+	limiter  *limiter
+
+	mu       sync.RWMutex
+	status   Status
+	progress progress
+}
+
+// Option configures a Worker.
+type Option func(*Worker)
+
+// WithInterval sets the time between the end of one sync and the start of the
+// next.
+func WithInterval(d time.Duration) Option {
+	return func(w *Worker) {
+		w.interval = d
+	}
+}
+
+// WithRate sets the maximum number of catalog requests per second.
+func WithRate(perSecond float64) Option {
+	return func(w *Worker) {
+		if perSecond > 0 {
+			w.limiter.interval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// WithProgressFile persists sync progress at path, so that a restarted worker
+// resumes an interrupted sync.
+func WithProgressFile(path string) Option {
+	return func(w *Worker) {
+		w.path = path
+	}
+}
+
+// NewWorker returns a worker that syncs the packages of the supplied accounts
+// from a catalog into an indexer.
+func NewWorker(c catalog.Catalog, idx Indexer, accounts []string, opts ...Option) *Worker {
+	w := &Worker{
+		indexer:  idx,
+		accounts: accounts,
+		interval: DefaultInterval,
+		limiter:  &limiter{interval: time.Duration(float64(time.Second) / DefaultRate)},
+	}
+	for _, o := range opts {
+		o(w)
+	}
+	w.catalog = &limitedCatalog{Catalog: c, l: w.limiter}
+	w.progress = progress{FormatVersion: FormatVersion, Cursors: map[string]string{}}
+	var p progress
+	ok, err := cache.ReadJSON(w.path, &p)
+	if err != nil {
+		log.Printf("Warning: Ignoring sync progress: %v", err)
+	}
+	if ok && p.FormatVersion == FormatVersion && p.Cursors != nil {
+		w.progress = p
+	}
+	w.status = Status{
+		State:           StateIdle,
+		Accounts:        accounts,
+		Cycle:           w.progress.Cycle,
+		StartedAt:       w.progress.StartedAt,
+		LastCompletedAt: w.progress.LastCompletedAt,
+	}
+	return w
+}
+
+// Status returns the state of the worker.
+func (w *Worker) Status() Status {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	s := w.status
+	s.Accounts = slices.Clone(s.Accounts)
+	return s
+}
+
+// Run syncs the configured accounts until the context is cancelled, waiting
+// the configured interval between syncs. A sync interrupted by an earlier run
+// is resumed first; otherwise the first sync starts once the interval has
+// passed since the last one completed.
+func (w *Worker) Run(ctx context.Context) {
+	defer w.update(func(s *Status) { s.State, s.NextRunAt = StateStopped, time.Time{} })
+	for {
+		w.mu.RLock()
+		next := w.progress.LastCompletedAt.Add(w.interval)
+		if w.progress.StartedAt.After(w.progress.LastCompletedAt) {
+			next = time.Time{}
+		}
+		w.mu.RUnlock()
+
+		if d := time.Until(next); d > 0 {
+			w.update(func(s *Status) { s.NextRunAt = next })
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		if err := w.Sync(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Warning: Catalog sync failed: %v", err)
+		}
+	}
+}
+
+// Sync runs one sync of every configured account, resuming an interrupted
+// sync if there is one. Packages that fail to sync are counted and skipped.
+func (w *Worker) Sync(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.progress.StartedAt.After(w.progress.LastCompletedAt) {
+		w.progress.Cycle++
+		w.progress.StartedAt = time.Now().UTC()
+		w.progress.Cursors = map[string]string{}
+		w.progress.Done = nil
+	}
+	w.status.State = StateSyncing
+	w.status.Cycle, w.status.StartedAt = w.progress.Cycle, w.progress.StartedAt
+	w.status.NextRunAt = time.Time{}
+	w.status.Checked, w.status.Indexed, w.status.Failed = 0, 0, 0
+	w.mu.Unlock()
+	defer w.update(func(s *Status) { s.State, s.Account = StateIdle, "" })
+
+	for _, account := range w.accounts {
+		w.mu.RLock()
+		done := slices.Contains(w.progress.Done, account)
+		w.mu.RUnlock()
+		if done {
+			continue
+		}
+		if err := w.syncAccount(ctx, account); err != nil {
+			if ctx.Err() != nil {
+				return errors.Join(err, w.checkpoint())
+			}
+			// Retry the account in the next cycle rather than blocking on it.
+			w.fail(err)
+		}
+		w.mu.Lock()
+		w.progress.Done = append(w.progress.Done, account)
+		w.mu.Unlock()
+	}
+
+	w.mu.Lock()
+	w.progress.LastCompletedAt = time.Now().UTC()
+	w.status.LastCompletedAt = w.progress.LastCompletedAt
+	w.mu.Unlock()
+	return w.checkpoint()
+}
+
+// syncAccount syncs the repositories of an account in name order, starting
+// after the account's cursor.
+func (w *Worker) syncAccount(ctx context.Context, account string) error {
+	w.update(func(s *Status) { s.Account = account })
+	repos, err := w.repositories(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	cursor := w.progress.Cursors[account]
+	w.mu.RUnlock()
+	synced := 0
+	for _, repo := range repos {
+		if repo <= cursor {
+			continue
+		}
+		indexed, err := w.syncPackage(ctx, account, repo)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		w.update(func(s *Status) {
+			s.Checked++
+			switch {
+			case err != nil:
+				s.Failed++
+			case indexed:
+				s.Indexed++
+			}
+		})
+		if err != nil {
+			w.fail(err)
+		}
+
+		w.mu.Lock()
+		w.progress.Cursors[account] = repo
+		w.mu.Unlock()
+		if synced++; synced%checkpointEvery == 0 {
+			if err := w.checkpoint(); err != nil {
+				return err
+			}
+		}
+	}
+	return w.checkpoint()
+}
+
+// repositories returns the sorted names of an account's repositories, found
+// by listing them and by searching for the account's packages. Listings may
+// omit repositories the caller cannot list, and search results packages that
+// are not public, so the union of both is used.
+func (w *Worker) repositories(ctx context.Context, account string) ([]string, error) {
+	names := map[string]bool{}
+	var errs []error
+	for page := 0; ; page++ {
+		resp, err := w.catalog.GetRepositories(ctx, account, marketplace.RepositoryParams{Size: pageSize, Page: page})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list repositories of %s: %w", account, err))
+			break
+		}
+		for _, r := range resp.Repositories {
+			names[r.Name] = true
+		}
+		if len(resp.Repositories) < pageSize || (resp.Count > 0 && (page+1)*pageSize >= resp.Count) {
+			break
+		}
+	}
+	for page := 0; ; page++ {
+		resp, err := w.catalog.SearchPackages(ctx, marketplace.SearchParams{AccountName: account, Size: pageSize, Page: page})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to search packages of %s: %w", account, err))
+			break
+		}
+		for _, p := range resp.Packages {
+			if p.Account == account {
+				names[p.Repository] = true
+			}
+		}
+		if len(resp.Packages) < pageSize || (resp.Total > 0 && (page+1)*pageSize >= resp.Total) {
+			break
+		}
+	}
+	if len(names) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	out := make([]string, 0, len(names))
+	for n := range names {
+		out = append(out, n)
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// syncPackage refreshes the metadata of a package and indexes its latest
+// version if it is not already indexed.
+func (w *Worker) syncPackage(ctx context.Context, account, repo string) (bool, error) {
+	md, err := w.catalog.GetPackageMetadata(ctx, account, repo, "", false)
+	if err != nil {
+		return false, fmt.Errorf("failed to get metadata of %s/%s: %w", account, repo, err)
+	}
+	version := md.LatestVersion
+	if version == "" || w.indexer.Indexed(account, repo, version) {
+		return false, nil
+	}
+	if err := w.indexer.Index(ctx, w.catalog, account, repo, version); err != nil {
+		return false, fmt.Errorf("failed to index %s/%s:%s: %w", account, repo, version, err)
+	}
+	return true, nil
+}
+
+// checkpoint saves the indexes and then the progress, so that saved progress
+// never runs ahead of saved indexes.
+func (w *Worker) checkpoint() error {
+	if err := w.indexer.Save(); err != nil {
+		return fmt.Errorf("failed to save indexes: %w", err)
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if err := cache.WriteJSON(w.path, w.progress); err != nil {
+		return fmt.Errorf("failed to save sync progress: %w", err)
+	}
+	return nil
+}
+
+// fail records the last error the worker encountered.
+func (w *Worker) fail(err error) {
+	log.Printf("Warning: Catalog sync: %v", err)
+	w.update(func(s *Status) {
+		s.LastError, s.LastErrorAt = err.Error(), time.Now().UTC()
+	})
+}
+
+func (w *Worker) update(fn func(s *Status)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(&w.status)
+}
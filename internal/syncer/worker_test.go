@@ -0,0 +1,150 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// fakeCatalog lists some repositories and finds others by search, and
+// records the packages whose metadata is read.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	listed   []string
+	searched []string
+	read     []string
+}
+
+func (f *fakeCatalog) GetRepositories(_ context.Context, account string, _ marketplace.RepositoryParams) (*marketplace.RepositoryResponse, error) {
+	resp := &marketplace.RepositoryResponse{}
+	for _, r := range f.listed {
+		resp.Repositories = append(resp.Repositories, marketplace.Repository{Account: account, Name: r})
+	}
+	return resp, nil
+}
+
+func (f *fakeCatalog) SearchPackages(_ context.Context, params marketplace.SearchParams) (*marketplace.SearchResponse, error) {
+	resp := &marketplace.SearchResponse{}
+	for _, r := range f.searched {
+		resp.Packages = append(resp.Packages, marketplace.Package{Account: params.AccountName, Repository: r})
+	}
+	return resp, nil
+}
+
+func (f *fakeCatalog) GetPackageMetadata(_ context.Context, account, repo, _ string, _ bool) (*marketplace.PackageMetadata, error) {
+	f.read = append(f.read, account+"/"+repo)
+	if repo == "broken" {
+		return nil, errors.New("boom")
+	}
+	return &marketplace.PackageMetadata{Account: account, Repository: repo, LatestVersion: "v1.0.0"}, nil
+}
+
+// fakeIndexer records indexed packages, and calls stop after indexing the
+// package named stopAfter.
+type fakeIndexer struct {
+	indexed   []string
+	saved     int
+	stopAfter string
+	stop      func()
+}
+
+func (f *fakeIndexer) Indexed(account, repository, _ string) bool {
+	return slices.Contains(f.indexed, account+"/"+repository)
+}
+
+func (f *fakeIndexer) Index(_ context.Context, _ catalog.Catalog, account, repository, _ string) error {
+	f.indexed = append(f.indexed, account+"/"+repository)
+	if repository == f.stopAfter {
+		f.stop()
+	}
+	return nil
+}
+
+func (f *fakeIndexer) Save() error {
+	f.saved++
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	c := &fakeCatalog{listed: []string{"provider-b", "broken"}, searched: []string{"provider-a", "provider-b"}}
+	idx := &fakeIndexer{indexed: []string{"upbound/provider-a"}}
+	w := NewWorker(c, idx, []string{"upbound"}, WithRate(1000))
+
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if want := []string{"upbound/broken", "upbound/provider-a", "upbound/provider-b"}; !slices.Equal(c.read, want) {
+		t.Errorf("read metadata of %v, want %v", c.read, want)
+	}
+	if want := []string{"upbound/provider-a", "upbound/provider-b"}; !slices.Equal(idx.indexed, want) {
+		t.Errorf("indexed %v, want %v", idx.indexed, want)
+	}
+
+	s := w.Status()
+	if s.State != StateIdle || s.Cycle != 1 || s.Checked != 3 || s.Indexed != 1 || s.Failed != 1 {
+		t.Errorf("Status() = %+v, want idle after cycle 1 with 3 checked, 1 indexed and 1 failed", s)
+	}
+	if s.LastCompletedAt.IsZero() || s.LastError == "" {
+		t.Errorf("Status() = %+v, want a completion time and the last error", s)
+	}
+}
+
+func TestSyncResumes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.json")
+	c := &fakeCatalog{listed: []string{"a", "b", "c", "d"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	idx := &fakeIndexer{stopAfter: "c", stop: cancel}
+
+	w := NewWorker(c, idx, []string{"upbound"}, WithRate(1000), WithProgressFile(path))
+	if err := w.Sync(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sync() error = %v, want context.Canceled", err)
+	}
+	if idx.saved == 0 {
+		t.Error("indexes were not saved when the sync was interrupted")
+	}
+
+	// The interrupted package was not recorded as synced, so it is synced
+	// again along with the packages after it.
+	c.read = nil
+	w = NewWorker(c, idx, []string{"upbound"}, WithRate(1000), WithProgressFile(path))
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("resumed Sync() error = %v", err)
+	}
+	if want := []string{"upbound/c", "upbound/d"}; !slices.Equal(c.read, want) {
+		t.Errorf("resumed sync read %v, want %v", c.read, want)
+	}
+	if s := w.Status(); s.Cycle != 1 {
+		t.Errorf("resumed sync is cycle %d, want 1", s.Cycle)
+	}
+
+	// A completed sync starts the next cycle from the beginning.
+	c.read = nil
+	if err := w.Sync(context.Background()); err != nil {
+		t.Fatalf("next Sync() error = %v", err)
+	}
+	if len(c.read) != 4 || w.Status().Cycle != 2 {
+		t.Errorf("next sync read %v in cycle %d, want all 4 packages in cycle 2", c.read, w.Status().Cycle)
+	}
+}
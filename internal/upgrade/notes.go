@@ -0,0 +1,73 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package upgrade
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	bulletRe  = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.+)$`)
+	headingRe = regexp.MustCompile(`^\s*(?:#+\s*(.+?)\s*#*|\*\*(.+?)\*\*:?)\s*$`)
+
+	// noiseRe matches release note entries that rarely matter when upgrading,
+	// such as dependency bumps.
+	noiseRe = regexp.MustCompile(`(?i)^(?:bump|chore|build\(deps\)|ci[:(]|update module|\*\*full changelog)`)
+)
+
+// Notes are the entries of a version's release notes, classified by their
+// relevance to an upgrade.
+type Notes struct {
+	Notable      []string `json:"notable,omitempty"`
+	Breaking     []string `json:"breaking,omitempty"`
+	Deprecations []string `json:"deprecations,omitempty"`
+}
+
+// ParseNotes classifies the entries of markdown release notes. List items
+// mentioning deprecations, or under a deprecation heading, are deprecations.
+// Items mentioning breaking changes, or under such a heading, are breaking.
+// Other items are notable, except for routine entries such as dependency
+// bumps. Paragraphs are only kept if they mention a deprecation or breaking
+// change.
+func ParseNotes(md string) Notes {
+	var n Notes
+	var heading string
+	for _, line := range strings.Split(md, "\n") {
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			heading = strings.ToLower(m[1] + m[2])
+			continue
+		}
+		text, bullet := strings.TrimSpace(line), false
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			text, bullet = strings.TrimSpace(m[1]), true
+		}
+		if text == "" || noiseRe.MatchString(text) {
+			continue
+		}
+		lower := strings.ToLower(text)
+		switch {
+		case strings.Contains(lower, "deprecat") || (bullet && strings.Contains(heading, "deprecat")):
+			n.Deprecations = append(n.Deprecations, text)
+		case strings.Contains(lower, "breaking") || (bullet && strings.Contains(heading, "breaking")):
+			n.Breaking = append(n.Breaking, text)
+		case bullet:
+			n.Notable = append(n.Notable, text)
+		}
+	}
+	return n
+}
@@ -0,0 +1,205 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/diff"
+)
+
+const (
+	// maxConcurrentFetches bounds the number of release notes fetched at
+	// once.
+	maxConcurrentFetches = 4
+
+	// maxNotable bounds the notable changes reported for each version.
+	maxNotable = 10
+
+	releaseNotes = "releaseNotes"
+)
+
+// Release is an intermediate version of an upgrade.
+type Release struct {
+	Version string `json:"version"`
+	Notes
+
+	// Omitted counts the notable changes left out of the report.
+	Omitted int `json:"omitted,omitempty"`
+
+	// URL links to the release notes when the catalog does not serve their
+	// content.
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Note is a release note entry and the version it belongs to.
+type Note struct {
+	Version string `json:"version"`
+	Text    string `json:"text"`
+}
+
+// Report is a consolidated plan for upgrading a package.
+type Report struct {
+	Account    string `json:"account"`
+	Repository string `json:"repository"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+
+	// Releases are the versions after From, up to and including To.
+	Releases []Release `json:"releases"`
+
+	// Breaking and Deprecations collect the release note entries of every
+	// release that announce breaking changes and deprecations.
+	Breaking     []Note `json:"breaking"`
+	Deprecations []Note `json:"deprecations"`
+
+	// SchemaChanges are the breaking schema changes between From and To.
+	SchemaChanges []diff.Change `json:"schemaChanges"`
+	SchemaErrors  []string      `json:"schemaErrors,omitempty"`
+}
+
+// Versions returns the semantic versions in all that are newer than from and
+// no newer than to, oldest first.
+func Versions(all []string, from, to string) ([]string, error) {
+	if !semver.IsValid(from) {
+		return nil, fmt.Errorf("current version %q is not a semantic version", from)
+	}
+	if !semver.IsValid(to) {
+		return nil, fmt.Errorf("target version %q is not a semantic version", to)
+	}
+	if semver.Compare(from, to) >= 0 {
+		return nil, fmt.Errorf("target version %s is not newer than current version %s", to, from)
+	}
+	var out []string
+	for _, v := range all {
+		if semver.IsValid(v) && semver.Compare(v, from) > 0 && semver.Compare(v, to) <= 0 {
+			out = append(out, v)
+		}
+	}
+	semver.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// Plan reports what changes when upgrading a package from one version to
+// another. An empty target upgrades to the latest version.
+func Plan(ctx context.Context, c catalog.Catalog, account, repository, from, to string) (*Report, error) {
+	md, err := c.GetPackageMetadata(ctx, account, repository, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package versions: %w", err)
+	}
+	if to == "" {
+		to = md.LatestVersion
+	}
+	if to == "" {
+		to = latest(md.Versions)
+	}
+	versions, err := Versions(md.Versions, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{
+		Account:       account,
+		Repository:    repository,
+		From:          from,
+		To:            to,
+		Releases:      make([]Release, len(versions)),
+		Breaking:      []Note{},
+		Deprecations:  []Note{},
+		SchemaChanges: []diff.Change{},
+	}
+
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for i, v := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			rep.Releases[i] = release(ctx, c, account, repository, v)
+		}()
+	}
+
+	// Compare the endpoints while the release notes are fetched.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d, err := diff.Compare(ctx, c, account, repository, from, to)
+		if err != nil {
+			rep.SchemaErrors = append(rep.SchemaErrors, err.Error())
+			return
+		}
+		for _, ch := range d.Changes {
+			if ch.Breaking {
+				rep.SchemaChanges = append(rep.SchemaChanges, ch)
+			}
+		}
+		rep.SchemaErrors = append(rep.SchemaErrors, d.Errors...)
+	}()
+	wg.Wait()
+
+	for _, r := range rep.Releases {
+		for _, t := range r.Breaking {
+			rep.Breaking = append(rep.Breaking, Note{Version: r.Version, Text: t})
+		}
+		for _, t := range r.Deprecations {
+			rep.Deprecations = append(rep.Deprecations, Note{Version: r.Version, Text: t})
+		}
+	}
+	return rep, nil
+}
+
+// release fetches and classifies the release notes of a version.
+func release(ctx context.Context, c catalog.Catalog, account, repository, version string) Release {
+	r := Release{Version: version}
+	a, err := c.GetPackageAssets(ctx, account, repository, version, releaseNotes)
+	switch {
+	case err != nil:
+		r.Error = fmt.Sprintf("failed to get release notes: %v", err)
+		return r
+	case a == nil:
+		return r
+	case a.Content == "":
+		r.URL = a.URL
+		return r
+	}
+	r.Notes = ParseNotes(a.Content)
+	if len(r.Notable) > maxNotable {
+		r.Omitted = len(r.Notable) - maxNotable
+		r.Notable = r.Notable[:maxNotable]
+	}
+	return r
+}
+
+// latest returns the newest semantic version in a list.
+func latest(versions []string) string {
+	var out string
+	for _, v := range versions {
+		if semver.IsValid(v) && (out == "" || semver.Compare(v, out) > 0) {
+			out = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package upgrade
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+func TestVersions(t *testing.T) {
+	all := []string{"v1.20.0", "v1.14.0", "v1.15.0", "v1.15.0-rc.1", "latest", "v1.16.1", "v1.21.0"}
+
+	cases := map[string]struct {
+		from, to string
+		want     []string
+		wantErr  bool
+	}{
+		"Range":       {from: "v1.14.0", to: "v1.20.0", want: []string{"v1.15.0-rc.1", "v1.15.0", "v1.16.1", "v1.20.0"}},
+		"Adjacent":    {from: "v1.20.0", to: "v1.21.0", want: []string{"v1.21.0"}},
+		"NotSemver":   {from: "latest", to: "v1.20.0", wantErr: true},
+		"NotNewer":    {from: "v1.20.0", to: "v1.14.0", wantErr: true},
+		"SameVersion": {from: "v1.20.0", to: "v1.20.0", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Versions(all, tc.from, tc.to)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Versions() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Versions() error = %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Versions() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseNotes(t *testing.T) {
+	cases := map[string]struct {
+		md   string
+		want Notes
+	}{
+		"Sections": {
+			md: `## What's Changed
+- Add support for bucket lifecycle rules by @someone in #123
+- Bump github.com/crossplane/crossplane-runtime from v1.19 to v1.20
+
+## Breaking Changes
+* The spec.forProvider.acl field moved to BucketACL
+
+## Deprecations
+1. v1beta1 of Bucket will be removed in v2.0.0
+
+**Full Changelog**: https://github.com/upbound/provider-aws/compare/v1.19.0...v1.20.0`,
+			want: Notes{
+				Notable:      []string{"Add support for bucket lifecycle rules by @someone in #123"},
+				Breaking:     []string{"The spec.forProvider.acl field moved to BucketACL"},
+				Deprecations: []string{"v1beta1 of Bucket will be removed in v2.0.0"},
+			},
+		},
+		"Keywords": {
+			md: `This release deprecates the legacy region field.
+- BREAKING: Instance now requires spec.forProvider.subnetId
+- Fix drift detection for tags`,
+			want: Notes{
+				Notable:      []string{"Fix drift detection for tags"},
+				Breaking:     []string{"BREAKING: Instance now requires spec.forProvider.subnetId"},
+				Deprecations: []string{"This release deprecates the legacy region field."},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ParseNotes(tc.md)
+			if !slices.Equal(got.Notable, tc.want.Notable) || !slices.Equal(got.Breaking, tc.want.Breaking) || !slices.Equal(got.Deprecations, tc.want.Deprecations) {
+				t.Errorf("ParseNotes() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeCatalog serves package versions and release notes.
+type fakeCatalog struct {
+	catalog.Catalog
+
+	notes map[string]string
+}
+
+func (f *fakeCatalog) GetPackageMetadata(_ context.Context, _, _, _ string, _ bool) (*marketplace.PackageMetadata, error) {
+	return &marketplace.PackageMetadata{Versions: []string{"v1.0.0", "v1.1.0", "v1.2.0"}}, nil
+}
+
+func (f *fakeCatalog) GetPackageAssets(_ context.Context, _, _, version, _ string) (*marketplace.AssetResponse, error) {
+	return &marketplace.AssetResponse{Content: f.notes[version]}, nil
+}
+
+func (f *fakeCatalog) GetV1PackagesAccountRepositoryVersionResources(_ context.Context, _, _, _ string) (*marketplace.PackageResources, error) {
+	return &marketplace.PackageResources{}, nil
+}
+
+func TestPlan(t *testing.T) {
+	c := &fakeCatalog{notes: map[string]string{
+		"v1.1.0": "- Deprecate the Legacy kind",
+		"v1.2.0": "- Breaking change: remove Legacy",
+	}}
+	rep, err := Plan(context.Background(), c, "upbound", "provider-test", "v1.0.0", "")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if rep.To != "v1.2.0" || len(rep.Releases) != 2 {
+		t.Fatalf("Plan() = %+v, want two releases up to v1.2.0", rep)
+	}
+	if len(rep.Deprecations) != 1 || rep.Deprecations[0].Version != "v1.1.0" {
+		t.Errorf("Deprecations = %+v", rep.Deprecations)
+	}
+	if len(rep.Breaking) != 1 || rep.Breaking[0].Version != "v1.2.0" {
+		t.Errorf("Breaking = %+v", rep.Breaking)
+	}
+}
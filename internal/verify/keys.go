@@ -0,0 +1,137 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Key is a trusted public key.
+type Key struct {
+	// Name identifies the key in verification results. Keys loaded from
+	// files are named after the file.
+	Name string
+
+	// PublicKey is an ECDSA, RSA or Ed25519 public key.
+	PublicKey crypto.PublicKey
+}
+
+// LoadKeys loads the PEM encoded public keys at the supplied paths. A path may
+// be a file holding one or more keys, or a directory whose .pem and .pub files
+// hold keys.
+func LoadKeys(paths ...string) ([]Key, error) {
+	var keys []Key
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key path: %w", err)
+		}
+		files := []string{p}
+		if fi.IsDir() {
+			entries, err := os.ReadDir(p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key directory %s: %w", p, err)
+			}
+			files = files[:0]
+			for _, e := range entries {
+				ext := filepath.Ext(e.Name())
+				if !e.IsDir() && (ext == ".pem" || ext == ".pub") {
+					files = append(files, filepath.Join(p, e.Name()))
+				}
+			}
+		}
+		for _, f := range files {
+			k, err := loadKeyFile(f)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k...)
+		}
+	}
+	return keys, nil
+}
+
+func loadKeyFile(path string) ([]Key, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // Reading configured key files is intended.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var keys []Key
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key in %s: %w", path, err)
+		}
+		k := Key{Name: name, PublicKey: pub}
+		if len(keys) > 0 {
+			k.Name = fmt.Sprintf("%s#%d", name, len(keys))
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no PEM encoded public keys found in %s", path)
+	}
+	return keys, nil
+}
+
+// Verify checks that sig is a signature of data made with the key. ECDSA and
+// RSA signatures are over the SHA-256 digest of data, as produced by cosign.
+func (k Key) Verify(data, sig []byte) error {
+	sum := sha256.Sum256(data)
+	switch pub := k.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, sum[:], sig) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig); err != nil {
+			if rsa.VerifyPSS(pub, crypto.SHA256, sum[:], sig, nil) == nil {
+				return nil
+			}
+			return fmt.Errorf("invalid RSA signature: %w", err)
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, sig) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
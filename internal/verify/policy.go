@@ -0,0 +1,68 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy describes the verification results a package must have to be
+// trusted.
+type Policy struct {
+	// RequireSignature requires a signature verified by a trusted key.
+	RequireSignature bool
+
+	// RequireProvenance requires a verified SLSA provenance attestation.
+	RequireProvenance bool
+
+	// TrustedBuilders, if set, requires verified provenance from a builder
+	// whose ID starts with one of these prefixes.
+	TrustedBuilders []string
+}
+
+// Check returns the ways in which a result violates the policy. A digest that
+// does not match the one the marketplace reports is always a violation.
+func (p Policy) Check(r *Result) []string {
+	var out []string
+	if r.DigestMatch != nil && !*r.DigestMatch {
+		out = append(out, fmt.Sprintf("registry digest %s does not match marketplace digest %s", r.Digest, r.MarketplaceDigest))
+	}
+	if p.RequireSignature && !r.Verified {
+		out = append(out, "package has no signature verified by a trusted key")
+	}
+
+	var provenance, trusted bool
+	for _, a := range r.Attestations {
+		if !a.Verified || a.Provenance == nil {
+			continue
+		}
+		provenance = true
+		for _, b := range p.TrustedBuilders {
+			if strings.HasPrefix(a.Provenance.BuilderID, b) {
+				trusted = true
+			}
+		}
+	}
+	if p.RequireProvenance && !provenance {
+		out = append(out, "package has no verified provenance attestation")
+	}
+	if len(p.TrustedBuilders) > 0 && !trusted {
+		out = append(out, fmt.Sprintf("package has no verified provenance from a trusted builder (%s)", strings.Join(p.TrustedBuilders, ", ")))
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package verify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PredicateSLSAProvenanceV02 is the predicate type of SLSA v0.2
+	// provenance.
+	PredicateSLSAProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+
+	// PredicateSLSAProvenanceV1 is the predicate type of SLSA v1 provenance.
+	PredicateSLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
+)
+
+// Attestation is a verified or unverified in-toto attestation of a package.
+type Attestation struct {
+	PredicateType string `json:"predicateType,omitempty"`
+
+	// Signer is the name of the trusted key that verified the attestation.
+	Signer string `json:"signer,omitempty"`
+
+	// Provenance summarises SLSA provenance predicates.
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Provenance describes how a package was built.
+type Provenance struct {
+	BuilderID    string `json:"builderId,omitempty"`
+	BuildType    string `json:"buildType,omitempty"`
+	SourceURI    string `json:"sourceUri,omitempty"`
+	SourceDigest string `json:"sourceDigest,omitempty"`
+}
+
+// envelope is a DSSE envelope.
+type envelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"` //nolint:tagliatelle // This is unmarshalling an external format.
+		Sig   string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// statement is an in-toto statement.
+type statement struct {
+	Type    string `json:"_type"` //nolint:tagliatelle // This is unmarshalling an external format.
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string         `json:"predicateType"`
+	Predicate     map[string]any `json:"predicate"`
+}
+
+func (v *Verifier) verifyAttestation(digest string, content []byte) Attestation {
+	var a Attestation
+	var env envelope
+	if err := json.Unmarshal(content, &env); err != nil {
+		a.Error = fmt.Sprintf("failed to decode attestation envelope: %v", err)
+		return a
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		a.Error = fmt.Sprintf("failed to decode attestation payload: %v", err)
+		return a
+	}
+	var st statement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		a.Error = fmt.Sprintf("failed to decode in-toto statement: %v", err)
+		return a
+	}
+	a.PredicateType = st.PredicateType
+	a.Provenance = provenance(st.PredicateType, st.Predicate)
+
+	if !st.covers(digest) {
+		a.Error = fmt.Sprintf("attestation subject does not include digest %s", digest)
+		return a
+	}
+
+	pae := preAuthEncoding(env.PayloadType, payload)
+	err = errors.New("attestation has no valid signatures")
+	for _, s := range env.Signatures {
+		sig, derr := base64.StdEncoding.DecodeString(s.Sig)
+		if derr != nil {
+			continue
+		}
+		var signer string
+		if signer, err = v.check(pae, sig); err == nil {
+			a.Signer = signer
+			a.Verified = true
+			return a
+		}
+	}
+	a.Error = err.Error()
+	return a
+}
+
+// covers returns true if the statement's subject includes the digest.
+func (st statement) covers(digest string) bool {
+	alg, hex, ok := strings.Cut(digest, ":")
+	if !ok {
+		return false
+	}
+	for _, s := range st.Subject {
+		if s.Digest[alg] == hex {
+			return true
+		}
+	}
+	return false
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of a payload,
+// which is what DSSE signatures sign.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// provenance summarises a SLSA provenance predicate. It returns nil for other
+// predicate types.
+func provenance(predicateType string, p map[string]any) *Provenance {
+	switch predicateType {
+	case PredicateSLSAProvenanceV02:
+		src, _ := value(p, "invocation", "configSource").(map[string]any)
+		return &Provenance{
+			BuilderID:    field(p, "builder", "id"),
+			BuildType:    field(p, "buildType"),
+			SourceURI:    field(src, "uri"),
+			SourceDigest: firstDigest(src),
+		}
+	case PredicateSLSAProvenanceV1:
+		out := &Provenance{
+			BuilderID: field(p, "runDetails", "builder", "id"),
+			BuildType: field(p, "buildDefinition", "buildType"),
+			SourceURI: field(p, "buildDefinition", "externalParameters", "workflow", "repository"),
+		}
+		if deps, _ := value(p, "buildDefinition", "resolvedDependencies").([]any); len(deps) > 0 {
+			dep, _ := deps[0].(map[string]any)
+			if out.SourceURI == "" {
+				out.SourceURI = field(dep, "uri")
+			}
+			out.SourceDigest = firstDigest(dep)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// firstDigest returns the first digest of an in-toto resource descriptor,
+// formatted as <algorithm>:<hex>.
+func firstDigest(obj map[string]any) string {
+	d, _ := value(obj, "digest").(map[string]any)
+	for _, alg := range []string{"sha256", "sha1", "gitCommit"} {
+		if h, ok := d[alg].(string); ok {
+			return alg + ":" + h
+		}
+	}
+	return ""
+}
+
+func value(obj map[string]any, path ...string) any {
+	var cur any = obj
+	for _, f := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[f]
+	}
+	return cur
+}
+
+func field(obj map[string]any, path ...string) string {
+	s, _ := value(obj, path...).(string)
+	return s
+}
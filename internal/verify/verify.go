@@ -0,0 +1,231 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package verify
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+)
+
+const (
+	// MediaTypeSimpleSigning is the media type of cosign signature payloads.
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// MediaTypeDSSE is the media type of cosign attestation envelopes.
+	MediaTypeDSSE = "application/vnd.dsse.envelope.v1+json"
+
+	// AnnotationSignature holds the base64 encoded signature of a cosign
+	// signature payload.
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+
+	// AnnotationCertificate holds the PEM encoded certificate of a keyless
+	// cosign signature.
+	AnnotationCertificate = "dev.sigstore.cosign/certificate"
+)
+
+// A Fetcher reads a package's digest and the signatures and attestations
+// attached to it. *registry.Client is a Fetcher.
+type Fetcher interface {
+	Resolve(ctx context.Context, repository, reference string) (string, error)
+	Signatures(ctx context.Context, repository, digest string) ([]registry.Layer, error)
+	Attestations(ctx context.Context, repository, digest string) ([]registry.Layer, error)
+}
+
+var _ Fetcher = &registry.Client{}
+
+// Result is the outcome of verifying a package.
+type Result struct {
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Digest     string `json:"digest"`
+
+	// MarketplaceDigest is the digest the marketplace reports for the
+	// package, if known. DigestMatch is set when it is.
+	MarketplaceDigest string `json:"marketplaceDigest,omitempty"`
+	DigestMatch       *bool  `json:"digestMatch,omitempty"`
+
+	// Signed is true if any signatures are attached to the package, and
+	// Verified is true if at least one was verified by a trusted key.
+	Signed       bool          `json:"signed"`
+	Verified     bool          `json:"verified"`
+	Signatures   []Signature   `json:"signatures"`
+	Attestations []Attestation `json:"attestations"`
+
+	// Violations lists the policy checks the result failed.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Signature is a verified or unverified signature of a package.
+type Signature struct {
+	// Signer is the name of the trusted key that verified the signature.
+	Signer string `json:"signer,omitempty"`
+
+	// Identity is the subject of the certificate of a keyless signature.
+	// It is reported as-is; certificate chains are not verified.
+	Identity string `json:"identity,omitempty"`
+
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CompareDigest records the digest the marketplace reports for the package and
+// whether it matches the digest the registry resolved.
+func (r *Result) CompareDigest(digest string) {
+	if digest == "" {
+		return
+	}
+	match := digest == r.Digest
+	r.MarketplaceDigest = digest
+	r.DigestMatch = &match
+}
+
+// Verifier verifies packages against a set of trusted keys.
+type Verifier struct {
+	keys []Key
+}
+
+// NewVerifier returns a verifier that trusts the supplied keys.
+func NewVerifier(keys ...Key) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// Keys returns the trusted keys.
+func (v *Verifier) Keys() []Key {
+	return v.keys
+}
+
+// Verify resolves a package reference to its digest and verifies the
+// signatures and attestations attached to it. Problems with individual
+// signatures are reported in the result rather than returned.
+func (v *Verifier) Verify(ctx context.Context, f Fetcher, repository, reference string) (*Result, error) {
+	digest, err := f.Resolve(ctx, repository, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s:%s: %w", repository, reference, err)
+	}
+	res := &Result{
+		Repository:   repository,
+		Reference:    reference,
+		Digest:       digest,
+		Signatures:   []Signature{},
+		Attestations: []Attestation{},
+	}
+
+	sigs, err := f.Signatures(ctx, repository, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signatures: %w", err)
+	}
+	for _, l := range sigs {
+		if l.MediaType != MediaTypeSimpleSigning {
+			continue
+		}
+		s := v.verifySignature(digest, l)
+		res.Signed = true
+		res.Verified = res.Verified || s.Verified
+		res.Signatures = append(res.Signatures, s)
+	}
+
+	atts, err := f.Attestations(ctx, repository, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attestations: %w", err)
+	}
+	for _, l := range atts {
+		if l.MediaType != MediaTypeDSSE {
+			continue
+		}
+		res.Attestations = append(res.Attestations, v.verifyAttestation(digest, l.Content))
+	}
+	return res, nil
+}
+
+// simpleSigning is the payload cosign signs.
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"` //nolint:tagliatelle // This is unmarshalling an external format.
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+func (v *Verifier) verifySignature(digest string, l registry.Layer) Signature {
+	s := Signature{Identity: certificateIdentity(l.Annotations[AnnotationCertificate])}
+	sig, err := base64.StdEncoding.DecodeString(l.Annotations[AnnotationSignature])
+	if err != nil || len(sig) == 0 {
+		s.Error = "signature annotation is missing or invalid"
+		return s
+	}
+	signer, err := v.check(l.Content, sig)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+
+	var p simpleSigning
+	if err := json.Unmarshal(l.Content, &p); err != nil {
+		s.Error = fmt.Sprintf("failed to decode signature payload: %v", err)
+		return s
+	}
+	if got := p.Critical.Image.DockerManifestDigest; got != digest {
+		s.Error = fmt.Sprintf("signature is for digest %s, not %s", got, digest)
+		return s
+	}
+	s.Signer = signer
+	s.Verified = true
+	return s
+}
+
+// check verifies a signature with every trusted key, returning the name of the
+// key that verified it.
+func (v *Verifier) check(data, sig []byte) (string, error) {
+	if len(v.keys) == 0 {
+		return "", errors.New("no trusted keys are configured")
+	}
+	for _, k := range v.keys {
+		if k.Verify(data, sig) == nil {
+			return k.Name, nil
+		}
+	}
+	return "", errors.New("signature was not made by a trusted key")
+}
+
+// certificateIdentity returns the subject alternative name of a PEM encoded
+// certificate, or an empty string if it cannot be read.
+func certificateIdentity(p string) string {
+	block, _ := pem.Decode([]byte(p))
+	if block == nil {
+		return ""
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	default:
+		return cert.Subject.String()
+	}
+}
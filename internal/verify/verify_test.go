@@ -0,0 +1,201 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/upbound/marketplace-mcp-server/internal/registry"
+)
+
+const testDigest = "sha256:0d5f1c4a0c3d5e0b2f0a0e3f7c1b9d8e6a5f4c3b2a19081726354453627180ab"
+
+// fakeFetcher serves canned signature and attestation layers.
+type fakeFetcher struct {
+	sigs []registry.Layer
+	atts []registry.Layer
+}
+
+func (f *fakeFetcher) Resolve(_ context.Context, _, _ string) (string, error) {
+	return testDigest, nil
+}
+
+func (f *fakeFetcher) Signatures(_ context.Context, _, _ string) ([]registry.Layer, error) {
+	return f.sigs, nil
+}
+
+func (f *fakeFetcher) Attestations(_ context.Context, _, _ string) ([]registry.Layer, error) {
+	return f.atts, nil
+}
+
+func sign(t *testing.T, k *ecdsa.PrivateKey, data []byte) []byte {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, k, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func signature(t *testing.T, k *ecdsa.PrivateKey, digest string) registry.Layer {
+	t.Helper()
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"xpkg.upbound.io/test/provider"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"}}`)
+	return registry.Layer{
+		MediaType:   MediaTypeSimpleSigning,
+		Annotations: map[string]string{AnnotationSignature: base64.StdEncoding.EncodeToString(sign(t, k, payload))},
+		Content:     payload,
+	}
+}
+
+func attestation(t *testing.T, k *ecdsa.PrivateKey, digest string) registry.Layer {
+	t.Helper()
+	hex := digest[len("sha256:"):]
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"` + PredicateSLSAProvenanceV02 + `",` +
+		`"subject":[{"name":"xpkg.upbound.io/test/provider","digest":{"sha256":"` + hex + `"}}],` +
+		`"predicate":{"builder":{"id":"https://github.com/slsa-framework/slsa-github-generator"},"buildType":"https://github.com/Attestations/GitHubActionsWorkflow@v1",` +
+		`"invocation":{"configSource":{"uri":"git+https://github.com/test/provider@refs/tags/v1.0.0","digest":{"sha1":"abc123"}}}}}`)
+	pt := "application/vnd.in-toto+json"
+	env := map[string]any{
+		"payloadType": pt,
+		"payload":     base64.StdEncoding.EncodeToString(payload),
+		"signatures":  []map[string]string{{"sig": base64.StdEncoding.EncodeToString(sign(t, k, preAuthEncoding(pt, payload)))}},
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry.Layer{MediaType: MediaTypeDSSE, Content: b}
+}
+
+func writeKey(t *testing.T, dir string, k *ecdsa.PrivateKey) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, "cosign.pub"), b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	trusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	dir := t.TempDir()
+	writeKey(t, dir, trusted)
+	keys, err := LoadKeys(dir)
+	if err != nil {
+		t.Fatalf("LoadKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].Name != "cosign" {
+		t.Fatalf("LoadKeys() = %+v, want one key named cosign", keys)
+	}
+
+	cases := map[string]struct {
+		fetcher        *fakeFetcher
+		policy         Policy
+		wantSigned     bool
+		wantVerified   bool
+		wantProvenance bool
+		wantViolations int
+	}{
+		"SignedWithProvenance": {
+			fetcher: &fakeFetcher{
+				sigs: []registry.Layer{signature(t, trusted, testDigest)},
+				atts: []registry.Layer{attestation(t, trusted, testDigest)},
+			},
+			policy:         Policy{RequireSignature: true, RequireProvenance: true, TrustedBuilders: []string{"https://github.com/slsa-framework/"}},
+			wantSigned:     true,
+			wantVerified:   true,
+			wantProvenance: true,
+		},
+		"UntrustedKey": {
+			fetcher: &fakeFetcher{
+				sigs: []registry.Layer{signature(t, other, testDigest)},
+				atts: []registry.Layer{attestation(t, other, testDigest)},
+			},
+			policy:         Policy{RequireSignature: true, RequireProvenance: true},
+			wantSigned:     true,
+			wantViolations: 2,
+		},
+		"SignatureForOtherDigest": {
+			fetcher: &fakeFetcher{
+				sigs: []registry.Layer{signature(t, trusted, "sha256:ffff")},
+			},
+			policy:         Policy{RequireSignature: true},
+			wantSigned:     true,
+			wantViolations: 1,
+		},
+		"Unsigned": {
+			fetcher:        &fakeFetcher{},
+			policy:         Policy{RequireSignature: true, TrustedBuilders: []string{"https://github.com/"}},
+			wantViolations: 2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			res, err := NewVerifier(keys...).Verify(context.Background(), tc.fetcher, "test/provider", "v1.0.0")
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if res.Signed != tc.wantSigned || res.Verified != tc.wantVerified {
+				t.Errorf("Signed, Verified = %t, %t, want %t, %t", res.Signed, res.Verified, tc.wantSigned, tc.wantVerified)
+			}
+			var provenance bool
+			for _, a := range res.Attestations {
+				if a.Verified && a.Provenance != nil {
+					provenance = true
+					if a.Provenance.SourceDigest != "sha1:abc123" {
+						t.Errorf("SourceDigest = %q, want sha1:abc123", a.Provenance.SourceDigest)
+					}
+				}
+			}
+			if provenance != tc.wantProvenance {
+				t.Errorf("verified provenance = %t, want %t", provenance, tc.wantProvenance)
+			}
+			if v := tc.policy.Check(res); len(v) != tc.wantViolations {
+				t.Errorf("Check() = %q, want %d violations", v, tc.wantViolations)
+			}
+		})
+	}
+}
+
+func TestCompareDigest(t *testing.T) {
+	res := &Result{Digest: testDigest}
+	res.CompareDigest("sha256:other")
+	if res.DigestMatch == nil || *res.DigestMatch {
+		t.Fatal("expected a digest mismatch")
+	}
+	if v := (Policy{}).Check(res); len(v) != 1 {
+		t.Errorf("Check() = %q, want a digest violation", v)
+	}
+}
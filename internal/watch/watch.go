@@ -0,0 +1,296 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+)
+
+const (
+	// DefaultInterval is the default time between polls.
+	DefaultInterval = 15 * time.Minute
+
+	// MaxSubscriptions bounds the packages a session may subscribe to.
+	MaxSubscriptions = 100
+
+	// MaxNotifyFailures is the number of notifications in a row a session
+	// may fail to receive before it is unsubscribed from every package.
+	MaxNotifyFailures = 3
+)
+
+// ErrTooManySubscriptions is returned when a session subscribes to more than
+// MaxSubscriptions packages.
+var ErrTooManySubscriptions = fmt.Errorf("a session may subscribe to at most %d packages", MaxSubscriptions)
+
+// A Clock waits. Tests replace it to control when polls happen.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// A Notifier tells a session that the resource with the supplied URI was
+// updated.
+type Notifier func(session, uri string) error
+
+// subscription is a package and the sessions subscribed to it.
+type subscription struct {
+	account    string
+	repository string
+	latest     string
+	sessions   []string
+}
+
+// Watcher polls subscribed packages for new versions. It is safe for
+// concurrent use.
+type Watcher struct {
+	reader   catalog.PackageReader
+	notify   Notifier
+	clock    Clock
+	interval time.Duration
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+
+	// failures counts the notifications each session failed to receive
+	// since it last received one.
+	failures map[string]int
+}
+
+// Option configures a Watcher.
+type Option func(*Watcher)
+
+// WithInterval sets the time between polls.
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithClock sets the clock the watcher waits on.
+func WithClock(c Clock) Option {
+	return func(w *Watcher) {
+		w.clock = c
+	}
+}
+
+// NewWatcher returns a watcher that reads package metadata from r and
+// notifies subscribers through n.
+func NewWatcher(r catalog.PackageReader, n Notifier, opts ...Option) *Watcher {
+	w := &Watcher{reader: r, notify: n, clock: realClock{}, interval: DefaultInterval, subs: map[string]*subscription{}, failures: map[string]int{}}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// Subscribe subscribes a session to the package with the supplied resource
+// URI. The package's current latest version is read so that only versions
+// released after the subscription are notified.
+func (w *Watcher) Subscribe(ctx context.Context, session, uri, account, repository string) error {
+	if session == "" {
+		return errors.New("subscriptions require a session")
+	}
+
+	w.mu.Lock()
+	n := 0
+	for _, s := range w.subs {
+		if slices.Contains(s.sessions, session) {
+			n++
+		}
+	}
+	s, ok := w.subs[uri]
+	w.mu.Unlock()
+	if ok && slices.Contains(s.sessions, session) {
+		return nil
+	}
+	if n >= MaxSubscriptions {
+		return ErrTooManySubscriptions
+	}
+
+	latest := ""
+	if !ok {
+		md, err := w.reader.GetPackageMetadata(ctx, account, repository, "", false)
+		if err != nil {
+			return fmt.Errorf("failed to get metadata of %s/%s: %w", account, repository, err)
+		}
+		latest = md.LatestVersion
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s, ok = w.subs[uri]
+	if !ok {
+		s = &subscription{account: account, repository: repository, latest: latest}
+		w.subs[uri] = s
+	}
+	if !slices.Contains(s.sessions, session) {
+		s.sessions = append(s.sessions, session)
+	}
+	return nil
+}
+
+// Unsubscribe unsubscribes a session from a resource URI.
+func (w *Watcher) Unsubscribe(session, uri string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.remove(session, uri)
+}
+
+// RemoveSession unsubscribes a session from every resource.
+func (w *Watcher) RemoveSession(session string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for uri := range w.subs {
+		w.remove(session, uri)
+	}
+	delete(w.failures, session)
+}
+
+// remove unsubscribes a session, forgetting packages nobody is subscribed to.
+// The caller must hold the lock.
+func (w *Watcher) remove(session, uri string) {
+	s, ok := w.subs[uri]
+	if !ok {
+		return
+	}
+	s.sessions = slices.DeleteFunc(s.sessions, func(id string) bool { return id == session })
+	if len(s.sessions) == 0 {
+		delete(w.subs, uri)
+	}
+}
+
+// Subscriptions returns the resource URIs a session is subscribed to.
+func (w *Watcher) Subscriptions(session string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []string
+	for uri, s := range w.subs {
+		if slices.Contains(s.sessions, session) {
+			out = append(out, uri)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Run polls the subscribed packages every interval until the context is
+// cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
+			w.Poll(ctx)
+		}
+	}
+}
+
+// Poll reads the latest version of every subscribed package and notifies the
+// subscribers of packages whose latest version changed. Packages that cannot
+// be read are retried at the next poll.
+func (w *Watcher) Poll(ctx context.Context) {
+	w.mu.Lock()
+	uris := make([]string, 0, len(w.subs))
+	for uri := range w.subs {
+		uris = append(uris, uri)
+	}
+	w.mu.Unlock()
+	slices.Sort(uris)
+
+	for _, uri := range uris {
+		if ctx.Err() != nil {
+			return
+		}
+		w.poll(ctx, uri)
+	}
+}
+
+// poll checks a subscribed package for a new version.
+func (w *Watcher) poll(ctx context.Context, uri string) {
+	w.mu.Lock()
+	s, ok := w.subs[uri]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	account, repository := s.account, s.repository
+	w.mu.Unlock()
+
+	md, err := w.reader.GetPackageMetadata(ctx, account, repository, "", false)
+	if err != nil {
+		log.Printf("Warning: Failed to check %s/%s for new versions: %v", account, repository, err)
+		return
+	}
+
+	w.mu.Lock()
+	s, ok = w.subs[uri]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	changed := md.LatestVersion != "" && md.LatestVersion != s.latest
+	if md.LatestVersion != "" {
+		s.latest = md.LatestVersion
+	}
+	sessions := slices.Clone(s.sessions)
+	w.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	for _, session := range sessions {
+		w.notifySession(session, uri)
+	}
+}
+
+// notifySession notifies a session of an update, unsubscribing it from every
+// resource once it has failed to receive MaxNotifyFailures notifications in a
+// row.
+func (w *Watcher) notifySession(session, uri string) {
+	err := w.notify(session, uri)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err == nil {
+		delete(w.failures, session)
+		return
+	}
+	// The session may not be listening right now; it can read the resource
+	// when it reconnects.
+	log.Printf("Warning: Failed to notify session of update to %s: %v", uri, err)
+	w.failures[session]++
+	if w.failures[session] < MaxNotifyFailures {
+		return
+	}
+	log.Printf("Warning: Unsubscribing session after %d failed notifications", w.failures[session])
+	for u := range w.subs {
+		w.remove(session, u)
+	}
+	delete(w.failures, session)
+}
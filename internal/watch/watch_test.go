@@ -0,0 +1,191 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/upbound/marketplace-mcp-server/internal/catalog"
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+// fakeClock fires the channels returned by After when Advance is called.
+type fakeClock struct {
+	mu      sync.Mutex
+	waiters []chan time.Time
+	waiting chan struct{}
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{waiting: make(chan struct{}, 16)}
+}
+
+func (c *fakeClock) After(_ time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, ch)
+	c.waiting <- struct{}{}
+	return ch
+}
+
+// Advance waits until something is waiting on the clock, then fires it.
+func (c *fakeClock) Advance() {
+	<-c.waiting
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.waiters {
+		ch <- time.Time{}
+	}
+	c.waiters = nil
+}
+
+// fakeMarketplace serves the latest version of each package.
+type fakeMarketplace struct {
+	catalog.PackageReader
+
+	mu     sync.Mutex
+	latest map[string]string
+	reads  int
+}
+
+func (f *fakeMarketplace) GetPackageMetadata(_ context.Context, account, repo, _ string, _ bool) (*marketplace.PackageMetadata, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	return &marketplace.PackageMetadata{Account: account, Repository: repo, LatestVersion: f.latest[account+"/"+repo]}, nil
+}
+
+func (f *fakeMarketplace) release(repo, version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latest[repo] = version
+}
+
+type notification struct{ session, uri string }
+
+func TestWatcher(t *testing.T) {
+	m := &fakeMarketplace{latest: map[string]string{"upbound/provider-aws-s3": "v1.20.0", "upbound/provider-gcp": "v1.0.0"}}
+	clock := newFakeClock()
+	notified := make(chan notification, 8)
+	w := NewWatcher(m, func(session, uri string) error {
+		notified <- notification{session, uri}
+		return nil
+	}, WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s3, gcp := "marketplace://upbound/provider-aws-s3", "marketplace://upbound/provider-gcp"
+	for _, sub := range []struct{ session, uri, repo string }{
+		{"one", s3, "provider-aws-s3"},
+		{"two", s3, "provider-aws-s3"},
+		{"two", gcp, "provider-gcp"},
+	} {
+		if err := w.Subscribe(ctx, sub.session, sub.uri, "upbound", sub.repo); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+	if err := w.Subscribe(ctx, "", s3, "upbound", "provider-aws-s3"); err == nil {
+		t.Error("Subscribe() without a session should fail")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	// Nothing was released, so nobody is notified.
+	clock.Advance()
+	m.release("upbound/provider-aws-s3", "v1.21.0")
+	clock.Advance()
+
+	got := map[notification]bool{<-notified: true, <-notified: true}
+	for _, want := range []notification{{"one", s3}, {"two", s3}} {
+		if !got[want] {
+			t.Errorf("notifications = %v, want %v", got, want)
+		}
+	}
+
+	// An unsubscribed session is not notified.
+	w.Unsubscribe("one", s3)
+	w.RemoveSession("two")
+	m.release("upbound/provider-aws-s3", "v1.22.0")
+	clock.Advance()
+	clock.Advance()
+	cancel()
+	<-done
+
+	select {
+	case n := <-notified:
+		t.Errorf("unexpected notification %v after unsubscribing", n)
+	default:
+	}
+	if subs := w.Subscriptions("two"); len(subs) != 0 {
+		t.Errorf("Subscriptions(two) = %v, want none", subs)
+	}
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	m := &fakeMarketplace{latest: map[string]string{}}
+	w := NewWatcher(m, func(string, string) error { return nil })
+	for i := range MaxSubscriptions + 1 {
+		uri := "marketplace://upbound/" + string(rune('a'+i%26)) + time.Duration(i).String()
+		err := w.Subscribe(context.Background(), "one", uri, "upbound", uri)
+		if i < MaxSubscriptions && err != nil {
+			t.Fatalf("Subscribe() %d error = %v", i, err)
+		}
+		if i == MaxSubscriptions && err != ErrTooManySubscriptions {
+			t.Errorf("Subscribe() beyond the limit error = %v, want ErrTooManySubscriptions", err)
+		}
+	}
+}
+
+func TestNotifyFailures(t *testing.T) {
+	m := &fakeMarketplace{latest: map[string]string{"upbound/provider-aws-s3": "v1.20.0"}}
+	w := NewWatcher(m, func(session, _ string) error {
+		if session == "gone" {
+			return errors.New("session not found")
+		}
+		return nil
+	})
+	s3 := "marketplace://upbound/provider-aws-s3"
+	for _, session := range []string{"gone", "live"} {
+		if err := w.Subscribe(context.Background(), session, s3, "upbound", "provider-aws-s3"); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+
+	for i := range MaxNotifyFailures {
+		if subs := w.Subscriptions("gone"); len(subs) != 1 {
+			t.Fatalf("Subscriptions(gone) after %d failures = %v, want it still subscribed", i, subs)
+		}
+		m.release("upbound/provider-aws-s3", fmt.Sprintf("v1.%d.0", 21+i))
+		w.Poll(context.Background())
+	}
+	if subs := w.Subscriptions("gone"); len(subs) != 0 {
+		t.Errorf("Subscriptions(gone) = %v, want none after %d failed notifications", subs, MaxNotifyFailures)
+	}
+	if subs := w.Subscriptions("live"); len(subs) != 1 {
+		t.Errorf("Subscriptions(live) = %v, want it still subscribed", subs)
+	}
+}
@@ -0,0 +1,86 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package xpkg
+
+import (
+	"path"
+	"strings"
+)
+
+// exampleDirs are the directories examples are kept in, both in package
+// layers (.up/examples) and in package source trees (examples).
+var exampleDirs = []string{".up/examples/", "examples/"}
+
+// Example is an example manifest shipped with a package.
+type Example struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// isExample returns true if the named file is a YAML file in one of the
+// example directories.
+func isExample(name string) bool {
+	ext := path.Ext(name)
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+	for _, d := range exampleDirs {
+		if strings.HasPrefix(name, d) || strings.Contains(name, "/"+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExamplesFor returns the content of every example that contains an object
+// of the supplied group and kind.
+func (p *Package) ExamplesFor(group, kind string) []string {
+	out := []string{}
+	for _, ex := range p.Examples {
+		match := false
+		_ = decodeAll(strings.NewReader(ex.Content), func(obj map[string]any) {
+			g, k := GroupKind(obj)
+			match = match || (g == group && k == kind)
+		})
+		if match {
+			out = append(out, ex.Content)
+		}
+	}
+	return out
+}
+
+// Definition returns the CRD or XRD that defines the supplied group and kind.
+func (p *Package) Definition(group, kind string) (map[string]any, bool) {
+	for _, l := range [][]map[string]any{p.CRDs, p.XRDs} {
+		for _, obj := range l {
+			if str(obj, "spec", "group") == group && str(obj, "spec", "names", "kind") == kind {
+				return obj, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Composition returns the named composition.
+func (p *Package) Composition(name string) (map[string]any, bool) {
+	for _, obj := range p.Compositions {
+		if str(obj, "metadata", "name") == name {
+			return obj, true
+		}
+	}
+	return nil, false
+}
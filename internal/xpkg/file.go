@@ -0,0 +1,195 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package xpkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MetaFile is the name of the file holding the package meta object in a
+// package source tree.
+const MetaFile = "crossplane.yaml"
+
+// Read reads a package from disk. The path may be a .xpkg file, as built by
+// `up xpkg build` or `crossplane xpkg build`, or a directory holding either an
+// unpacked package.yaml or a package source tree with a crossplane.yaml.
+func Read(path string) (*Package, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package: %w", err)
+	}
+	if fi.IsDir() {
+		return readDir(path)
+	}
+	return readFile(path)
+}
+
+// IsPackageDir returns true if the directory looks like an unpacked package
+// or a package source tree.
+func IsPackageDir(dir string) bool {
+	for _, f := range []string{StreamFile, MetaFile} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readFile reads a .xpkg image tarball. Every file in the tarball that is
+// itself a layer is searched for the package stream and examples.
+func readFile(path string) (*Package, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Read only.
+
+	pkg := &Package{}
+	var examples []Example
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || strings.HasSuffix(hdr.Name, ".json") {
+			continue
+		}
+		l, err := readLayer(tr)
+		if isNotLayer(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		examples = append(examples, l.Examples...)
+		if l.Meta != nil && pkg.Meta == nil {
+			pkg = l
+		}
+	}
+	if pkg.Meta == nil {
+		return nil, fmt.Errorf("%s is not a Crossplane package: no layer contains %s", path, StreamFile)
+	}
+	pkg.Examples = examples
+	return pkg, nil
+}
+
+// isNotLayer returns true if the error indicates a file was not a tarball.
+func isNotLayer(err error) bool {
+	return errors.Is(err, tar.ErrHeader) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// readDir reads an unpacked package or a package source tree. Symbolic links
+// inside the directory are not followed, so a package cannot read files from
+// outside it.
+func readDir(dir string) (*Package, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+	stream := filepath.Join(dir, StreamFile)
+	if fi, err := os.Lstat(stream); err == nil && fi.Mode().IsRegular() {
+		f, err := os.Open(stream) //nolint:gosec // Reading user supplied packages is the point.
+		if err != nil {
+			return nil, fmt.Errorf("failed to open package: %w", err)
+		}
+		defer f.Close() //nolint:errcheck // Read only.
+		pkg, err := Parse(f)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Examples, err = readExamples(dir)
+		return pkg, err
+	}
+
+	pkg := &Package{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if ext := filepath.Ext(rel); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		b, err := os.ReadFile(path) //nolint:gosec // Reading user supplied packages is the point.
+		if err != nil {
+			return err
+		}
+		if isExample(rel) {
+			pkg.Examples = append(pkg.Examples, Example{Path: rel, Content: string(b)})
+			return nil
+		}
+		if err := decodeAll(bytes.NewReader(b), pkg.add); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+	if pkg.Meta == nil {
+		return nil, fmt.Errorf("%s is not a Crossplane package: no %s found", dir, MetaFile)
+	}
+	return pkg, nil
+}
+
+// readExamples reads the examples of an unpacked package. Like readDir it
+// skips symbolic links.
+func readExamples(dir string) ([]Example, error) {
+	var out []Example
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if !isExample(rel) {
+			return nil
+		}
+		b, err := os.ReadFile(path) //nolint:gosec // Reading user supplied packages is the point.
+		if err != nil {
+			return err
+		}
+		out = append(out, Example{Path: rel, Content: string(b)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read examples: %w", err)
+	}
+	return out, nil
+}
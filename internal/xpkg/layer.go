@@ -0,0 +1,131 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package xpkg
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNoPackageStream is returned when a layer does not contain a package
+// stream.
+var ErrNoPackageStream = errors.New("layer does not contain " + StreamFile)
+
+// gzipMagic is the header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ParseLayer reads an image layer, which may or may not be gzip compressed,
+// and parses the package stream and any examples it contains. It returns
+// ErrNoPackageStream if the layer has no package.yaml.
+func ParseLayer(r io.Reader) (*Package, error) {
+	pkg, err := readLayer(r)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Meta == nil {
+		return nil, ErrNoPackageStream
+	}
+	return pkg, nil
+}
+
+// readLayer reads the package stream and examples in a layer. The returned
+// package has no meta object if the layer does not contain a package stream.
+func readLayer(r io.Reader) (*Package, error) {
+	pkg := &Package{}
+	var examples []Example
+	err := walkLayer(r, func(name string, r io.Reader) error {
+		if name == StreamFile {
+			p, err := Parse(r)
+			if err != nil {
+				return err
+			}
+			pkg = p
+			return nil
+		}
+		ex, ok, err := readExample(name, r)
+		if ok {
+			examples = append(examples, ex)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	pkg.Examples = append(pkg.Examples, examples...)
+	return pkg, nil
+}
+
+// walkLayer calls fn for every regular file in a layer.
+func walkLayer(r io.Reader, fn func(name string, r io.Reader) error) error {
+	tr, err := layerReader(r)
+	if err != nil {
+		return err
+	}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read layer: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(path.Clean(strings.TrimPrefix(hdr.Name, "/")), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readExample reads a file as an example if it is a YAML file in one of the
+// directories packages keep examples in.
+func readExample(name string, r io.Reader) (Example, bool, error) {
+	if !isExample(name) {
+		return Example{}, false, nil
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Example{}, true, fmt.Errorf("failed to read example %s: %w", name, err)
+	}
+	return Example{Path: name, Content: string(b)}, true, nil
+}
+
+// layerReader returns a tar reader for a layer, transparently decompressing
+// it if required.
+func layerReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read layer: %w", err)
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return tar.NewReader(br), nil
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress layer: %w", err)
+	}
+	return tar.NewReader(gz), nil
+}
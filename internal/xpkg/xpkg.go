@@ -0,0 +1,312 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package xpkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/upbound/marketplace-mcp-server/internal/marketplace"
+)
+
+const (
+	// StreamFile is the name of the file in a package layer that holds the
+	// package stream.
+	StreamFile = "package.yaml"
+
+	// AnnotationKey is the OCI layer annotation used to identify xpkg
+	// layers.
+	AnnotationKey = "io.crossplane.xpkg"
+
+	// PackageAnnotation is the AnnotationKey value of the layer holding the
+	// package stream.
+	PackageAnnotation = "base"
+
+	metaGroup       = "meta.pkg.crossplane.io"
+	apiextGroup     = "apiextensions.k8s.io"
+	crossplaneGroup = "apiextensions.crossplane.io"
+	kindCRD         = "CustomResourceDefinition"
+	kindXRD         = "CompositeResourceDefinition"
+	kindComposition = "Composition"
+
+	annotationDescription = "meta.crossplane.io/description"
+	annotationReadme      = "meta.crossplane.io/readme"
+	annotationSource      = "meta.crossplane.io/source"
+	annotationLicense     = "meta.crossplane.io/license"
+)
+
+// Package is the parsed contents of a Crossplane package.
+type Package struct {
+	// Digest of the package image, if known.
+	Digest string
+
+	Meta         map[string]any
+	CRDs         []map[string]any
+	XRDs         []map[string]any
+	Compositions []map[string]any
+	Examples     []Example
+}
+
+// Parse parses a package stream, i.e. the multi-document package.yaml found
+// in the base layer of an xpkg.
+func Parse(r io.Reader) (*Package, error) {
+	pkg := &Package{}
+	if err := decodeAll(r, pkg.add); err != nil {
+		return nil, fmt.Errorf("failed to decode package stream: %w", err)
+	}
+	if pkg.Meta == nil {
+		return nil, fmt.Errorf("package stream does not contain a %s object", metaGroup)
+	}
+	return pkg, nil
+}
+
+// add sorts an object into the package by its group and kind. Objects that
+// are not part of the package surface are ignored.
+func (p *Package) add(obj map[string]any) {
+	group, kind := GroupKind(obj)
+	switch {
+	case group == metaGroup && p.Meta == nil:
+		p.Meta = obj
+	case group == apiextGroup && kind == kindCRD:
+		p.CRDs = append(p.CRDs, obj)
+	case group == crossplaneGroup && kind == kindXRD:
+		p.XRDs = append(p.XRDs, obj)
+	case group == crossplaneGroup && kind == kindComposition:
+		p.Compositions = append(p.Compositions, obj)
+	}
+}
+
+// Name returns the name of the package from its meta object.
+func (p *Package) Name() string {
+	return str(p.Meta, "metadata", "name")
+}
+
+// Type returns the package type (provider, configuration or function) from
+// its meta object.
+func (p *Package) Type() marketplace.PackageType {
+	_, kind := GroupKind(p.Meta)
+	return marketplace.PackageType(strings.ToLower(kind))
+}
+
+// Resources summarises the package in the same form the marketplace uses for
+// indexed packages.
+func (p *Package) Resources(account, repository string) *marketplace.PackageResources {
+	res := &marketplace.PackageResources{
+		PackageMeta: marketplace.PackageMeta{
+			Account:     account,
+			Repository:  repository,
+			RepoKey:     account + "/" + repository,
+			Name:        p.Name(),
+			PackageType: p.Type(),
+			PkgDigest:   p.Digest,
+		},
+		CRDs:         make([]marketplace.CRDMeta, 0, len(p.CRDs)),
+		XRDs:         make([]marketplace.XRDMeta, 0, len(p.XRDs)),
+		Compositions: make([]marketplace.CompositionMeta, 0, len(p.Compositions)),
+	}
+	for _, crd := range p.CRDs {
+		res.CRDs = append(res.CRDs, crdMeta(crd))
+	}
+	for _, xrd := range p.XRDs {
+		res.XRDs = append(res.XRDs, xrdMeta(xrd))
+	}
+	for _, comp := range p.Compositions {
+		res.Compositions = append(res.Compositions, compositionMeta(comp))
+	}
+	return res
+}
+
+// Metadata describes the package in the same form the marketplace uses for
+// package metadata.
+func (p *Package) Metadata(account, repository, version string) *marketplace.PackageMetadata {
+	md := &marketplace.PackageMetadata{
+		Account:       account,
+		Repository:    repository,
+		Name:          p.Name(),
+		Version:       version,
+		Type:          string(p.Type()),
+		Description:   p.Description(),
+		Homepage:      p.annotation(annotationSource),
+		License:       p.annotation(annotationLicense),
+		Documentation: p.Readme(),
+		Versions:      []string{version},
+		LatestVersion: version,
+	}
+	deps, _ := Value(p.Meta, "spec", "dependsOn").([]any)
+	for _, d := range deps {
+		dep, _ := d.(map[string]any)
+		name := str(dep, "package")
+		for _, k := range []string{"provider", "configuration", "function"} {
+			if name == "" {
+				name = str(dep, k)
+			}
+		}
+		md.Dependencies = append(md.Dependencies, marketplace.Dependency{Name: name, Version: str(dep, "version")})
+	}
+	for _, crd := range p.CRDs {
+		m := crdMeta(crd)
+		md.CRDs = append(md.CRDs, marketplace.CRD{
+			Name:     str(crd, "metadata", "name"),
+			Group:    m.Group,
+			Version:  m.StorageVersion,
+			Kind:     m.Kind,
+			Plural:   str(crd, "spec", "names", "plural"),
+			Singular: str(crd, "spec", "names", "singular"),
+		})
+	}
+	return md
+}
+
+// Description returns the description annotation of the package, if any.
+func (p *Package) Description() string {
+	return p.annotation(annotationDescription)
+}
+
+// Readme returns the readme annotation of the package, if any.
+func (p *Package) Readme() string {
+	return p.annotation(annotationReadme)
+}
+
+func (p *Package) annotation(key string) string {
+	return str(p.Meta, "metadata", "annotations", key)
+}
+
+// GroupKind returns the API group and kind of an object.
+func GroupKind(obj map[string]any) (string, string) {
+	apiVersion, kind := str(obj, "apiVersion"), str(obj, "kind")
+	group := ""
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+	}
+	return group, kind
+}
+
+func crdMeta(obj map[string]any) marketplace.CRDMeta {
+	m := marketplace.CRDMeta{
+		Group:  str(obj, "spec", "group"),
+		Kind:   str(obj, "spec", "names", "kind"),
+		Scope:  str(obj, "spec", "scope"),
+		Plural: str(obj, "spec", "names", "plural"),
+	}
+	short, _ := Value(obj, "spec", "names", "shortNames").([]any)
+	for _, s := range short {
+		if s, ok := s.(string); ok {
+			m.ShortNames = append(m.ShortNames, s)
+		}
+	}
+	m.Versions, m.StorageVersion = versions(obj, "storage")
+	return m
+}
+
+func xrdMeta(obj map[string]any) marketplace.XRDMeta {
+	m := marketplace.XRDMeta{
+		Group:  str(obj, "spec", "group"),
+		Kind:   str(obj, "spec", "names", "kind"),
+		Plural: str(obj, "spec", "names", "plural"),
+	}
+	m.Versions, m.ReferenceableVersion = versions(obj, "referenceable")
+	return m
+}
+
+func compositionMeta(obj map[string]any) marketplace.CompositionMeta {
+	res, _ := Value(obj, "spec", "resources").([]any)
+	return marketplace.CompositionMeta{
+		Name:          str(obj, "metadata", "name"),
+		ResourceCount: len(res),
+		XrdAPIVersion: str(obj, "spec", "compositeTypeRef", "apiVersion"),
+		XrdKind:       str(obj, "spec", "compositeTypeRef", "kind"),
+	}
+}
+
+// versions returns the names of spec.versions and the name of the first
+// version that has the supplied boolean flag set.
+func versions(obj map[string]any, flag string) ([]string, string) {
+	list, _ := Value(obj, "spec", "versions").([]any)
+	names := make([]string, 0, len(list))
+	selected := ""
+	for _, v := range list {
+		vo, _ := v.(map[string]any)
+		name := str(vo, "name")
+		names = append(names, name)
+		if ok, _ := vo[flag].(bool); ok && selected == "" {
+			selected = name
+		}
+	}
+	return names, selected
+}
+
+// Value returns the value at the supplied path of object fields, or nil if
+// any field along the path is missing.
+func Value(obj map[string]any, path ...string) any {
+	var cur any = obj
+	for _, f := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[f]
+	}
+	return cur
+}
+
+func str(obj map[string]any, path ...string) string {
+	s, _ := Value(obj, path...).(string)
+	return s
+}
+
+// decodeAll decodes every non-empty document in a YAML stream and passes it
+// to fn.
+func decodeAll(r io.Reader, fn func(map[string]any)) error {
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc map[string]any
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj, err := normalize(doc)
+		if err != nil {
+			return err
+		}
+		fn(obj)
+	}
+}
+
+// normalize round trips a decoded YAML document through JSON so that it only
+// contains JSON compatible types.
+func normalize(doc map[string]any) (map[string]any, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert object to JSON: %w", err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,225 @@
+// /*
+// Copyright 2025 The Upbound Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// */
+
+package xpkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	meta = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Configuration
+metadata:
+  name: platform-ref-test
+  annotations:
+    meta.crossplane.io/readme: Test configuration.
+spec:
+  dependsOn:
+  - provider: xpkg.upbound.io/upbound/provider-aws-s3
+    version: ">=v1.0.0"
+`
+	xrd = `apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: xbuckets.test.io
+spec:
+  group: test.io
+  names:
+    kind: XBucket
+  versions:
+  - name: v1alpha1
+    referenceable: true
+`
+	composition = `apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: xbuckets-aws
+spec:
+  compositeTypeRef:
+    apiVersion: test.io/v1alpha1
+    kind: XBucket
+  resources:
+  - name: bucket
+`
+	example = `apiVersion: test.io/v1alpha1
+kind: XBucket
+metadata:
+  name: example
+`
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tarball(t *testing.T, compress bool, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	stream := meta + "---\n" + xrd + "---\n" + composition
+
+	sourceTree := t.TempDir()
+	writeFiles(t, sourceTree, map[string]string{
+		"crossplane.yaml":             meta,
+		"apis/bucket/definition.yaml": xrd,
+		"apis/bucket/aws.yaml":        composition,
+		"examples/bucket.yaml":        example,
+		"README.md":                   "not yaml",
+	})
+
+	unpacked := t.TempDir()
+	writeFiles(t, unpacked, map[string]string{
+		"package.yaml":                stream,
+		".up/examples/xbucket.yaml":   example,
+		".up/examples/unrelated.yaml": "apiVersion: v1\nkind: ConfigMap\n",
+	})
+
+	file := filepath.Join(t.TempDir(), "test.xpkg")
+	image := tarball(t, false, map[string][]byte{
+		"manifest.json":  []byte(`[{"Layers":["base.tar.gz","upbound.tar.gz"]}]`),
+		"config":         []byte(`{"config":{}}`),
+		"base.tar.gz":    tarball(t, true, map[string][]byte{"package.yaml": []byte(stream)}),
+		"upbound.tar.gz": tarball(t, true, map[string][]byte{".up/examples/xbucket.yaml": []byte(example)}),
+	})
+	if err := os.WriteFile(file, image, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"SourceTree": sourceTree,
+		"Unpacked":   unpacked,
+		"XpkgFile":   file,
+	}
+
+	for name, path := range cases {
+		t.Run(name, func(t *testing.T) {
+			pkg, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if pkg.Name() != "platform-ref-test" || pkg.Type() != "configuration" {
+				t.Errorf("unexpected meta: name %q type %q", pkg.Name(), pkg.Type())
+			}
+			if pkg.Readme() != "Test configuration." {
+				t.Errorf("Readme() = %q", pkg.Readme())
+			}
+			res := pkg.Resources("local", pkg.Name())
+			if len(res.XRDs) != 1 || res.XRDs[0].ReferenceableVersion != "v1alpha1" {
+				t.Errorf("unexpected XRDs: %+v", res.XRDs)
+			}
+			if len(res.Compositions) != 1 || res.Compositions[0].XrdKind != "XBucket" || res.Compositions[0].ResourceCount != 1 {
+				t.Errorf("unexpected compositions: %+v", res.Compositions)
+			}
+			if _, ok := pkg.Definition("test.io", "XBucket"); !ok {
+				t.Error("Definition() did not find XBucket")
+			}
+			if got := pkg.ExamplesFor("test.io", "XBucket"); len(got) != 1 {
+				t.Errorf("ExamplesFor() returned %d examples, want 1", len(got))
+			}
+			md := pkg.Metadata("local", pkg.Name(), "local")
+			if len(md.Dependencies) != 1 || md.Dependencies[0].Name != "xpkg.upbound.io/upbound/provider-aws-s3" {
+				t.Errorf("unexpected dependencies: %+v", md.Dependencies)
+			}
+		})
+	}
+}
+
+func TestReadSkipsSymlinks(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.yaml")
+	if err := os.WriteFile(outside, []byte("secret: value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sourceTree := t.TempDir()
+	writeFiles(t, sourceTree, map[string]string{
+		"crossplane.yaml":      meta,
+		"examples/bucket.yaml": example,
+	})
+	unpacked := t.TempDir()
+	writeFiles(t, unpacked, map[string]string{
+		"package.yaml":              meta,
+		".up/examples/xbucket.yaml": example,
+	})
+	for _, l := range []string{
+		filepath.Join(sourceTree, "examples", "leak.yaml"),
+		filepath.Join(unpacked, ".up", "examples", "leak.yaml"),
+	} {
+		if err := os.Symlink(outside, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(sourceTree, "examples", "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"SourceTree": sourceTree,
+		"Unpacked":   unpacked,
+	}
+	for name, path := range cases {
+		t.Run(name, func(t *testing.T) {
+			pkg, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(pkg.Examples) != 1 {
+				t.Errorf("Read() returned %d examples, want 1: %+v", len(pkg.Examples), pkg.Examples)
+			}
+		})
+	}
+}